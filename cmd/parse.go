@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"dumpall-go/internal/dsstore"
+	"dumpall-go/internal/git"
+	"dumpall-go/internal/svn"
+	"dumpall-go/pkg/sqlite"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	parseFormat string
+	parseExport string
+)
+
+// parsedEntry 表示离线解析得到的一条记录
+type parsedEntry struct {
+	Source  string `json:"source"`
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mtime,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+// 离线解析时按文件名识别的泄露文件
+var parseFileNames = map[string]bool{
+	".DS_Store": true,
+	"index":     true,
+	"wc.db":     true,
+	"entries":   true,
+}
+
+// 支持的输出格式
+var parseFormats = map[string]bool{
+	"text": true,
+	"json": true,
+	"csv":  true,
+}
+
+// parseCmd 离线解析本地的泄露文件
+var parseCmd = &cobra.Command{
+	Use:   "parse <file-or-dir>",
+	Short: "离线解析本地的 .DS_Store、.git/index、.svn/wc.db、.svn/entries 文件",
+	Long: `离线解析已经保存到本地的泄露文件，不发送任何网络请求。
+可以指定单个文件，也可以指定目录递归解析其中的以下文件：
+  .DS_Store
+  .git/index
+  .svn/wc.db
+  .svn/entries`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 先检查输出格式，避免创建空的导出文件
+		if !parseFormats[parseFormat] {
+			return fmt.Errorf("不支持的输出格式: %s", parseFormat)
+		}
+
+		entries, err := parseLocal(args[0])
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if parseExport != "" {
+			f, err := os.Create(parseExport)
+			if err != nil {
+				return fmt.Errorf("创建导出文件失败: %v", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := writeParsed(w, entries, parseFormat); err != nil {
+			return err
+		}
+
+		if parseExport != "" {
+			successColor.Printf("共解析 %d 条记录，已导出到 %s\n", len(entries), parseExport)
+		}
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
+
+func init() {
+	parseCmd.Flags().SortFlags = false
+	parseCmd.Flags().StringVar(&parseFormat, "format", "text", "输出格式 (text, json, csv)")
+	parseCmd.Flags().StringVarP(&parseExport, "export", "e", "", "导出到文件，默认输出到终端")
+
+	RootCmd.AddCommand(parseCmd)
+}
+
+// parseLocal 解析单个文件或递归解析目录
+func parseLocal(target string) ([]parsedEntry, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标失败: %v", err)
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		return parseData(target, "", data)
+	}
+
+	var entries []parsedEntry
+	err = filepath.WalkDir(target, func(p string, de fs.DirEntry, err error) error {
+		if err != nil || de.IsDir() || !parseFileNames[de.Name()] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(target, p)
+		if err != nil {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			errorColor.Printf("读取文件失败: %v\n", err)
+			return nil
+		}
+
+		parsed, err := parseData(p, prefixFor(filepath.ToSlash(rel)), data)
+		if err != nil {
+			errorColor.Printf("解析 %s 失败: %v\n", p, err)
+			return nil
+		}
+		entries = append(entries, parsed...)
+		return nil
+	})
+
+	return entries, err
+}
+
+// prefixFor 根据泄露文件的位置计算其中记录对应的目录前缀
+func prefixFor(rel string) string {
+	dir := path.Dir(rel)
+	switch path.Base(dir) {
+	case ".git", ".svn":
+		dir = path.Dir(dir)
+	}
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// parseData 根据文件内容识别文件类型并解析
+func parseData(source string, prefix string, data []byte) ([]parsedEntry, error) {
+	var entries []parsedEntry
+	add := func(kind, name, typ string, size int64, mtime time.Time, hash string) {
+		e := parsedEntry{
+			Source: source,
+			Kind:   kind,
+			Path:   path.Join(prefix, name),
+			Type:   typ,
+			Size:   size,
+			Hash:   hash,
+		}
+		if !mtime.IsZero() {
+			e.ModTime = mtime.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, e)
+	}
+
+	switch {
+	case len(data) >= 8 && string(data[4:8]) == "Bud1":
+		ds, err := dsstore.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range ds.Records {
+			add("dsstore", rec.Name, rec.Type, rec.Size, rec.ModTime(), "")
+		}
+
+	case bytes.HasPrefix(data, []byte("DIRC")):
+		idx, err := git.ParseIndex(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range idx.Entries {
+			add("git-index", e.Name, "file", int64(e.Size), e.ModTime, e.SHA1)
+		}
+
+	case bytes.HasPrefix(data, []byte(sqlite.Magic)):
+		nodes, err := svn.ParseWcDB(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			add("svn-wcdb", n.Path, n.Kind, n.Size, n.ModTime, n.Checksum)
+		}
+
+	default:
+		nodes, err := svn.ParseEntries(data)
+		if err != nil {
+			return nil, fmt.Errorf("无法识别的文件类型: %v", err)
+		}
+		for _, n := range nodes {
+			add("svn-entries", n.Path, n.Kind, n.Size, n.ModTime, n.Checksum)
+		}
+	}
+
+	return entries, nil
+}
+
+// writeParsed 按指定格式输出解析结果
+func writeParsed(w io.Writer, entries []parsedEntry, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if entries == nil {
+			entries = []parsedEntry{}
+		}
+		return enc.Encode(entries)
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"source", "kind", "path", "type", "size", "mtime", "hash"})
+		for _, e := range entries {
+			cw.Write([]string{e.Source, e.Kind, e.Path, e.Type, strconv.FormatInt(e.Size, 10), e.ModTime, e.Hash})
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KIND\tTYPE\tSIZE\tMTIME\tHASH\tPATH")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", e.Kind, e.Type, e.Size, e.ModTime, e.Hash, e.Path)
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("不支持的输出格式: %s", format)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyFixture 把其他包的测试文件复制到临时目录中的 name
+func copyFixture(t *testing.T, src, dir, name string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseLocal(t *testing.T) {
+	dir := t.TempDir()
+	copyFixture(t, "../internal/git/testdata/index.v2", dir, ".git/index")
+	copyFixture(t, "../internal/svn/testdata/entries.v10", dir, "old/.svn/entries")
+	copyFixture(t, "../internal/svn/testdata/wc.db", dir, "app/.svn/wc.db")
+	os.WriteFile(filepath.Join(dir, "index"), []byte("not a leak file"), 0644)
+
+	entries, err := parseLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := make(map[string]int)
+	paths := make(map[string]bool)
+	for _, e := range entries {
+		count[e.Kind]++
+		paths[e.Path] = true
+	}
+	if count["git-index"] != 3 || count["svn-entries"] != 4 || count["svn-wcdb"] != 103 {
+		t.Errorf("各类记录数 = %v", count)
+	}
+	// 记录的路径相对于泄露文件所在的工作区
+	for _, p := range []string{"README.md", "src/lib/中文.txt", "old/config.php", "app/src/a.php"} {
+		if !paths[p] {
+			t.Errorf("缺少记录 %s", p)
+		}
+	}
+
+	// 单个文件不加目录前缀
+	entries, err = parseLocal(filepath.Join(dir, ".git", "index"))
+	if err != nil || len(entries) != 3 || entries[0].Path != "README.md" || entries[0].ModTime != "2024-01-02T03:04:05Z" {
+		t.Errorf("parseLocal(.git/index) = %+v, %v", entries, err)
+	}
+
+	if _, err := parseLocal(filepath.Join(dir, "index")); err == nil {
+		t.Error("无法识别的文件应返回错误")
+	}
+}
+
+func TestPrefixFor(t *testing.T) {
+	tests := []struct {
+		rel  string
+		want string
+	}{
+		{".DS_Store", ""},
+		{"img/.DS_Store", "img"},
+		{".git/index", ""},
+		{"sub/.svn/entries", "sub"},
+		{"a/b/.svn/wc.db", "a/b"},
+	}
+	for _, tt := range tests {
+		if got := prefixFor(tt.rel); got != tt.want {
+			t.Errorf("prefixFor(%q) = %q, want %q", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestWriteParsed(t *testing.T) {
+	entries := []parsedEntry{{Source: "x/.git/index", Kind: "git-index", Path: "a,b.txt", Type: "file", Size: 3, Hash: "abc"}}
+
+	var buf bytes.Buffer
+	if err := writeParsed(&buf, entries, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "source,kind,path,type,size,mtime,hash\nx/.git/index,git-index,\"a,b.txt\",file,3,,abc\n"; buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeParsed(&buf, nil, "json"); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("没有记录时 json = %q, %v", buf.String(), err)
+	}
+	buf.Reset()
+	writeParsed(&buf, entries, "json")
+	var decoded []parsedEntry
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0] != entries[0] {
+		t.Errorf("json = %q, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	writeParsed(&buf, entries, "text")
+	if !strings.Contains(buf.String(), "git-index  file  3") || !strings.HasSuffix(buf.String(), "a,b.txt\n") {
+		t.Errorf("text = %q", buf.String())
+	}
+}
+
+// 不支持的输出格式在创建导出文件之前报错
+func TestParseCmdInvalidFormat(t *testing.T) {
+	export := filepath.Join(t.TempDir(), "out.xml")
+	parseFormat, parseExport = "xml", export
+	defer func() { parseFormat, parseExport = "text", "" }()
+
+	if err := parseCmd.RunE(parseCmd, []string{"../internal/git/testdata/index.v2"}); err == nil {
+		t.Fatal("不支持的格式应返回错误")
+	}
+	if _, err := os.Stat(export); err == nil {
+		t.Error("格式无效时不应创建导出文件")
+	}
+}
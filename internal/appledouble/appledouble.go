@@ -0,0 +1,202 @@
+// Package appledouble 实现 macOS AppleDouble (._ 文件) 中扩展属性的提取
+package appledouble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// 提取到的信息保存到输出目录下的该文件中
+const findingsFile = "appledouble-findings.txt"
+
+// 最多解析的 ._ 文件数量
+const maxFiles = 10000
+
+// 下载单个 ._ 文件的最大长度
+const maxFetchSize = 64 << 20
+
+// Finding 表示从 ._ 文件中提取的一条信息
+type Finding struct {
+	Source string // 来源文件
+	Kind   string // 类型: origin, quarantine, downloaded, user, xattr
+	Detail string // 具体内容
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Kind, f.Source, f.Detail)
+}
+
+// AppleDoubleDumper 解析 .DS_Store 和目录列表中发现的 ._ 文件，提取下载来源和用户名
+type AppleDoubleDumper struct {
+	dumper.BaseDumper
+	// Filter 下载 ._ 文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 其他 dumper 记录的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewAppleDoubleDumper 创建 AppleDoubleDumper 实例
+func NewAppleDoubleDumper() *AppleDoubleDumper {
+	return &AppleDoubleDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "appledouble",
+			Description: "提取 ._ 文件中的下载来源和用户名",
+		},
+	}
+}
+
+// Check 检查是否记录了 ._ 文件
+func (d *AppleDoubleDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	return len(d.names()) > 0, nil
+}
+
+// names 返回已记录的 ._ 文件路径
+func (d *AppleDoubleDumper) names() []string {
+	var names []string
+	for _, p := range d.Paths.List() {
+		if base := path.Base(p); strings.HasPrefix(base, "._") && len(base) > 2 {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// Execute 执行下载操作
+func (d *AppleDoubleDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	names := d.names()
+	if len(names) == 0 {
+		return nil
+	}
+
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	if len(names) > maxFiles {
+		names = names[:maxFiles]
+	}
+
+	// 并发下载和解析，结果按文件顺序汇总
+	results := make([][]Finding, len(names))
+	pool := dumper.NewPool(workers)
+	for i, name := range names {
+		pool.Go(func() {
+			data, ok := d.load(client, baseline, targetURL, outdir, name, progressCb)
+			if !ok {
+				return
+			}
+			f, err := Parse(data)
+			if err != nil {
+				return
+			}
+			results[i] = Analyze(name, f)
+		})
+	}
+	pool.Wait()
+
+	var findings []Finding
+	for _, r := range results {
+		findings = append(findings, r...)
+	}
+
+	if len(findings) > 0 {
+		var buf bytes.Buffer
+		for _, f := range findings {
+			buf.WriteString(f.String())
+			buf.WriteByte('\n')
+		}
+		if localPath, err := dumper.SaveFile(outdir, findingsFile, &buf); err == nil && progressCb != nil {
+			progressCb(targetURL+findingsFile, http.StatusOK, localPath)
+		}
+	}
+
+	return nil
+}
+
+// Analyze 汇总 ._ 文件中的下载来源、隔离信息和用户名
+func Analyze(source string, f *File) []Finding {
+	var findings []Finding
+	add := func(kind, detail string) {
+		findings = append(findings, Finding{Source: source, Kind: kind, Detail: detail})
+	}
+
+	for _, origin := range f.WhereFroms() {
+		add("origin", origin)
+	}
+	if t := f.DownloadedDate(); !t.IsZero() {
+		add("downloaded", t.UTC().Format(time.RFC3339))
+	}
+	if q, ok := f.Quarantine(); ok {
+		detail := q.Agent
+		if !q.Time.IsZero() {
+			detail += " " + q.Time.UTC().Format(time.RFC3339)
+		}
+		if q.UUID != "" {
+			detail += " " + q.UUID
+		}
+		add("quarantine", strings.TrimSpace(detail))
+	}
+	for _, user := range f.Usernames() {
+		add("user", user)
+	}
+	for _, a := range f.Attrs {
+		add("xattr", fmt.Sprintf("%s (%d 字节)", a.Name, len(a.Value)))
+	}
+	return findings
+}
+
+// load 优先读取其他 dumper 已经保存的 ._ 文件，不存在时下载
+func (d *AppleDoubleDumper) load(client *http.Client, baseline *soft404.Baseline, targetURL string, outdir string, name string, progressCb dumper.ProgressCallback) ([]byte, bool) {
+	localPath, err := dumper.SafePath(outdir, name)
+	if err != nil {
+		return nil, false
+	}
+	if data, err := os.ReadFile(localPath); err == nil {
+		return data, isAppleDouble(data)
+	}
+
+	if !d.Filter.Match(filter.Entry{Path: name, Size: -1}) {
+		return nil, false
+	}
+	fileURL := targetURL + dumper.EscapePath(name)
+	data, ok := dumper.Fetch(client, baseline, fileURL, maxFetchSize, progressCb)
+	if !ok || !isAppleDouble(data) {
+		return nil, false
+	}
+	dumper.SaveData(outdir, name, data, fileURL, progressCb)
+	return data, true
+}
+
+// isAppleDouble 检查文件头，排除返回 HTML 的自定义404页面
+func isAppleDouble(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	magic := binary.BigEndian.Uint32(data)
+	return magic == MagicDouble || magic == MagicSingle
+}
+
+// Validate 验证URL是否有效
+func (d *AppleDoubleDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,259 @@
+package appledouble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 文件头
+const (
+	MagicDouble = 0x00051607
+	MagicSingle = 0x00051600
+)
+
+// 条目编号
+const (
+	EntryDataFork     = 1
+	EntryResourceFork = 2
+	EntryRealName     = 3
+	EntryFinderInfo   = 9
+)
+
+// 扩展属性名
+const (
+	AttrWhereFroms     = "com.apple.metadata:kMDItemWhereFroms"
+	AttrQuarantine     = "com.apple.quarantine"
+	AttrDownloadedDate = "com.apple.metadata:kMDItemDownloadedDate"
+)
+
+// Finder Info 条目中 32 字节 Finder 信息和 2 字节填充之后是扩展属性头
+const (
+	finderInfoSize  = 32
+	attrHeaderStart = finderInfoSize + 2
+	attrHeaderSize  = 36
+	maxEntries      = 1024
+)
+
+// Entry 表示 AppleDouble 头中的一个条目
+type Entry struct {
+	ID     uint32
+	Offset uint32
+	Length uint32
+}
+
+// Attr 表示一个扩展属性
+type Attr struct {
+	Name  string
+	Value []byte
+}
+
+// File 表示解析后的 AppleDouble 文件
+type File struct {
+	Entries  []Entry
+	RealName string
+	Attrs    []Attr
+}
+
+// Parse 解析 AppleDouble (._ 文件) 或 AppleSingle 文件
+func Parse(data []byte) (*File, error) {
+	if len(data) < 26 {
+		return nil, fmt.Errorf("文件长度不足")
+	}
+	magic := binary.BigEndian.Uint32(data)
+	if magic != MagicDouble && magic != MagicSingle {
+		return nil, fmt.Errorf("无效的 AppleDouble 文件头: %08x", magic)
+	}
+
+	num := int(binary.BigEndian.Uint16(data[24:]))
+	if num > maxEntries || 26+num*12 > len(data) {
+		return nil, fmt.Errorf("条目数量异常: %d", num)
+	}
+
+	f := &File{}
+	for i := 0; i < num; i++ {
+		b := data[26+i*12:]
+		e := Entry{
+			ID:     binary.BigEndian.Uint32(b),
+			Offset: binary.BigEndian.Uint32(b[4:]),
+			Length: binary.BigEndian.Uint32(b[8:]),
+		}
+		if uint64(e.Offset)+uint64(e.Length) > uint64(len(data)) {
+			continue
+		}
+		f.Entries = append(f.Entries, e)
+
+		switch e.ID {
+		case EntryRealName:
+			f.RealName = string(data[e.Offset : e.Offset+e.Length])
+		case EntryFinderInfo:
+			// 扩展属性超出 Finder Info 条目的范围，按整个文件解析偏移
+			if e.Length >= attrHeaderStart+attrHeaderSize {
+				f.Attrs = parseAttrs(data, int(e.Offset)+attrHeaderStart)
+			}
+		}
+	}
+	return f, nil
+}
+
+// parseAttrs 解析 macOS 写入 Finder Info 条目的扩展属性，属性值的偏移相对于文件开头
+func parseAttrs(data []byte, start int) []Attr {
+	if start+attrHeaderSize > len(data) || string(data[start:start+4]) != "ATTR" {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(data[start+34:]))
+
+	var attrs []Attr
+	off := start + attrHeaderSize
+	for i := 0; i < count && off+11 <= len(data); i++ {
+		valueOff := binary.BigEndian.Uint32(data[off:])
+		valueLen := binary.BigEndian.Uint32(data[off+4:])
+		nameLen := int(data[off+10])
+		if off+11+nameLen > len(data) {
+			break
+		}
+		name := strings.TrimRight(string(data[off+11:off+11+nameLen]), "\x00")
+
+		// 每个属性条目按 4 字节对齐
+		off += (11 + nameLen + 3) &^ 3
+
+		if uint64(valueOff)+uint64(valueLen) > uint64(len(data)) {
+			continue
+		}
+		attrs = append(attrs, Attr{Name: name, Value: data[valueOff : valueOff+valueLen]})
+	}
+	return attrs
+}
+
+// Attr 返回指定名称的扩展属性
+func (f *File) Attr(name string) ([]byte, bool) {
+	for _, a := range f.Attrs {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// WhereFroms 返回文件的下载来源，通常是下载地址和来源页面，邮件附件则是发件人和邮件链接
+func (f *File) WhereFroms() []string {
+	value, ok := f.Attr(AttrWhereFroms)
+	if !ok {
+		return nil
+	}
+	v, err := decodePlist(value)
+	if err != nil {
+		return nil
+	}
+	return plistStrings(v)
+}
+
+// DownloadedDate 返回文件的下载时间
+func (f *File) DownloadedDate() time.Time {
+	value, ok := f.Attr(AttrDownloadedDate)
+	if !ok {
+		return time.Time{}
+	}
+	v, err := decodePlist(value)
+	if err != nil {
+		return time.Time{}
+	}
+	if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
+		v = arr[0]
+	}
+	t, _ := v.(time.Time)
+	return t
+}
+
+// Quarantine 表示 com.apple.quarantine 属性，格式为 标志;十六进制时间戳;下载程序;UUID
+type Quarantine struct {
+	Flags string
+	Time  time.Time
+	Agent string
+	UUID  string
+}
+
+// Quarantine 解析文件的隔离属性
+func (f *File) Quarantine() (Quarantine, bool) {
+	value, ok := f.Attr(AttrQuarantine)
+	if !ok {
+		return Quarantine{}, false
+	}
+	parts := strings.Split(strings.TrimRight(string(value), "\x00"), ";")
+	if len(parts) < 3 {
+		return Quarantine{}, false
+	}
+
+	q := Quarantine{Flags: parts[0], Agent: parts[2]}
+	if ts, err := strconv.ParseInt(parts[1], 16, 64); err == nil && ts > 0 {
+		q.Time = time.Unix(ts, 0)
+	}
+	if len(parts) > 3 {
+		q.UUID = parts[3]
+	}
+	return q, true
+}
+
+var (
+	// 本地路径中的用户名
+	homeDirRe = regexp.MustCompile(`(?:/Users|/home)/([A-Za-z0-9._-]+)`)
+	// URL 中的用户信息和邮箱地址
+	urlUserRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://([^/@:\s]+)(?::[^/@\s]*)?@`)
+	emailRe   = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// Usernames 从下载来源和其他文本属性中提取用户名和邮箱地址
+func (f *File) Usernames() []string {
+	var texts []string
+	texts = append(texts, f.WhereFroms()...)
+	for _, a := range f.Attrs {
+		if a.Name == AttrWhereFroms {
+			continue
+		}
+		if v, err := decodePlist(a.Value); err == nil {
+			texts = append(texts, plistStrings(v)...)
+		} else if isText(a.Value) {
+			texts = append(texts, string(a.Value))
+		}
+	}
+
+	var users []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && u != "Shared" && !seen[u] {
+			seen[u] = true
+			users = append(users, u)
+		}
+	}
+	for _, s := range texts {
+		for _, m := range homeDirRe.FindAllStringSubmatch(s, -1) {
+			add(m[1])
+		}
+		if m := urlUserRe.FindStringSubmatch(s); m != nil {
+			add(m[1])
+		} else {
+			for _, m := range emailRe.FindAllString(s, -1) {
+				add(m)
+			}
+		}
+	}
+	return users
+}
+
+// isText 判断属性值是否为可打印文本
+func isText(b []byte) bool {
+	b = bytes.TrimRight(b, "\x00")
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
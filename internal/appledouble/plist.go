@@ -0,0 +1,265 @@
+package appledouble
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf16"
+)
+
+// 二进制 plist 中日期的起点
+var plistEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// 解析嵌套对象的最大深度
+const maxPlistDepth = 32
+
+// bplist 表示一个二进制 plist 文件
+type bplist struct {
+	data       []byte
+	offsetSize int
+	refSize    int
+	offsets    []uint64
+}
+
+// decodePlist 解析扩展属性中常用的二进制 plist，只支持字符串、数字、日期、数据、数组和字典
+func decodePlist(data []byte) (interface{}, error) {
+	if len(data) < 8+32 || string(data[:8]) != "bplist00" {
+		return nil, fmt.Errorf("无效的二进制 plist")
+	}
+
+	trailer := data[len(data)-32:]
+	p := &bplist{
+		data:       data,
+		offsetSize: int(trailer[6]),
+		refSize:    int(trailer[7]),
+	}
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+
+	if p.offsetSize < 1 || p.offsetSize > 8 || p.refSize < 1 || p.refSize > 8 {
+		return nil, fmt.Errorf("无效的 plist 尾部")
+	}
+	// 先检查偏移再用减法比较长度，避免尾部中的大数溢出
+	end := uint64(len(data) - 32)
+	if numObjects == 0 || top >= numObjects || tableOffset >= end ||
+		numObjects > (end-tableOffset)/uint64(p.offsetSize) {
+		return nil, fmt.Errorf("无效的 plist 对象表")
+	}
+
+	p.offsets = make([]uint64, numObjects)
+	for i := range p.offsets {
+		p.offsets[i] = readUint(data[tableOffset+uint64(i*p.offsetSize):], p.offsetSize)
+	}
+	return p.object(top, 0)
+}
+
+// readUint 读取 n 字节的大端无符号整数
+func readUint(b []byte, n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+// object 解析编号为 ref 的对象
+func (p *bplist) object(ref uint64, depth int) (interface{}, error) {
+	if ref >= uint64(len(p.offsets)) || depth > maxPlistDepth {
+		return nil, fmt.Errorf("无效的对象引用: %d", ref)
+	}
+	off := p.offsets[ref]
+	if off >= uint64(len(p.data)) {
+		return nil, fmt.Errorf("对象偏移超出范围: %d", off)
+	}
+
+	marker := p.data[off]
+	typ, info := marker>>4, int(marker&0x0f)
+	off++
+
+	switch typ {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		}
+		return nil, nil
+
+	case 0x1:
+		n := 1 << info
+		if n > 8 {
+			return nil, fmt.Errorf("不支持的整数长度: %d", n)
+		}
+		b, err := p.bytes(off, uint64(n))
+		if err != nil {
+			return nil, err
+		}
+		return int64(readUint(b, n)), nil
+
+	case 0x2:
+		n := 1 << info
+		b, err := p.bytes(off, uint64(n))
+		if err != nil {
+			return nil, err
+		}
+		switch n {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		}
+		return nil, fmt.Errorf("不支持的浮点数长度: %d", n)
+
+	case 0x3:
+		b, err := p.bytes(off, 8)
+		if err != nil {
+			return nil, err
+		}
+		sec := math.Float64frombits(binary.BigEndian.Uint64(b))
+		return plistEpoch.Add(time.Duration(sec * float64(time.Second))), nil
+	}
+
+	count, off, err := p.length(info, off)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case 0x4:
+		return p.bytes(off, count)
+
+	case 0x5:
+		b, err := p.bytes(off, count)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+
+	case 0x6:
+		b, err := p.bytes(off, count*2)
+		if err != nil {
+			return nil, err
+		}
+		u := make([]uint16, count)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(b[i*2:])
+		}
+		return string(utf16.Decode(u)), nil
+
+	case 0xa:
+		refs, err := p.refs(off, count)
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]interface{}, 0, len(refs))
+		for _, r := range refs {
+			v, err := p.object(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+
+	case 0xd:
+		refs, err := p.refs(off, count*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(map[string]interface{}, count)
+		for i := uint64(0); i < count; i++ {
+			k, err := p.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			v, err := p.object(refs[count+i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dict[fmt.Sprint(k)] = v
+		}
+		return dict, nil
+	}
+
+	return nil, fmt.Errorf("不支持的对象类型: %#x", marker)
+}
+
+// length 读取对象的长度，低 4 位为 0xf 时长度保存在其后的整数对象中
+func (p *bplist) length(info int, off uint64) (uint64, uint64, error) {
+	if info != 0x0f {
+		return uint64(info), off, nil
+	}
+	if off >= uint64(len(p.data)) || p.data[off]>>4 != 0x1 {
+		return 0, 0, fmt.Errorf("无效的对象长度")
+	}
+	n := 1 << (p.data[off] & 0x0f)
+	if n > 8 {
+		return 0, 0, fmt.Errorf("无效的对象长度")
+	}
+	b, err := p.bytes(off+1, uint64(n))
+	if err != nil {
+		return 0, 0, err
+	}
+	count := readUint(b, n)
+	if count > uint64(len(p.data)) {
+		return 0, 0, fmt.Errorf("对象长度超出范围: %d", count)
+	}
+	return count, off + 1 + uint64(n), nil
+}
+
+// bytes 返回从 off 开始的 n 字节
+func (p *bplist) bytes(off uint64, n uint64) ([]byte, error) {
+	if off > uint64(len(p.data)) || n > uint64(len(p.data))-off {
+		return nil, fmt.Errorf("对象数据超出范围")
+	}
+	return p.data[off : off+n], nil
+}
+
+// refs 读取数组或字典中的对象引用
+func (p *bplist) refs(off uint64, count uint64) ([]uint64, error) {
+	if count > uint64(len(p.data))/uint64(p.refSize) {
+		return nil, fmt.Errorf("对象数据超出范围")
+	}
+	b, err := p.bytes(off, count*uint64(p.refSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, count)
+	for i := range refs {
+		refs[i] = readUint(b[i*p.refSize:], p.refSize)
+	}
+	return refs, nil
+}
+
+// plistStrings 按顺序返回对象中的所有字符串
+func plistStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, e := range v {
+			out = append(out, plistStrings(e)...)
+		}
+		return out
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var out []string
+		for _, k := range keys {
+			out = append(out, plistStrings(v[k])...)
+		}
+		return out
+	}
+	return nil
+}
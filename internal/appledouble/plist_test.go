@@ -0,0 +1,94 @@
+package appledouble
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// buildPlist 按对象编号顺序拼出二进制 plist，偏移表和引用都使用 1 字节
+func buildPlist(objs ...[]byte) []byte {
+	data := []byte("bplist00")
+	var offsets []byte
+	for _, o := range objs {
+		offsets = append(offsets, byte(len(data)))
+		data = append(data, o...)
+	}
+	tableOffset := len(data)
+	data = append(data, offsets...)
+	return append(data, trailer(1, 1, uint64(len(objs)), 0, uint64(tableOffset))...)
+}
+
+// trailer 生成 32 字节的 plist 尾部
+func trailer(offsetSize, refSize byte, numObjects, top, tableOffset uint64) []byte {
+	t := make([]byte, 32)
+	t[6], t[7] = offsetSize, refSize
+	binary.BigEndian.PutUint64(t[8:], numObjects)
+	binary.BigEndian.PutUint64(t[16:], top)
+	binary.BigEndian.PutUint64(t[24:], tableOffset)
+	return t
+}
+
+// withTrailer 复制 body 并追加尾部
+func withTrailer(body, t []byte) []byte {
+	return append(append([]byte(nil), body...), t...)
+}
+
+func TestDecodePlist(t *testing.T) {
+	data := buildPlist(
+		[]byte{0xd2, 1, 2, 3, 4},             // {name: 3, tags: 4}
+		append([]byte{0x54}, "name"...),      // ASCII 字符串
+		append([]byte{0x54}, "tags"...),      //
+		append([]byte{0x55}, "a.txt"...),     //
+		[]byte{0xa2, 5, 6},                   // [5, 6]
+		[]byte{0x62, 0x4e, 0x2d, 0x65, 0x87}, // UTF-16 字符串“中文”
+		[]byte{0x10, 42},                     // 整数
+	)
+
+	v, err := decodePlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"name": "a.txt",
+		"tags": []interface{}{"中文", int64(42)},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("decodePlist = %#v, want %#v", v, want)
+	}
+	if got := plistStrings(v); !reflect.DeepEqual(got, []string{"a.txt", "中文"}) {
+		t.Errorf("plistStrings = %q", got)
+	}
+}
+
+func TestDecodePlistMalformed(t *testing.T) {
+	valid := buildPlist(append([]byte{0x53}, "abc"...))
+	body := valid[:len(valid)-32]
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"空文件", nil},
+		{"缺少文件头", withTrailer([]byte("bplist01"), valid[8:])},
+		{"截断", valid[:len(valid)-1]},
+		{"字符串越界", buildPlist([]byte{0x5f, 0x10, 0xff, 'a'})},
+		{"引用越界", buildPlist([]byte{0xa1, 9})},
+		{"自身引用", buildPlist([]byte{0xa1, 0})},
+		{"互相引用", buildPlist([]byte{0xa1, 1}, []byte{0xa1, 0})},
+		{"偏移表溢出", withTrailer(body, trailer(1, 1, 2, 0, ^uint64(0)))},
+		{"对象数溢出", withTrailer(body, trailer(8, 1, ^uint64(0)/8+2, 0, 8))},
+		{"偏移表越界", withTrailer(body, trailer(1, 1, 1, 0, uint64(len(body))))},
+		{"顶层对象越界", withTrailer(body, trailer(1, 1, 1, 1, uint64(len(body)-1)))},
+		{"对象偏移越界", func() []byte {
+			d := append([]byte(nil), body[:len(body)-1]...)
+			d = append(d, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+			return withTrailer(d, trailer(8, 1, 1, 0, uint64(len(body)-1)))
+		}()},
+	}
+	for _, tt := range tests {
+		if _, err := decodePlist(tt.data); err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+		}
+	}
+}
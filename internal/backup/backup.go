@@ -0,0 +1,141 @@
+// Package backup 实现已知文件的备份文件和编辑器临时文件探测
+package backup
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// DefaultMaxPaths 默认最多探测的已知文件数量
+const DefaultMaxPaths = 1000
+
+// 常见的备份文件后缀
+var suffixes = []string{".bak", ".old", "~", ".orig", ".swp", ".save", ".copy", ".zip", ".tar.gz"}
+
+// BackupDumper 根据其他 dumper 发现的文件路径探测备份文件
+type BackupDumper struct {
+	dumper.BaseDumper
+	// Paths 其他 dumper 记录的文件路径
+	Paths *dumper.PathSet
+	// MaxPaths 最多探测的已知文件数量，0 表示不限制
+	MaxPaths int
+	// Filter 下载备份文件时的过滤条件
+	Filter *filter.Filter
+}
+
+// NewBackupDumper 创建 BackupDumper 实例
+func NewBackupDumper() *BackupDumper {
+	return &BackupDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "backup",
+			Description: "探测已知文件的备份文件",
+		},
+		MaxPaths: DefaultMaxPaths,
+	}
+}
+
+// Variants 返回文件可能存在的备份文件路径，包括 vim 的 .name.swp 交换文件
+func Variants(name string) []string {
+	dir, file := path.Split(name)
+	if file == "" {
+		return nil
+	}
+	variants := make([]string, 0, len(suffixes)+1)
+	for _, suffix := range suffixes {
+		variants = append(variants, name+suffix)
+	}
+	if !strings.HasPrefix(file, ".") {
+		variants = append(variants, dir+"."+file+".swp")
+	}
+	return variants
+}
+
+// isBackup 判断路径本身是否已经是备份文件
+func isBackup(name string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 检查目标是否记录了可以探测的文件路径
+func (d *BackupDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	return len(d.Paths.List()) > 0, nil
+}
+
+// Execute 执行下载操作
+func (d *BackupDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	paths := d.Paths.List()
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	// 探测的路径大多不存在，空的200响应不是备份文件
+	f := filter.Filter{MinSize: 1}
+	if d.Filter != nil {
+		f = *d.Filter
+		f.MinSize = max(f.MinSize, 1)
+	}
+
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	probed := 0
+	for _, name := range paths {
+		if isBackup(name) {
+			continue
+		}
+		if d.MaxPaths > 0 && probed >= d.MaxPaths {
+			break
+		}
+		probed++
+
+		for _, variant := range Variants(name) {
+			// 已知文件交给其他 dumper 处理
+			if d.Paths.Contains(variant) {
+				continue
+			}
+			if !d.Filter.Match(filter.Entry{Path: variant, Size: -1}) {
+				continue
+			}
+			pool.Go(func() {
+				dumper.DownloadStream(client, baseline, &f, targetURL+dumper.EscapePath(variant), outdir, variant, progressCb)
+			})
+		}
+	}
+
+	return nil
+}
+
+// Validate 验证URL是否有效
+func (d *BackupDumper) Validate(url string) error {
+	return nil
+}
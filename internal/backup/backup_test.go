@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+)
+
+func TestVariants(t *testing.T) {
+	want := []string{
+		"app/config.php.bak", "app/config.php.old", "app/config.php~", "app/config.php.orig",
+		"app/config.php.swp", "app/config.php.save", "app/config.php.copy", "app/config.php.zip",
+		"app/config.php.tar.gz", "app/.config.php.swp",
+	}
+	if got := Variants("app/config.php"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Variants(app/config.php) = %q", got)
+	}
+
+	// 隐藏文件不再加点前缀，目录没有备份文件
+	if got := Variants(".env"); got[len(got)-1] != ".env.tar.gz" {
+		t.Errorf("Variants(.env) = %q", got)
+	}
+	if got := Variants("src/"); got != nil {
+		t.Errorf("Variants(src/) = %q", got)
+	}
+}
+
+func TestIsBackup(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"config.php", false},
+		{"config.php.bak", true},
+		{"index.html~", true},
+		{".index.php.swp", true},
+		{"site.tar.gz", true},
+		{"backup.sql", false},
+		{"old/index.php", false},
+	}
+	for _, tt := range tests {
+		if got := isBackup(tt.name); got != tt.want {
+			t.Errorf("isBackup(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	big := strings.Repeat("x", 2<<20)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.php.bak":
+			w.Write([]byte("<?php $pass = 'old';"))
+		case "/site/中文.txt~":
+			w.Write([]byte("backup"))
+		case "/dump.sql.zip":
+			// 大于流式下载先检查的长度，仍应完整保存
+			w.Write([]byte("PK\x03\x04" + big))
+		case "/config.php.old":
+			// 空的200响应不是备份文件
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	paths := dumper.NewPathSet()
+	for _, p := range []string{"config.php", "site/中文.txt", "dump.sql", "config.php.orig"} {
+		paths.Add(p)
+	}
+
+	d := NewBackupDumper()
+	d.Paths = paths
+	outdir := t.TempDir()
+	if err := d.Execute(srv.URL, outdir, "", false, false, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []string
+	filepath.WalkDir(outdir, func(p string, e os.DirEntry, err error) error {
+		if err == nil && !e.IsDir() {
+			rel, _ := filepath.Rel(outdir, p)
+			saved = append(saved, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(saved)
+	if want := []string{"config.php.bak", "dump.sql.zip", "site/中文.txt~"}; !reflect.DeepEqual(saved, want) {
+		t.Errorf("saved = %q, want %q", saved, want)
+	}
+	if fi, err := os.Stat(filepath.Join(outdir, "dump.sql.zip")); err != nil || fi.Size() != int64(4+len(big)) {
+		t.Errorf("dump.sql.zip 没有完整保存: %v", err)
+	}
+
+	// 过滤条件对备份文件同样有效
+	d.Filter = &filter.Filter{Extensions: []string{"bak"}}
+	outdir = t.TempDir()
+	d.Execute(srv.URL, outdir, "", false, false, 4, nil)
+	entries, _ := os.ReadDir(outdir)
+	if len(entries) != 1 || entries[0].Name() != "config.php.bak" {
+		t.Errorf("过滤后保存了 %v", entries)
+	}
+}
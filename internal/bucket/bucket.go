@@ -0,0 +1,164 @@
+// Package bucket 实现公开对象存储桶 (S3 兼容、GCS、Azure Blob) 的列表下载
+package bucket
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+)
+
+// 默认最多下载的对象数量
+const DefaultMaxFiles = 10000
+
+// 列表请求的上限，防止服务器返回循环的分页标记
+const (
+	maxPages    = 10000
+	maxPageSize = 32 << 20
+)
+
+// BucketDumper 实现公开存储桶的对象下载
+type BucketDumper struct {
+	dumper.BaseDumper
+	MaxFiles int // 最多下载的对象数量，0 表示不限制
+	// Truncated 执行后为 true 表示达到了 MaxFiles 上限，下载的对象不完整
+	Truncated bool
+	// Filter 下载文件的过滤条件
+	Filter *filter.Filter
+	// Paths 记录列表中的对象键
+	Paths *dumper.PathSet
+}
+
+// NewBucketDumper 创建 BucketDumper 实例
+func NewBucketDumper() *BucketDumper {
+	return &BucketDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "bucket",
+			Description: "下载公开存储桶中的对象",
+		},
+		MaxFiles: DefaultMaxFiles,
+	}
+}
+
+// Check 检查目标是否为可列出的存储桶
+func (d *BucketDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	_, _, err := d.firstPage(client, targetURL)
+	return err == nil, nil
+}
+
+// fetchPage 获取一页列表，返回实际请求的URL (跟随重定向后) 和响应内容
+func fetchPage(client *http.Client, pageURL string) (*url.URL, []byte, error) {
+	resp, err := client.Get(pageURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取列表失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("列表状态码异常: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取列表失败: %v", err)
+	}
+
+	return resp.Request.URL, body, nil
+}
+
+// firstPage 获取并识别第一页列表
+// Azure Blob 容器需要 restype=container&comp=list 参数才会返回列表
+func (d *BucketDumper) firstPage(client *http.Client, targetURL string) (*provider, *page, error) {
+	candidates := []string{targetURL}
+	if u, err := url.Parse(targetURL); err == nil && strings.HasSuffix(strings.ToLower(u.Hostname()), ".blob.core.windows.net") && u.Query().Get("comp") == "" {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		candidates = append(candidates, withQuery(u, map[string]string{"restype": "container", "comp": "list"}))
+	}
+
+	for _, candidate := range candidates {
+		pageURL, body, err := fetchPage(client, candidate)
+		if err != nil {
+			continue
+		}
+		p := detectProvider(body)
+		if p == nil {
+			continue
+		}
+		result, err := p.Parse(pageURL, body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, result, nil
+	}
+
+	return nil, nil, fmt.Errorf("未识别到存储桶列表")
+}
+
+// Execute 执行下载操作
+func (d *BucketDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 不是存储桶时直接返回
+	p, result, err := d.firstPage(client, targetURL)
+	if err != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	files := 0
+	seen := make(map[string]bool)
+	for pages := 1; ; pages++ {
+		for _, obj := range result.Objects {
+			if d.MaxFiles > 0 && files >= d.MaxFiles {
+				d.Truncated = true
+				return nil
+			}
+			// 以 / 结尾的对象是控制台创建的目录占位符
+			if obj.Key == "" || strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+			d.Paths.Add(obj.Key)
+			if !d.Filter.Match(filter.Entry{Path: obj.Key, Size: obj.Size, ModTime: obj.ModTime}) {
+				continue
+			}
+			files++
+			pool.Go(func() {
+				dumper.DownloadStream(client, nil, d.Filter, obj.URL, outdir, obj.Key, progressCb)
+			})
+		}
+
+		if result.Next == "" || seen[result.Next] || pages >= maxPages {
+			return nil
+		}
+		seen[result.Next] = true
+
+		pageURL, body, err := fetchPage(client, result.Next)
+		if err != nil {
+			return err
+		}
+		if result, err = p.Parse(pageURL, body); err != nil {
+			return err
+		}
+	}
+}
+
+// Validate 验证URL是否有效
+func (d *BucketDumper) Validate(url string) error {
+	return nil
+}
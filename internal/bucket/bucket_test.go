@@ -0,0 +1,184 @@
+package bucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"dumpall-go/internal/dumper"
+)
+
+// s3Page 生成一页 ListObjects V1 结果
+func s3Page(truncated bool, nextMarker string, keys ...string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `<ListBucketResult><IsTruncated>%v</IsTruncated>`, truncated)
+	if nextMarker != "" {
+		fmt.Fprintf(&buf, `<NextMarker>%s</NextMarker>`, nextMarker)
+	}
+	for _, key := range keys {
+		fmt.Fprintf(&buf, `<Contents><Key>%s</Key><Size>%d</Size></Contents>`, key, len(key))
+	}
+	buf.WriteString(`</ListBucketResult>`)
+	return buf.String()
+}
+
+// newBucketServer 根据 pages 返回列表，列表以外的路径返回对象键本身
+func newBucketServer(t *testing.T, pages func(r *http.Request) string) (*httptest.Server, *int32) {
+	var listings int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			atomic.AddInt32(&listings, 1)
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(pages(r)))
+			return
+		}
+		w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/")))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &listings
+}
+
+func savedFiles(t *testing.T, outdir string) []string {
+	var files []string
+	filepath.WalkDir(outdir, func(p string, e os.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(outdir, p)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func TestExecutePagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    func(r *http.Request) string
+		files    string
+		listings int32
+	}{
+		{
+			name: "v1 NextMarker",
+			pages: func(r *http.Request) string {
+				switch r.URL.Query().Get("marker") {
+				case "":
+					return s3Page(true, "m1", "a.txt", "dir/")
+				case "m1":
+					return s3Page(true, "m2", "dir/b.txt")
+				default:
+					return s3Page(false, "", "c.txt")
+				}
+			},
+			files:    "a.txt,c.txt,dir/b.txt",
+			listings: 3,
+		},
+		{
+			name: "v1 last key as marker",
+			pages: func(r *http.Request) string {
+				if r.URL.Query().Get("marker") == "" {
+					return s3Page(true, "", "a.txt", "b.txt")
+				}
+				return s3Page(false, "", "c.txt")
+			},
+			files:    "a.txt,b.txt,c.txt",
+			listings: 2,
+		},
+		{
+			name: "v2 continuation token",
+			pages: func(r *http.Request) string {
+				if r.URL.Query().Get("continuation-token") == "" {
+					return `<ListBucketResult><KeyCount>1</KeyCount><IsTruncated>true</IsTruncated><NextContinuationToken>t1</NextContinuationToken><Contents><Key>a.txt</Key></Contents></ListBucketResult>`
+				}
+				return `<ListBucketResult><KeyCount>1</KeyCount><IsTruncated>false</IsTruncated><Contents><Key>b.txt</Key></Contents></ListBucketResult>`
+			},
+			files:    "a.txt,b.txt",
+			listings: 2,
+		},
+		{
+			// 每一页都返回相同的 marker，第二次请求同一页后停止
+			name: "repeated marker",
+			pages: func(r *http.Request) string {
+				return s3Page(true, "same", "a.txt")
+			},
+			files:    "a.txt",
+			listings: 2,
+		},
+		{
+			// 没有 NextMarker 时最后一个对象键不变，同样视为重复
+			name: "repeated last key",
+			pages: func(r *http.Request) string {
+				return s3Page(true, "", "a.txt")
+			},
+			files:    "a.txt",
+			listings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, listings := newBucketServer(t, tt.pages)
+			outdir := t.TempDir()
+
+			if err := NewBucketDumper().Execute(srv.URL+"/", outdir, "", false, false, 4, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(savedFiles(t, outdir), ","); got != tt.files {
+				t.Errorf("saved files = %s, want %s", got, tt.files)
+			}
+			if got := atomic.LoadInt32(listings); got != tt.listings {
+				t.Errorf("listing requests = %d, want %d", got, tt.listings)
+			}
+		})
+	}
+}
+
+func TestExecuteAzureAndGCS(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/c" && q.Get("comp") == "list":
+			if q.Get("marker") == "" {
+				fmt.Fprintf(w, `<EnumerationResults><Blobs><Blob><Name>a.txt</Name><Url>%s/c/a.txt</Url></Blob></Blobs><NextMarker>2!x</NextMarker></EnumerationResults>`, srvURL)
+				return
+			}
+			w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>dir/b.txt</Name></Blob></Blobs><NextMarker/></EnumerationResults>`))
+		case r.URL.Path == "/storage/v1/b/bkt/o":
+			if q.Get("pageToken") == "" {
+				w.Write([]byte(`{"kind":"storage#objects","nextPageToken":"p2","items":[{"name":"a.txt"}]}`))
+				return
+			}
+			w.Write([]byte(`{"kind":"storage#objects","items":[{"name":"dir/b.txt"}]}`))
+		case strings.HasPrefix(r.URL.Path, "/c/"), strings.HasPrefix(r.URL.Path, "/storage/v1/b/bkt/o/"):
+			w.Write([]byte("ok"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	for _, target := range []string{srv.URL + "/c?restype=container&comp=list", srv.URL + "/storage/v1/b/bkt/o"} {
+		outdir := t.TempDir()
+		d := NewBucketDumper()
+		d.Paths = dumper.NewPathSet()
+		if err := d.Execute(target, outdir, "", false, false, 4, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(savedFiles(t, outdir), ","); got != "a.txt,dir/b.txt" {
+			t.Errorf("%s: saved files = %s, want a.txt,dir/b.txt", target, got)
+		}
+		if got := strings.Join(d.Paths.List(), ","); got != "a.txt,dir/b.txt" {
+			t.Errorf("%s: paths = %s, want a.txt,dir/b.txt", target, got)
+		}
+	}
+}
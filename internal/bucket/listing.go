@@ -0,0 +1,238 @@
+package bucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Object 表示存储桶中的一个对象
+type Object struct {
+	Key     string    // 对象键，即相对路径
+	URL     string    // 对象的下载地址
+	Size    int64     // 对象大小，未知时为 -1
+	ModTime time.Time // 修改时间，未知时为零值
+}
+
+// page 表示一页列表结果
+type page struct {
+	Objects []Object
+	Next    string // 下一页的URL，没有更多结果时为空
+}
+
+// provider 定义一种对象存储列表格式的识别和解析方法
+type provider struct {
+	Name  string
+	Match func(body []byte) bool
+	Parse func(pageURL *url.URL, body []byte) (*page, error)
+}
+
+// providers 按识别优先级排列
+var providers = []provider{
+	{Name: "s3", Match: matchXMLRoot("ListBucketResult"), Parse: parseS3},
+	{Name: "azure", Match: matchXMLRoot("EnumerationResults"), Parse: parseAzure},
+	{Name: "gcs", Match: matchGCSJSON, Parse: parseGCS},
+}
+
+// detectProvider 根据响应内容识别对象存储类型
+func detectProvider(body []byte) *provider {
+	for i := range providers {
+		if providers[i].Match(body) {
+			return &providers[i]
+		}
+	}
+	return nil
+}
+
+// matchXMLRoot 返回判断 XML 根元素名称的函数
+func matchXMLRoot(name string) func([]byte) bool {
+	return func(body []byte) bool {
+		dec := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return false
+			}
+			if se, ok := tok.(xml.StartElement); ok {
+				return se.Name.Local == name
+			}
+		}
+	}
+}
+
+// baseURL 返回去掉查询参数并以 / 结尾的URL，用于拼接对象地址
+func baseURL(u *url.URL) *url.URL {
+	base := *u
+	base.RawQuery = ""
+	base.Fragment = ""
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		base.RawPath = ""
+	}
+	return &base
+}
+
+// objectURL 将对象键逐段编码后拼接到 base 之后
+func objectURL(base *url.URL, key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.TrimSuffix(base.String(), "/") + "/" + strings.Join(parts, "/")
+}
+
+// withQuery 返回替换了部分查询参数的URL
+func withQuery(u *url.URL, values map[string]string) string {
+	next := *u
+	q := next.Query()
+	for k, v := range values {
+		q.Set(k, v)
+	}
+	next.RawQuery = q.Encode()
+	return next.String()
+}
+
+// S3 兼容存储 (包括 GCS XML API、MinIO、OSS 等) 的 ListBucketResult
+type s3Result struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextMarker            string `xml:"NextMarker"`
+	KeyCount              string `xml:"KeyCount"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		Size         string `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// parseS3 解析 ListBucketResult，支持 ListObjects V1 的 marker 和 V2 的 continuation-token 分页
+func parseS3(pageURL *url.URL, body []byte) (*page, error) {
+	var r s3Result
+	if err := xml.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("解析 ListBucketResult 失败: %v", err)
+	}
+
+	base := baseURL(pageURL)
+	p := &page{}
+	for _, c := range r.Contents {
+		obj := Object{Key: c.Key, URL: objectURL(base, c.Key), Size: parseInt(c.Size)}
+		obj.ModTime, _ = time.Parse(time.RFC3339, strings.TrimSpace(c.LastModified))
+		p.Objects = append(p.Objects, obj)
+	}
+
+	if !r.IsTruncated {
+		return p, nil
+	}
+
+	switch {
+	case r.NextContinuationToken != "":
+		p.Next = withQuery(pageURL, map[string]string{"list-type": "2", "continuation-token": r.NextContinuationToken})
+	case r.NextMarker != "":
+		p.Next = withQuery(pageURL, map[string]string{"marker": r.NextMarker})
+	case len(r.Contents) > 0 && r.KeyCount == "":
+		// V1 没有返回 NextMarker 时使用最后一个对象键作为 marker
+		p.Next = withQuery(pageURL, map[string]string{"marker": r.Contents[len(r.Contents)-1].Key})
+	}
+	return p, nil
+}
+
+// Azure Blob 容器的 EnumerationResults
+type azureResult struct {
+	NextMarker string `xml:"NextMarker"`
+	Blobs      []struct {
+		Name       string `xml:"Name"`
+		URL        string `xml:"Url"`
+		Properties struct {
+			ContentLength string `xml:"Content-Length"`
+			LastModified  string `xml:"Last-Modified"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+}
+
+// parseAzure 解析 Azure Blob 列表，使用 marker 分页
+func parseAzure(pageURL *url.URL, body []byte) (*page, error) {
+	var r azureResult
+	if err := xml.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("解析 EnumerationResults 失败: %v", err)
+	}
+
+	base := baseURL(pageURL)
+	p := &page{}
+	for _, b := range r.Blobs {
+		obj := Object{Key: b.Name, URL: b.URL, Size: parseInt(b.Properties.ContentLength)}
+		if obj.URL == "" {
+			// 较新的 API 版本不再返回 Url，根据容器地址拼接
+			obj.URL = objectURL(base, b.Name)
+		}
+		obj.ModTime, _ = time.Parse(time.RFC1123, strings.TrimSpace(b.Properties.LastModified))
+		p.Objects = append(p.Objects, obj)
+	}
+
+	if r.NextMarker != "" {
+		p.Next = withQuery(pageURL, map[string]string{"marker": r.NextMarker})
+	}
+	return p, nil
+}
+
+// GCS JSON API 的对象列表
+type gcsResult struct {
+	Kind          string `json:"kind"`
+	NextPageToken string `json:"nextPageToken"`
+	Items         []struct {
+		Name      string `json:"name"`
+		Size      string `json:"size"`
+		Updated   string `json:"updated"`
+		MediaLink string `json:"mediaLink"`
+	} `json:"items"`
+}
+
+// matchGCSJSON 判断是否为 GCS JSON API 的对象列表
+func matchGCSJSON(body []byte) bool {
+	var r gcsResult
+	if err := json.Unmarshal(body, &r); err != nil {
+		return false
+	}
+	return r.Kind == "storage#objects"
+}
+
+// parseGCS 解析 GCS JSON API 的对象列表，使用 pageToken 分页
+func parseGCS(pageURL *url.URL, body []byte) (*page, error) {
+	var r gcsResult
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("解析 GCS 对象列表失败: %v", err)
+	}
+
+	p := &page{}
+	for _, item := range r.Items {
+		obj := Object{Key: item.Name, URL: item.MediaLink, Size: parseInt(item.Size)}
+		if obj.URL == "" {
+			// 没有 mediaLink 时通过 alt=media 下载，对象名中的 / 需要编码
+			u := *pageURL
+			u.Path = strings.TrimSuffix(u.Path, "/") + "/" + item.Name
+			u.RawPath = strings.TrimSuffix(pageURL.EscapedPath(), "/") + "/" + url.PathEscape(item.Name)
+			u.RawQuery = "alt=media"
+			obj.URL = u.String()
+		}
+		obj.ModTime, _ = time.Parse(time.RFC3339, strings.TrimSpace(item.Updated))
+		p.Objects = append(p.Objects, obj)
+	}
+
+	if r.NextPageToken != "" {
+		p.Next = withQuery(pageURL, map[string]string{"pageToken": r.NextPageToken})
+	}
+	return p, nil
+}
+
+// parseInt 解析对象大小，无法识别时返回 -1
+func parseInt(s string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
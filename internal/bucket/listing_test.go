@@ -0,0 +1,168 @@
+package bucket
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+const s3V1Page = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <Prefix></Prefix>
+  <Marker></Marker>
+  <MaxKeys>2</MaxKeys>
+  <IsTruncated>true</IsTruncated>
+  <Contents>
+    <Key>a.txt</Key>
+    <LastModified>2024-01-02T03:04:05.000Z</LastModified>
+    <Size>12</Size>
+  </Contents>
+  <Contents>
+    <Key>dir/b c.txt</Key>
+    <LastModified>2024-01-02T03:04:05.000Z</LastModified>
+    <Size>34</Size>
+  </Contents>
+</ListBucketResult>`
+
+const s3V2Page = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <KeyCount>1</KeyCount>
+  <MaxKeys>1</MaxKeys>
+  <IsTruncated>true</IsTruncated>
+  <NextContinuationToken>token+1/=</NextContinuationToken>
+  <Contents>
+    <Key>a.txt</Key>
+    <Size>12</Size>
+  </Contents>
+</ListBucketResult>`
+
+const azurePage = `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ContainerName="https://acct.blob.core.windows.net/c">
+  <Blobs>
+    <Blob>
+      <Name>a.txt</Name>
+      <Url>https://acct.blob.core.windows.net/c/a.txt</Url>
+      <Properties>
+        <Last-Modified>Tue, 02 Jan 2024 03:04:05 GMT</Last-Modified>
+        <Content-Length>12</Content-Length>
+      </Properties>
+    </Blob>
+    <Blob>
+      <Name>dir/b.txt</Name>
+      <Properties><Content-Length>34</Content-Length></Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker>2!abc</NextMarker>
+</EnumerationResults>`
+
+const gcsPage = `{
+  "kind": "storage#objects",
+  "nextPageToken": "CgVhLnR4dA==",
+  "items": [
+    {"name": "a.txt", "size": "12", "updated": "2024-01-02T03:04:05.000Z", "mediaLink": "https://storage.googleapis.com/download/storage/v1/b/bkt/o/a.txt?alt=media"},
+    {"name": "dir/b.txt", "size": "34"}
+  ]
+}`
+
+func TestParseListings(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageURL  string
+		body     string
+		provider string
+		objects  []Object
+		next     string
+	}{
+		{
+			name:     "s3 v1 without NextMarker",
+			pageURL:  "https://bucket.s3.amazonaws.com/",
+			body:     s3V1Page,
+			provider: "s3",
+			objects: []Object{
+				{Key: "a.txt", URL: "https://bucket.s3.amazonaws.com/a.txt", Size: 12, ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{Key: "dir/b c.txt", URL: "https://bucket.s3.amazonaws.com/dir/b%20c.txt", Size: 34, ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			},
+			next: "https://bucket.s3.amazonaws.com/?marker=dir%2Fb+c.txt",
+		},
+		{
+			name:     "s3 v2",
+			pageURL:  "https://s3.amazonaws.com/bucket?list-type=2",
+			body:     s3V2Page,
+			provider: "s3",
+			objects: []Object{
+				{Key: "a.txt", URL: "https://s3.amazonaws.com/bucket/a.txt", Size: 12},
+			},
+			next: "https://s3.amazonaws.com/bucket?continuation-token=token%2B1%2F%3D&list-type=2",
+		},
+		{
+			name:     "azure",
+			pageURL:  "https://acct.blob.core.windows.net/c?comp=list&restype=container",
+			body:     azurePage,
+			provider: "azure",
+			objects: []Object{
+				{Key: "a.txt", URL: "https://acct.blob.core.windows.net/c/a.txt", Size: 12, ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("GMT", 0))},
+				{Key: "dir/b.txt", URL: "https://acct.blob.core.windows.net/c/dir/b.txt", Size: 34},
+			},
+			next: "https://acct.blob.core.windows.net/c?comp=list&marker=2%21abc&restype=container",
+		},
+		{
+			name:     "gcs",
+			pageURL:  "https://storage.googleapis.com/storage/v1/b/bkt/o",
+			body:     gcsPage,
+			provider: "gcs",
+			objects: []Object{
+				{Key: "a.txt", URL: "https://storage.googleapis.com/download/storage/v1/b/bkt/o/a.txt?alt=media", Size: 12, ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{Key: "dir/b.txt", URL: "https://storage.googleapis.com/storage/v1/b/bkt/o/dir%2Fb.txt?alt=media", Size: 34},
+			},
+			next: "https://storage.googleapis.com/storage/v1/b/bkt/o?pageToken=CgVhLnR4dA%3D%3D",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := detectProvider([]byte(tt.body))
+			if p == nil || p.Name != tt.provider {
+				t.Fatalf("detectProvider = %v, want %s", p, tt.provider)
+			}
+			pageURL, _ := url.Parse(tt.pageURL)
+			result, err := p.Parse(pageURL, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Objects) != len(tt.objects) {
+				t.Fatalf("got %d objects, want %d", len(result.Objects), len(tt.objects))
+			}
+			for i, want := range tt.objects {
+				got := result.Objects[i]
+				if got.Key != want.Key || got.URL != want.URL || got.Size != want.Size || !got.ModTime.Equal(want.ModTime) {
+					t.Errorf("object %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if result.Next != tt.next {
+				t.Errorf("next = %q, want %q", result.Next, tt.next)
+			}
+		})
+	}
+}
+
+func TestParseS3LastPage(t *testing.T) {
+	body := `<ListBucketResult><IsTruncated>false</IsTruncated><NextMarker>x</NextMarker><Contents><Key>a</Key></Contents></ListBucketResult>`
+	pageURL, _ := url.Parse("https://bucket.s3.amazonaws.com/")
+	result, err := parseS3(pageURL, []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Next != "" {
+		t.Fatalf("next = %q, want empty", result.Next)
+	}
+}
+
+func TestDetectProviderUnknown(t *testing.T) {
+	for _, body := range []string{"", "<html><body>Index of /</body></html>", `<Error><Code>AccessDenied</Code></Error>`, `{"kind":"other"}`} {
+		if p := detectProvider([]byte(body)); p != nil {
+			t.Errorf("detectProvider(%q) = %s, want nil", body, p.Name)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package bzr 实现 .bzr (Bazaar) 仓库泄露的下载
+package bzr
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// BzrDumper 实现 .bzr 源代码下载
+type BzrDumper struct {
+	dumper.BaseDumper
+	// Filter 下载工作区文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 dirstate 中的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewBzrDumper 创建 BzrDumper 实例
+func NewBzrDumper() *BzrDumper {
+	return &BzrDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "bzr",
+			Description: "下载 .bzr 源代码",
+		},
+	}
+}
+
+// 常见的 Bazaar 文件
+var bzrFiles = []string{
+	".bzr/README",
+	".bzr/branch-format",
+	".bzr/branch/format",
+	".bzr/branch/branch.conf",
+	".bzr/branch/last-revision",
+	".bzr/branch/tags",
+	".bzr/checkout/format",
+	".bzr/checkout/dirstate",
+	".bzr/checkout/conflicts",
+	".bzr/checkout/merge-hashes",
+	".bzr/checkout/views",
+	".bzr/repository/format",
+	".bzr/repository/pack-names",
+}
+
+// 每个 pack 对应的索引文件扩展名
+var indexExts = []string{".rix", ".iix", ".tix", ".six", ".cix"}
+
+// Check 检查目标是否存在 .bzr 信息泄露
+func (d *BzrDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	// 检查 .bzr/branch-format 文件，排除不存在的文件也返回200的情况
+	fileURL := targetURL + ".bzr/branch-format"
+	_, ok := dumper.Fetch(client, soft404.Calibrate(client, targetURL), fileURL, 0, nil)
+	return ok, nil
+}
+
+// Execute 执行下载操作
+func (d *BzrDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	// branch-format 以 "Bazaar-NG meta directory" 开头，不匹配时认为不存在 .bzr 泄露
+	format, ok := dumper.Fetch(client, baseline, targetURL+".bzr/branch-format", 0, progressCb)
+	if !ok || !bytes.HasPrefix(format, []byte("Bazaar")) {
+		return nil
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range bzrFiles {
+		data, ok := dumper.Fetch(client, baseline, targetURL+file, 0, progressCb)
+		if !ok {
+			continue
+		}
+		files[file] = data
+		dumper.SaveData(outdir, file, data, targetURL+file, progressCb)
+	}
+
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	// 下载 pack 和索引文件，可以在本地使用 bzr 命令还原完整历史
+	for _, name := range ParsePackNames(files[".bzr/repository/pack-names"]) {
+		packFiles := []string{".bzr/repository/packs/" + name + ".pack"}
+		for _, ext := range indexExts {
+			packFiles = append(packFiles, ".bzr/repository/indices/"+name+ext)
+		}
+		for _, file := range packFiles {
+			pool.Go(func() {
+				if data, ok := dumper.Fetch(client, baseline, targetURL+file, 0, progressCb); ok {
+					dumper.SaveData(outdir, file, data, targetURL+file, progressCb)
+				}
+			})
+		}
+	}
+
+	// 根据 dirstate 下载工作区中的文件
+	entries, err := ParseDirstate(files[".bzr/checkout/dirstate"])
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Kind != "file" {
+			continue
+		}
+		d.Paths.Add(entry.Path)
+		if !d.Filter.Match(filter.Entry{Path: entry.Path, Size: entry.Size}) {
+			continue
+		}
+		pool.Go(func() {
+			dumper.Download(client, baseline, d.Filter, targetURL+dumper.EscapePath(entry.Path), outdir, entry.Path, 0, progressCb)
+		})
+	}
+
+	return nil
+}
+
+// Validate 验证URL是否有效
+func (d *BzrDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package bzr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dirstate 文件头，目前只支持 format 3
+const dirstateHeader = "#bazaar dirstate flat format 3\n"
+
+// dirstate 中每棵树占用的字段数: minikind, fingerprint, size, executable, packed_stat
+const fieldsPerTree = 5
+
+// 解压 pack-names 索引页时的长度上限
+const maxIndexPageSize = 4 << 20
+
+// DirstateEntry 表示 dirstate 中工作区的一项
+type DirstateEntry struct {
+	Path   string // 相对路径
+	FileID string // Bazaar 内部文件ID
+	Kind   string // file, dir, symlink 或 tree-reference
+	SHA1   string // 文件内容的 SHA1，目录为空
+	Size   int64  // 文件大小
+}
+
+// minikind 到类型名称的映射，a (absent) 和 r (relocated) 表示工作区中不存在
+var kinds = map[string]string{
+	"f": "file",
+	"d": "dir",
+	"l": "symlink",
+	"t": "tree-reference",
+}
+
+// ParseDirstate 解析 .bzr/checkout/dirstate，返回工作区 (第一棵树) 中存在的条目
+func ParseDirstate(data []byte) ([]DirstateEntry, error) {
+	if !bytes.HasPrefix(data, []byte(dirstateHeader)) {
+		return nil, fmt.Errorf("不支持的 dirstate 格式")
+	}
+	rest := data[len(dirstateHeader):]
+
+	// 跳过 crc32 和 num_entries 两行
+	for i := 0; i < 2; i++ {
+		nl := bytes.IndexByte(rest, '\n')
+		if nl < 0 {
+			return nil, fmt.Errorf("dirstate 文件头不完整")
+		}
+		rest = rest[nl+1:]
+	}
+
+	lines := bytes.Split(rest, []byte("\x00\n\x00"))
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("dirstate 内容不完整")
+	}
+
+	// 第一行为父版本数量和ID，决定每条记录包含多少棵树
+	parents, _, _ := bytes.Cut(lines[0], []byte{0})
+	numParents, err := strconv.Atoi(string(parents))
+	if err != nil || numParents < 0 {
+		return nil, fmt.Errorf("无效的父版本数量: %q", parents)
+	}
+	numFields := 3 + fieldsPerTree*(numParents+1)
+
+	var entries []DirstateEntry
+	for _, line := range lines[2:] {
+		fields := strings.Split(string(line), "\x00")
+		if len(fields) < numFields {
+			continue
+		}
+		kind, ok := kinds[fields[3]]
+		if !ok {
+			continue
+		}
+		dir, base := fields[0], fields[1]
+		// 根目录的 dirname 和 basename 都为空
+		if base == "" {
+			continue
+		}
+		entry := DirstateEntry{
+			Path:   base,
+			FileID: fields[2],
+			Kind:   kind,
+		}
+		if dir != "" {
+			entry.Path = dir + "/" + base
+		}
+		if kind == "file" {
+			entry.SHA1 = fields[4]
+			entry.Size, _ = strconv.ParseInt(fields[5], 10, 64)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// pack 名称为 32 位十六进制的 MD5
+var packNameRe = regexp.MustCompile(`\b[0-9a-f]{32}\b`)
+
+// ParsePackNames 从 .bzr/repository/pack-names 中提取 pack 名称
+// 旧格式为纯文本的 GraphIndex，2a 格式为 zlib 压缩的 B+Tree 索引，两种格式都按文本查找名称
+func ParsePackNames(data []byte) []string {
+	texts := [][]byte{data}
+	for i := 0; i+1 < len(data); i++ {
+		// zlib 头: CMF=0x78 且 (CMF<<8|FLG) 是 31 的倍数
+		if data[i] != 0x78 || (int(data[i])<<8|int(data[i+1]))%31 != 0 {
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[i:]))
+		if err != nil {
+			continue
+		}
+		page, _ := io.ReadAll(io.LimitReader(zr, maxIndexPageSize))
+		zr.Close()
+		if len(page) > 0 {
+			texts = append(texts, page)
+		}
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, name := range packNameRe.FindAllString(string(text), -1) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
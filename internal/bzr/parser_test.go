@@ -0,0 +1,75 @@
+package bzr
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// testdata/dirstate 有一个父版本，包含根目录、文件、符号链接、子目录，
+// 以及工作区中已删除 (a) 的 old.txt 和新增后尚未提交的 src/中文.txt
+func TestParseDirstate(t *testing.T) {
+	entries, err := ParseDirstate(readFixture(t, "dirstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DirstateEntry{
+		{"README.md", "readme.md-20240102030405-7f3k2m-1", "file", "f572d396fae9206628714fb2ce00f72e94f2258f", 12},
+		{"link", "link-20240102030405-7f3k2m-2", "symlink", "", 0},
+		{"src", "src-20240102030405-7f3k2m-4", "dir", "", 0},
+		{"src/config.php", "config.php-20240102030405-7f3k2m-5", "file", "4c1fd9a1ab0cf8d65c1b2d8bfa0d2a3d7a01e2c3", 37},
+		{"src/中文.txt", "zh.txt-20240102030405-7f3k2m-6", "file", "0c7d2c0d2ed4b6e4e0a3e5b8d3b7f1a0f3c9b2d1", 7},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseDirstate = %+v\nwant %+v", entries, want)
+	}
+}
+
+func TestParseDirstateMalformed(t *testing.T) {
+	data := readFixture(t, "dirstate")
+	tests := map[string][]byte{
+		"格式不支持":   []byte("#bazaar dirstate flat format 2\ncrc32: 0\nnum_entries: 0\n"),
+		"文件头不完整":  []byte(dirstateHeader + "crc32: 0\n"),
+		"内容不完整":   []byte(dirstateHeader + "crc32: 0\nnum_entries: 0\n1\x00rev"),
+		"父版本数量无效": []byte(dirstateHeader + "crc32: 0\nnum_entries: 0\nx\x00\n\x000\x00\n\x00"),
+		"HTML 页面": []byte("<html><body>Not Found</body></html>"),
+	}
+	for name, d := range tests {
+		if _, err := ParseDirstate(d); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+
+	// 字段不足的记录被跳过
+	truncated := data[:len(data)-60]
+	entries, err := ParseDirstate(truncated)
+	if err != nil || len(entries) != 4 {
+		t.Errorf("截断的 dirstate 得到 %d 项, %v", len(entries), err)
+	}
+}
+
+func TestParsePackNames(t *testing.T) {
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"pack-names.2a", []string{"4a1e1f0b5c8d4f0e9a3b2c1d0e9f8a7b", "9f8e7d6c5b4a39281706f5e4d3c2b1a0"}},
+		{"pack-names.092", []string{"0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e"}},
+	}
+	for _, tt := range tests {
+		if got := ParsePackNames(readFixture(t, tt.file)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParsePackNames = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+	if got := ParsePackNames([]byte("<html>404</html>")); got != nil {
+		t.Errorf("ParsePackNames = %q, want nil", got)
+	}
+}
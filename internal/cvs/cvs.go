@@ -0,0 +1,154 @@
+// Package cvs 实现 CVS 元数据泄露的下载
+package cvs
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// 递归解析子目录 Entries 的最大深度
+const maxEntriesDepth = 32
+
+// CvsDumper 实现 CVS 元数据下载
+type CvsDumper struct {
+	dumper.BaseDumper
+	// Filter 下载工作区文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 Entries 中的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewCvsDumper 创建 CvsDumper 实例
+func NewCvsDumper() *CvsDumper {
+	return &CvsDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "cvs",
+			Description: "下载 CVS 元数据及其记录的文件",
+		},
+	}
+}
+
+// 每个目录下 CVS 目录中的常见文件
+var cvsFiles = []string{
+	"CVS/Entries",
+	"CVS/Entries.Log",
+	"CVS/Entries.Extra",
+	"CVS/Repository",
+	"CVS/Root",
+	"CVS/Tag",
+	"CVS/Template",
+}
+
+// Check 检查目标是否存在 CVS 信息泄露
+func (d *CvsDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	// 检查 CVS/Root 文件，排除不存在的文件也返回200的情况
+	fileURL := targetURL + "CVS/Root"
+	_, ok := dumper.Fetch(client, soft404.Calibrate(client, targetURL), fileURL, 0, nil)
+	return ok, nil
+}
+
+// Execute 执行下载操作
+func (d *CvsDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	d.crawl(client, baseline, pool, targetURL, outdir, "", 0, progressCb)
+	return nil
+}
+
+// crawl 下载一个目录的 CVS 元数据，下载其中记录的文件并递归子目录
+func (d *CvsDumper) crawl(client *http.Client, baseline *soft404.Baseline, pool *dumper.Pool, dirURL string, outdir string, relDir string, depth int, progressCb dumper.ProgressCallback) {
+	// 不像 Entries 的内容 (例如自定义404页面) 直接跳过
+	entriesData, ok := dumper.Fetch(client, baseline, dirURL+"CVS/Entries", 0, progressCb)
+	if !ok || !looksLikeEntries(entriesData) {
+		return
+	}
+	dumper.SaveData(outdir, relDir+"/CVS/Entries", entriesData, dirURL+"CVS/Entries", progressCb)
+
+	entries := ParseEntries(entriesData)
+	for _, file := range cvsFiles[1:] {
+		data, ok := dumper.Fetch(client, baseline, dirURL+file, 0, progressCb)
+		if !ok {
+			continue
+		}
+		dumper.SaveData(outdir, relDir+"/"+file, data, dirURL+file, progressCb)
+		// Entries.Log 中记录了尚未合并到 Entries 的新增条目
+		if file == "CVS/Entries.Log" {
+			entries = append(entries, ParseEntriesLog(data)...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		// Entries 中只记录当前目录下的名称，包含路径分隔符的是异常记录
+		if seen[entry.Name] || entry.Name == "." || entry.Name == ".." || strings.ContainsAny(entry.Name, "/\\") {
+			continue
+		}
+		seen[entry.Name] = true
+		name := relDir + "/" + entry.Name
+
+		if entry.IsDir {
+			if depth < maxEntriesDepth {
+				d.crawl(client, baseline, pool, dirURL+url.PathEscape(entry.Name)+"/", outdir, name, depth+1, progressCb)
+			}
+			continue
+		}
+
+		if entry.Removed() {
+			continue
+		}
+		d.Paths.Add(name)
+		if !d.Filter.Match(entry.filterEntry(name)) {
+			continue
+		}
+		pool.Go(func() {
+			dumper.Download(client, baseline, d.Filter, dirURL+url.PathEscape(entry.Name), outdir, name, 0, progressCb)
+		})
+	}
+}
+
+// looksLikeEntries 判断内容是否为 Entries 文件，每行都应以 / 或 D 开头
+func looksLikeEntries(data []byte) bool {
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line != "" && line[0] != '/' && line[0] != 'D' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate 验证URL是否有效
+func (d *CvsDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package cvs
+
+import (
+	"strings"
+	"time"
+
+	"dumpall-go/internal/filter"
+)
+
+// Entry 表示 CVS/Entries 中的一条记录
+type Entry struct {
+	Name      string    // 文件或目录名
+	IsDir     bool      // 是否为目录
+	Revision  string    // 版本号，0 表示新增未提交，- 开头表示已删除
+	ModTime   time.Time // 检出时的时间戳，未知时为零值
+	Options   string    // 关键字替换选项，例如 -kb
+	TagOrDate string    // 粘性标签或日期
+}
+
+// Entries 中时间戳使用的 asctime 格式
+const entryTimeLayout = "Mon Jan _2 15:04:05 2006"
+
+// ParseEntries 解析 CVS/Entries 文件
+// 文件记录格式为 /name/revision/timestamp/options/tagdate，目录记录以 D 开头
+func ParseEntries(data []byte) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(string(data), "\n") {
+		if entry, ok := parseLine(strings.TrimRight(line, "\r")); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ParseEntriesLog 解析 CVS/Entries.Log，返回其中新增 (A) 的记录
+func ParseEntriesLog(data []byte) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(string(data), "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimRight(line, "\r"), "A ")
+		if !ok {
+			continue
+		}
+		if entry, ok := parseLine(rest); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// parseLine 解析单行记录
+func parseLine(line string) (Entry, bool) {
+	var entry Entry
+	if rest, ok := strings.CutPrefix(line, "D"); ok {
+		// 单独的 D 表示没有子目录
+		if rest == "" {
+			return entry, false
+		}
+		entry.IsDir = true
+		line = rest
+	}
+	if !strings.HasPrefix(line, "/") {
+		return entry, false
+	}
+
+	fields := strings.Split(line[1:], "/")
+	if fields[0] == "" {
+		return entry, false
+	}
+	entry.Name = fields[0]
+	if len(fields) > 1 {
+		entry.Revision = fields[1]
+	}
+	if len(fields) > 2 {
+		// 存在冲突时时间戳形如 "Result of merge+..."，只保留可解析的部分
+		ts := fields[2]
+		if i := strings.IndexByte(ts, '+'); i >= 0 {
+			ts = ts[i+1:]
+		}
+		if t, err := time.Parse(entryTimeLayout, ts); err == nil {
+			entry.ModTime = t
+		}
+	}
+	if len(fields) > 3 {
+		entry.Options = fields[3]
+	}
+	if len(fields) > 4 {
+		entry.TagOrDate = fields[4]
+	}
+	return entry, true
+}
+
+// Removed 判断文件是否已标记为删除
+func (e Entry) Removed() bool {
+	return strings.HasPrefix(e.Revision, "-")
+}
+
+// filterEntry 生成用于过滤的文件信息，Entries 中没有记录文件大小
+func (e Entry) filterEntry(name string) filter.Entry {
+	return filter.Entry{Path: strings.TrimPrefix(name, "/"), Size: -1, ModTime: e.ModTime}
+}
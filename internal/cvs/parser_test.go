@@ -0,0 +1,81 @@
+package cvs
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseEntries(t *testing.T) {
+	jan2 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []Entry{
+		{Name: "README", Revision: "1.3", ModTime: jan2},
+		{Name: "logo.png", Revision: "1.1", ModTime: jan2, Options: "-kb"},
+		// 合并冲突时只保留 + 后的时间戳
+		{Name: "config.php", Revision: "1.5", ModTime: time.Date(2023, 2, 1, 12, 30, 0, 0, time.UTC), TagOrDate: "Trel-1-0"},
+		{Name: "removed.txt", Revision: "-1.2", ModTime: jan2},
+		// 新增未提交的文件没有时间戳
+		{Name: "new.txt", Revision: "0"},
+		{Name: "中文.txt", Revision: "1.1", ModTime: jan2},
+		{Name: "src", IsDir: true},
+		{Name: "docs", IsDir: true},
+	}
+	entries := ParseEntries(readFixture(t, "Entries"))
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseEntries = %+v\nwant %+v", entries, want)
+	}
+
+	removed := map[string]bool{}
+	for _, e := range entries {
+		removed[e.Name] = e.Removed()
+	}
+	if !removed["removed.txt"] || removed["README"] || removed["new.txt"] {
+		t.Errorf("Removed = %v", removed)
+	}
+
+	// 单独的 D、空名称和其他内容被忽略
+	if got := ParseEntries([]byte("D\n//1.1///\nfoo\n<html>\n")); got != nil {
+		t.Errorf("ParseEntries = %+v, want nil", got)
+	}
+}
+
+func TestParseEntriesLog(t *testing.T) {
+	want := []Entry{
+		{Name: "added.php", Revision: "0"},
+		{Name: "lib", IsDir: true},
+	}
+	if got := ParseEntriesLog(readFixture(t, "Entries.Log")); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEntriesLog = %+v, want %+v", got, want)
+	}
+}
+
+func TestFilterEntry(t *testing.T) {
+	e := Entry{Name: "a.txt", ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	fe := e.filterEntry("/src/a.txt")
+	if fe.Path != "src/a.txt" || fe.Size != -1 || !fe.ModTime.Equal(e.ModTime) {
+		t.Errorf("filterEntry = %+v", fe)
+	}
+}
+
+func TestLooksLikeEntries(t *testing.T) {
+	tests := map[string]bool{
+		string(readFixture(t, "Entries")):     true,
+		"D\n":                                 true,
+		"<html><body>Not Found</body></html>": false,
+		"/a/1.1///\nfoo\n":                    false,
+	}
+	for data, want := range tests {
+		if got := looksLikeEntries([]byte(data)); got != want {
+			t.Errorf("looksLikeEntries(%q) = %v, want %v", data, got, want)
+		}
+	}
+}
@@ -2,21 +2,43 @@ package dirlisting
 
 import (
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
 
-	"github.com/PuerkitoBio/goquery"
+// 默认的递归深度和文件数量上限
+const (
+	DefaultMaxDepth = 10
+	DefaultMaxFiles = 10000
 )
 
 // DirListingDumper 实现目录列表下载
 type DirListingDumper struct {
 	dumper.BaseDumper
+	MaxDepth int // 最大递归深度，0 表示不限制
+	MaxFiles int // 最多下载的文件数量，0 表示不限制
+	// Truncated 执行后为 true 表示达到了 MaxFiles 上限，下载的文件或生成的清单不完整
+	Truncated bool
+
+	// ListOnly 只遍历目录列表并生成清单，不下载文件
+	ListOnly bool
+	// InventoryFormat 清单格式 (csv 或 json)
+	InventoryFormat string
+	// Inventory 非空时不再遍历目录列表，只下载清单中属于当前目标的文件
+	Inventory []InventoryEntry
+	// Filter 下载文件的过滤条件
+	Filter *filter.Filter
+	// Paths 记录目录列表中的文件路径
+	Paths *dumper.PathSet
 }
 
 // NewDirListingDumper 创建 DirListingDumper 实例
@@ -26,7 +48,47 @@ func NewDirListingDumper() *DirListingDumper {
 			Name:        "dirlisting",
 			Description: "下载目录列表中的文件",
 		},
+		MaxDepth:        DefaultMaxDepth,
+		MaxFiles:        DefaultMaxFiles,
+		InventoryFormat: "csv",
+	}
+}
+
+// crawlState 记录一次目录列表遍历的状态
+type crawlState struct {
+	client     *http.Client
+	baseline   *soft404.Baseline
+	root       *url.URL
+	outdir     string
+	visited    map[string]bool
+	files      int
+	inventory  []InventoryEntry
+	pool       *dumper.Pool
+	progressCb dumper.ProgressCallback
+}
+
+// inScope 判断链接是否与起始URL同源且位于起始路径之下
+func (s *crawlState) inScope(u *url.URL) bool {
+	p := path.Clean(u.Path)
+	if strings.HasSuffix(u.Path, "/") {
+		p += "/"
 	}
+	return u.Scheme == s.root.Scheme &&
+		strings.EqualFold(u.Host, s.root.Host) &&
+		strings.HasPrefix(p, s.root.Path)
+}
+
+// visit 标记URL已访问，已访问过时返回 false
+func (s *crawlState) visit(u *url.URL) bool {
+	key := *u
+	key.RawQuery = ""
+	key.Fragment = ""
+	k := key.String()
+	if s.visited[k] {
+		return false
+	}
+	s.visited[k] = true
+	return true
 }
 
 // FileInfo 表示文件信息
@@ -35,6 +97,7 @@ type FileInfo struct {
 	URL     string // 文件URL
 	Size    string // 文件大小
 	ModTime string // 修改时间
+	IsDir   bool   // 是否为目录
 }
 
 // Check 检查目标是否存在目录列表
@@ -44,61 +107,55 @@ func (d *DirListingDumper) Check(targetURL string, client *http.Client) (bool, e
 		targetURL += "/"
 	}
 
-	// 获取页面内容
-	resp, err := client.Get(targetURL)
+	page, err := fetchListing(client, targetURL)
 	if err != nil {
 		return false, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return false, nil
-	}
+	return detectParser(page) != nil, nil
+}
 
-	// 解析HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+// fetchListing 获取并预解析目录列表页面
+func fetchListing(client *http.Client, targetURL string) (*listingPage, error) {
+	resp, err := client.Get(targetURL)
 	if err != nil {
-		return false, nil
+		return nil, fmt.Errorf("获取页面失败: %v", err)
 	}
+	defer resp.Body.Close()
 
-	// 检查是否包含目录列表特征
-	links := doc.Find("a")
-	if links.Length() == 0 {
-		return false, nil
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("页面状态码异常: %d", resp.StatusCode)
+	}
+
+	body, ok := dumper.ReadLimited(resp.Body, dumper.DefaultFetchLimit)
+	if !ok {
+		return nil, fmt.Errorf("读取页面失败或页面过大")
 	}
 
-	hasParentDir := false
-	hasFiles := false
+	return newListingPage(resp.Request.URL, resp.Header, body), nil
+}
 
-	links.Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
+// Parse 识别目录列表的服务器类型并解析其中的条目
+func (d *DirListingDumper) Parse(client *http.Client, targetURL string) ([]FileInfo, string, error) {
+	page, err := fetchListing(client, targetURL)
+	if err != nil {
+		return nil, "", err
+	}
 
-		if href == "../" || href == ".." {
-			hasParentDir = true
-		} else if !strings.HasPrefix(href, "?") && !strings.HasPrefix(href, "#") {
-			hasFiles = true
-		}
-	})
+	parser := detectParser(page)
+	if parser == nil {
+		return nil, "", fmt.Errorf("未识别到目录列表")
+	}
 
-	return hasParentDir && hasFiles, nil
+	return parser.Parse(page), parser.Name, nil
 }
 
 // Execute 执行下载操作
 func (d *DirListingDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
 	// 创建HTTP客户端
-	client := &http.Client{}
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return fmt.Errorf("代理设置错误: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		client.Transport = transport
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
 	}
 
 	// 确保URL以/结尾
@@ -106,117 +163,149 @@ func (d *DirListingDumper) Execute(targetURL string, outdir string, proxy string
 		targetURL += "/"
 	}
 
+	root, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("URL解析失败: %v", err)
+	}
+
 	// 创建输出目录
 	if err := os.MkdirAll(outdir, 0755); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
-	// 获取页面内容
-	resp, err := client.Get(targetURL)
-	if err != nil {
-		return fmt.Errorf("获取页面失败: %v", err)
+	state := &crawlState{
+		client:     client,
+		root:       root,
+		outdir:     outdir,
+		visited:    make(map[string]bool),
+		pool:       dumper.NewPool(workers),
+		progressCb: progressCb,
 	}
-	defer resp.Body.Close()
+	state.visit(root)
+	defer state.pool.Wait()
 
-	// 解析HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return fmt.Errorf("解析HTML失败: %v", err)
+	// 列表中的链接也可能指向返回200错误页面的文件，只生成清单时不需要校准
+	if !d.ListOnly {
+		state.baseline = soft404.Calibrate(client, targetURL)
 	}
 
-	// 下载所有文件
-	links := doc.Find("a")
-	links.Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
+	// 根据已有清单下载文件
+	if d.Inventory != nil {
+		d.downloadInventory(state)
+		return nil
+	}
+
+	d.crawl(state, targetURL, "", 0)
+
+	// 只生成清单时保存到输出目录
+	if d.ListOnly && len(state.inventory) > 0 {
+		filename := filepath.Join(outdir, "dirlisting-inventory."+d.InventoryFormat)
+		if err := SaveInventory(filename, state.inventory); err != nil {
+			return err
+		}
+		if progressCb != nil {
+			progressCb(targetURL, http.StatusOK, filename)
 		}
+	}
 
-		// 跳过父目录和特殊链接
-		if href == "../" || href == ".." || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+	return nil
+}
+
+// downloadInventory 下载清单中属于当前目标的文件
+func (d *DirListingDumper) downloadInventory(state *crawlState) {
+	for _, entry := range d.Inventory {
+		if entry.Type == "dir" {
+			continue
+		}
+		if d.MaxFiles > 0 && state.files >= d.MaxFiles {
+			d.Truncated = true
 			return
 		}
 
-		// 构建完整URL
-		baseURL, err := url.Parse(targetURL)
-		if err != nil {
-			if progressCb != nil {
-				progressCb(href, 0, "URL解析失败")
-			}
-			return
+		fileURL, err := url.Parse(entry.URL)
+		if err != nil || !state.inScope(fileURL) || !state.visit(fileURL) {
+			continue
 		}
+		d.Paths.Add(entry.Path)
 
-		fileURL, err := baseURL.Parse(href)
-		if err != nil {
-			if progressCb != nil {
-				progressCb(href, 0, "URL解析失败")
-			}
-			return
+		if !d.Filter.Match(filter.Entry{Path: entry.Path, Size: entry.Size, ModTime: filter.ParseTime(entry.ModTime)}) {
+			continue
 		}
 
-		// 构建本地路径
-		localPath := filepath.Join(outdir, href)
+		state.files++
+		state.pool.Go(func() {
+			dumper.DownloadStream(state.client, state.baseline, d.Filter, entry.URL, state.outdir, entry.Path, state.progressCb)
+		})
+	}
+}
 
-		// 创建目录
-		if strings.HasSuffix(href, "/") {
-			if err := os.MkdirAll(localPath, 0755); err != nil {
-				if progressCb != nil {
-					progressCb(fileURL.String(), 0, "创建目录失败")
-				}
-				return
-			}
-			// 递归下载子目录
-			if err := d.Execute(fileURL.String(), localPath, proxy, force, debug, workers, progressCb); err != nil {
-				if progressCb != nil {
-					progressCb(fileURL.String(), 0, "下载子目录失败")
-				}
-			}
-			return
-		}
+// crawl 解析一个目录列表页面，下载其中的文件并递归子目录
+// 文件交给 pool 并发下载，子目录在当前 goroutine 中依次解析
+func (d *DirListingDumper) crawl(state *crawlState, dirURL string, relDir string, depth int) {
+	progressCb := state.progressCb
 
-		// 下载文件
-		resp, err := client.Get(fileURL.String())
-		if err != nil {
-			if progressCb != nil {
-				progressCb(fileURL.String(), 0, "下载失败")
-			}
+	// 不是目录列表的页面直接跳过
+	files, _, err := d.Parse(state.client, dirURL)
+	if err != nil {
+		return
+	}
+
+	for _, fi := range files {
+		if d.MaxFiles > 0 && state.files >= d.MaxFiles {
+			d.Truncated = true
 			return
 		}
 
-		// 调用进度回调
-		if progressCb != nil {
-			progressCb(fileURL.String(), resp.StatusCode, localPath)
+		fileURL, err := url.Parse(fi.URL)
+		if err != nil || !state.inScope(fileURL) || !state.visit(fileURL) {
+			continue
 		}
 
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			return
+		// 链接可能指向 ../ 等越出输出目录的位置
+		name := relDir + "/" + fi.Name
+		if _, err := dumper.SafePath(state.outdir, name); err != nil {
+			if progressCb != nil {
+				progressCb(fi.URL, 0, "非法路径")
+			}
+			continue
 		}
 
-		// 创建本地文件
-		f, err := os.Create(localPath)
-		if err != nil {
-			resp.Body.Close()
-			if progressCb != nil {
-				progressCb(fileURL.String(), 0, "创建文件失败")
+		// 递归下载子目录
+		if fi.IsDir {
+			if d.ListOnly {
+				state.inventory = append(state.inventory, newInventoryEntry(fi, name))
 			}
-			return
+			if d.MaxDepth > 0 && depth+1 > d.MaxDepth {
+				continue
+			}
+			d.crawl(state, fi.URL, name, depth+1)
+			continue
 		}
 
-		// 写入文件内容
-		_, err = io.Copy(f, resp.Body)
-		resp.Body.Close()
-		f.Close()
+		d.Paths.Add(name)
 
-		if err != nil {
-			if progressCb != nil {
-				progressCb(fileURL.String(), 0, "写入失败")
-			}
-			return
+		// 只记录清单，不下载文件
+		if d.ListOnly {
+			state.files++
+			state.inventory = append(state.inventory, newInventoryEntry(fi, name))
+			continue
 		}
-	})
 
-	return nil
+		entry := filter.Entry{
+			Path:    strings.TrimPrefix(name, "/"),
+			Size:    filter.ParseSize(fi.Size),
+			ModTime: filter.ParseTime(fi.ModTime),
+		}
+		if !d.Filter.Match(entry) {
+			continue
+		}
+
+		state.files++
+
+		state.pool.Go(func() {
+			dumper.DownloadStream(state.client, state.baseline, d.Filter, fi.URL, state.outdir, name, state.progressCb)
+		})
+	}
 }
 
 // Validate 验证URL是否有效
@@ -0,0 +1,113 @@
+package dirlisting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 恶意目录列表中的链接，全部指向输出目录之外
+var hostileHrefs = []string{
+	"../../../../evil1",
+	"%2e%2e/%2e%2e/%2e%2e/evil2",
+	"..%2f..%2f..%2fevil3",
+	"%252e%252e%252f%252e%252e%252fevil4",
+	"..%5c..%5c..%5cevil5",
+	"%2e%2e%5cevil6",
+	"evil7%00.txt",
+	"/evil8",
+	"//evil.example/evil9",
+	"file:///etc/evil10",
+}
+
+func hostileServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/dir/":
+			w.Header().Set("Server", "Apache")
+			var links strings.Builder
+			for _, href := range append(hostileHrefs, "ok.txt", "sub/") {
+				links.WriteString(`<a href="` + href + `">` + href + "</a>\n")
+			}
+			w.Write([]byte("<html><head><title>Index of /dir</title></head><body><h1>Index of /dir</h1><pre>" + links.String() + "</pre></body></html>"))
+		case r.URL.Path == "/dir/sub/":
+			// 子目录中的 JSON 列表直接在名称中使用 .. 和反斜杠
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"name":"../../../evil11","type":"file","mtime":"Mon, 01 Jan 2024 00:00:00 GMT","size":5},` +
+				`{"name":"..\\..\\evil12","type":"file","mtime":"Mon, 01 Jan 2024 00:00:00 GMT","size":5},` +
+				`{"name":"..","type":"directory","mtime":"Mon, 01 Jan 2024 00:00:00 GMT"},` +
+				`{"name":"ok2.txt","type":"file","mtime":"Mon, 01 Jan 2024 00:00:00 GMT","size":2}]`))
+		case r.URL.Path == "/dir/ok.txt", r.URL.Path == "/dir/sub/ok2.txt":
+			w.Write([]byte("ok"))
+		default:
+			// 其他任何路径都返回内容，确认写入前的路径检查生效
+			w.Write([]byte("pwned"))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExecuteHostileListing(t *testing.T) {
+	srv := hostileServer(t)
+
+	base := t.TempDir()
+	outdir := filepath.Join(base, "a", "b", "c", "out")
+
+	d := NewDirListingDumper()
+	if err := d.Execute(srv.URL+"/dir/", outdir, "", false, false, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []string
+	filepath.WalkDir(base, func(p string, e os.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outdir, p)
+		if err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("file written outside output directory: %s", p)
+			return nil
+		}
+		saved = append(saved, filepath.ToSlash(rel))
+		return nil
+	})
+
+	if got := strings.Join(saved, ","); got != "ok.txt,sub/ok2.txt" {
+		t.Errorf("saved files = %s, want ok.txt,sub/ok2.txt", got)
+	}
+}
+
+// 列表中已删除的文件返回200错误页面时不保存
+func TestExecuteSoft404(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dir/":
+			w.Header().Set("Server", "Apache")
+			w.Write([]byte(`<html><head><title>Index of /dir</title></head><body><h1>Index of /dir</h1><pre>` +
+				`<a href="a.txt">a.txt</a>` + "\n" + `<a href="gone.txt">gone.txt</a>` + "\n</pre></body></html>"))
+		case "/dir/a.txt":
+			w.Write([]byte("real file"))
+		default:
+			w.Write([]byte("<html><head><title>Page Not Found</title></head><body>" + r.URL.Path + " was not found</body></html>"))
+		}
+	}))
+	defer srv.Close()
+
+	outdir := t.TempDir()
+	if err := NewDirListingDumper().Execute(srv.URL+"/dir/", outdir, "", false, false, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outdir, "a.txt")); err != nil {
+		t.Errorf("a.txt 未保存: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outdir, "gone.txt")); err == nil {
+		t.Error("错误页面被保存为 gone.txt")
+	}
+}
@@ -0,0 +1,133 @@
+package dirlisting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"dumpall-go/internal/filter"
+)
+
+// InventoryEntry 表示清单中的一条目录列表记录
+type InventoryEntry struct {
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size"`
+	RawSize string `json:"raw_size,omitempty"`
+	ModTime string `json:"mtime,omitempty"`
+}
+
+// 清单的 CSV 表头
+var inventoryHeader = []string{"url", "path", "type", "size", "raw_size", "mtime"}
+
+// newInventoryEntry 根据解析到的文件信息生成清单记录
+func newInventoryEntry(fi FileInfo, name string) InventoryEntry {
+	entry := InventoryEntry{
+		URL:     fi.URL,
+		Path:    strings.TrimPrefix(name, "/"),
+		Type:    "file",
+		Size:    filter.ParseSize(fi.Size),
+		RawSize: fi.Size,
+		ModTime: fi.ModTime,
+	}
+	if fi.IsDir {
+		entry.Type = "dir"
+		entry.Size = -1
+	}
+	return entry
+}
+
+// WriteInventory 按指定格式写出清单
+func WriteInventory(w io.Writer, entries []InventoryEntry, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if entries == nil {
+			entries = []InventoryEntry{}
+		}
+		return enc.Encode(entries)
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(inventoryHeader)
+		for _, e := range entries {
+			cw.Write([]string{e.URL, e.Path, e.Type, strconv.FormatInt(e.Size, 10), e.RawSize, e.ModTime})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return fmt.Errorf("不支持的清单格式: %s", format)
+}
+
+// SaveInventory 将清单保存到文件，格式由扩展名决定
+func SaveInventory(filename string, entries []InventoryEntry) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("创建清单文件失败: %v", err)
+	}
+	defer f.Close()
+
+	return WriteInventory(f, entries, inventoryFormat(filename))
+}
+
+// LoadInventory 读取清单文件，格式由扩展名决定
+func LoadInventory(filename string) ([]InventoryEntry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("打开清单文件失败: %v", err)
+	}
+	defer f.Close()
+
+	if inventoryFormat(filename) == "json" {
+		var entries []InventoryEntry
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("解析清单文件失败: %v", err)
+		}
+		return entries, nil
+	}
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析清单文件失败: %v", err)
+	}
+
+	var entries []InventoryEntry
+	for i, rec := range records {
+		// 跳过表头
+		if i == 0 && len(rec) > 0 && rec[0] == inventoryHeader[0] {
+			continue
+		}
+		if len(rec) < 3 {
+			continue
+		}
+		entry := InventoryEntry{URL: rec[0], Path: rec[1], Type: rec[2], Size: -1}
+		if len(rec) > 3 {
+			if size, err := strconv.ParseInt(rec[3], 10, 64); err == nil {
+				entry.Size = size
+			}
+		}
+		if len(rec) > 4 {
+			entry.RawSize = rec[4]
+		}
+		if len(rec) > 5 {
+			entry.ModTime = rec[5]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func inventoryFormat(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return "json"
+	}
+	return "csv"
+}
@@ -0,0 +1,605 @@
+package dirlisting
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// listingPage 表示一个待解析的目录列表页面
+type listingPage struct {
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+	Doc    *goquery.Document
+	Title  string
+}
+
+// listingParser 解析特定服务器生成的目录列表
+type listingParser struct {
+	Name  string
+	Match func(p *listingPage) bool
+	Parse func(p *listingPage) []FileInfo
+}
+
+// 按指纹识别的顺序排列，越具体的解析器越靠前
+var listingParsers = []listingParser{
+	{Name: "nginx-json", Match: matchNginxJSON, Parse: parseNginxJSON},
+	{Name: "nginx-xml", Match: matchNginxXML, Parse: parseNginxXML},
+	{Name: "caddy-json", Match: matchCaddyJSON, Parse: parseCaddyJSON},
+	{Name: "caddy", Match: matchCaddy, Parse: parseCaddy},
+	{Name: "lighttpd", Match: matchLighttpd, Parse: parseLighttpd},
+	{Name: "tomcat", Match: matchTomcat, Parse: parseTomcat},
+	{Name: "jetty", Match: matchJetty, Parse: parseJetty},
+	{Name: "iis", Match: matchIIS, Parse: parseIIS},
+	{Name: "apache-table", Match: matchApacheTable, Parse: parseApacheTable},
+	{Name: "apache", Match: matchApache, Parse: parseApache},
+	{Name: "nginx", Match: matchNginx, Parse: parseNginx},
+	{Name: "python", Match: matchPython, Parse: parsePython},
+	{Name: "generic", Match: matchGeneric, Parse: parseGeneric},
+}
+
+// newListingPage 根据响应内容构建页面，HTML 页面会预先解析
+func newListingPage(pageURL *url.URL, header http.Header, body []byte) *listingPage {
+	p := &listingPage{URL: pageURL, Header: header, Body: body}
+	if !p.isJSON() && !p.isXML() {
+		if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body)); err == nil {
+			p.Doc = doc
+			p.Title = strings.TrimSpace(doc.Find("title").First().Text())
+		}
+	}
+	return p
+}
+
+// detectParser 根据指纹选择对应的解析器
+func detectParser(p *listingPage) *listingParser {
+	for i := range listingParsers {
+		if listingParsers[i].Match(p) {
+			return &listingParsers[i]
+		}
+	}
+	return nil
+}
+
+func (p *listingPage) server() string {
+	return strings.ToLower(p.Header.Get("Server"))
+}
+
+func (p *listingPage) isJSON() bool {
+	trimmed := bytes.TrimSpace(p.Body)
+	return strings.Contains(p.Header.Get("Content-Type"), "json") || bytes.HasPrefix(trimmed, []byte("["))
+}
+
+func (p *listingPage) isXML() bool {
+	trimmed := bytes.TrimSpace(p.Body)
+	return strings.Contains(p.Header.Get("Content-Type"), "xml") && !bytes.Contains(bytes.ToLower(trimmed[:min(len(trimmed), 512)]), []byte("<html"))
+}
+
+// entry 根据链接构建一条记录，父目录和排序等特殊链接返回 false
+func (p *listingPage) entry(href string) (FileInfo, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || href == "/" || href == "../" || href == ".." || href == "./" ||
+		strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+		return FileInfo{}, false
+	}
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return FileInfo{}, false
+	}
+	// 指向当前目录或上级目录的链接不是条目
+	fileURL := p.URL.ResolveReference(ref)
+	if strings.HasPrefix(p.URL.Path, fileURL.Path) {
+		return FileInfo{}, false
+	}
+
+	isDir := strings.HasSuffix(fileURL.Path, "/")
+	name := path.Base(strings.TrimSuffix(fileURL.Path, "/"))
+	if name == "." || name == "/" || name == "" {
+		return FileInfo{}, false
+	}
+
+	return FileInfo{
+		Name:  name,
+		URL:   fileURL.String(),
+		IsDir: isDir,
+	}, true
+}
+
+// anchors 遍历选择器匹配的链接并生成记录
+func (p *listingPage) anchors(sel *goquery.Selection, fn func(s *goquery.Selection, fi *FileInfo)) []FileInfo {
+	var files []FileInfo
+	sel.Each(func(i int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			return
+		}
+		if fn != nil {
+			fn(s, &fi)
+		}
+		files = append(files, fi)
+	})
+	return files
+}
+
+// nextText 返回链接之后同一行的文本
+func nextText(s *goquery.Selection) string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+	var buf strings.Builder
+	for n := s.Nodes[0].NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == html.TextNode {
+			line, _, found := strings.Cut(n.Data, "\n")
+			buf.WriteString(line)
+			if found {
+				break
+			}
+			continue
+		}
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "br") {
+			break
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// prevText 返回链接之前同一行的文本
+func prevText(s *goquery.Selection) string {
+	if len(s.Nodes) == 0 {
+		return ""
+	}
+	var parts []string
+	for n := s.Nodes[0].PrevSibling; n != nil; n = n.PrevSibling {
+		if n.Type == html.TextNode {
+			idx := strings.LastIndex(n.Data, "\n")
+			parts = append([]string{n.Data[idx+1:]}, parts...)
+			if idx >= 0 {
+				break
+			}
+			continue
+		}
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "br") {
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, ""))
+}
+
+func cleanText(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\u00a0", " "))
+}
+
+func titleHasPrefix(p *listingPage, prefix string) bool {
+	return strings.HasPrefix(p.Title, prefix)
+}
+
+// Apache mod_autoindex
+
+// 日期时间后跟大小，例如 "2020-01-01 10:00  1.2K" 或 "01-Jan-2020 10:00  -"
+var apacheLine = regexp.MustCompile(`^(\S+\s+\d{1,2}:\d{2}(?::\d{2})?)\s+(\S+)`)
+
+func matchApache(p *listingPage) bool {
+	if p.Doc == nil || !titleHasPrefix(p, "Index of") {
+		return false
+	}
+	return strings.Contains(p.server(), "apache") ||
+		bytes.Contains(p.Body, []byte("?C=N;O=D")) ||
+		bytes.Contains(p.Body, []byte("?C=M;O=A"))
+}
+
+func parseApache(p *listingPage) []FileInfo {
+	// FancyIndexing 使用 <pre>，关闭后使用 <ul><li>
+	if p.Doc.Find("pre a").Length() > 0 {
+		return p.anchors(p.Doc.Find("pre a"), func(s *goquery.Selection, fi *FileInfo) {
+			if m := apacheLine.FindStringSubmatch(nextText(s)); m != nil {
+				fi.ModTime = m[1]
+				if m[2] != "-" {
+					fi.Size = m[2]
+				}
+			}
+		})
+	}
+	return p.anchors(p.Doc.Find("ul li a"), nil)
+}
+
+func matchApacheTable(p *listingPage) bool {
+	return matchApache(p) && p.Doc.Find("table th a[href^='?C=']").Length() > 0
+}
+
+func parseApacheTable(p *listingPage) []FileInfo {
+	var files []FileInfo
+	p.Doc.Find("table tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		link := cells.Find("a").First()
+		href, ok := link.Attr("href")
+		if !ok {
+			return
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			return
+		}
+		// 列顺序: 图标、名称、修改时间、大小、描述
+		idx := link.Closest("td").Index()
+		if idx+1 < cells.Length() {
+			fi.ModTime = cleanText(cells.Eq(idx + 1).Text())
+		}
+		if idx+2 < cells.Length() {
+			if size := cleanText(cells.Eq(idx + 2).Text()); size != "-" {
+				fi.Size = size
+			}
+		}
+		files = append(files, fi)
+	})
+	return files
+}
+
+// nginx autoindex
+
+// 例如 "01-Jan-2020 10:00     1234" 或 "01-Jan-2020 10:00       -"
+var nginxLine = regexp.MustCompile(`^(\d{2}-\w{3}-\d{4}\s+\d{2}:\d{2})\s+(\S+)`)
+
+func matchNginx(p *listingPage) bool {
+	if p.Doc == nil || !titleHasPrefix(p, "Index of") {
+		return false
+	}
+	return strings.Contains(p.server(), "nginx") || p.Doc.Find("body > pre a").Length() > 0
+}
+
+func parseNginx(p *listingPage) []FileInfo {
+	return p.anchors(p.Doc.Find("pre a"), func(s *goquery.Selection, fi *FileInfo) {
+		if m := nginxLine.FindStringSubmatch(nextText(s)); m != nil {
+			fi.ModTime = m[1]
+			if m[2] != "-" {
+				fi.Size = m[2]
+			}
+		}
+	})
+}
+
+// nginx autoindex_format json
+type nginxJSONEntry struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	MTime string      `json:"mtime"`
+	Size  json.Number `json:"size"`
+}
+
+func matchNginxJSON(p *listingPage) bool {
+	if !p.isJSON() {
+		return false
+	}
+	var entries []map[string]interface{}
+	if json.Unmarshal(p.Body, &entries) != nil || len(entries) == 0 {
+		return false
+	}
+	_, hasName := entries[0]["name"]
+	_, hasType := entries[0]["type"]
+	_, hasMTime := entries[0]["mtime"]
+	return hasName && hasType && hasMTime
+}
+
+func parseNginxJSON(p *listingPage) []FileInfo {
+	var entries []nginxJSONEntry
+	if json.Unmarshal(p.Body, &entries) != nil {
+		return nil
+	}
+	var files []FileInfo
+	for _, e := range entries {
+		href := url.PathEscape(e.Name)
+		if e.Type == "directory" {
+			href += "/"
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			continue
+		}
+		fi.ModTime = e.MTime
+		fi.Size = e.Size.String()
+		files = append(files, fi)
+	}
+	return files
+}
+
+// nginx autoindex_format xml
+type nginxXMLList struct {
+	XMLName xml.Name `xml:"list"`
+	Items   []struct {
+		XMLName xml.Name
+		MTime   string `xml:"mtime,attr"`
+		Size    string `xml:"size,attr"`
+		Name    string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+func matchNginxXML(p *listingPage) bool {
+	if !p.isXML() {
+		return false
+	}
+	var list nginxXMLList
+	return xml.Unmarshal(p.Body, &list) == nil
+}
+
+func parseNginxXML(p *listingPage) []FileInfo {
+	var list nginxXMLList
+	if xml.Unmarshal(p.Body, &list) != nil {
+		return nil
+	}
+	var files []FileInfo
+	for _, item := range list.Items {
+		href := url.PathEscape(strings.TrimSpace(item.Name))
+		if item.XMLName.Local == "directory" {
+			href += "/"
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			continue
+		}
+		fi.ModTime = item.MTime
+		fi.Size = item.Size
+		files = append(files, fi)
+	}
+	return files
+}
+
+// IIS directory browsing
+
+// 例如 "1/1/2020 10:00 AM        1234" 或 "1/1/2020 10:00 AM        <dir>"
+var iisLine = regexp.MustCompile(`^(.+?(?:AM|PM|\d{2}:\d{2}))\s+(\S+)$`)
+
+func matchIIS(p *listingPage) bool {
+	if p.Doc == nil {
+		return false
+	}
+	return bytes.Contains(p.Body, []byte("[To Parent Directory]")) ||
+		(strings.Contains(p.server(), "microsoft-iis") && strings.Contains(p.Title, " - /"))
+}
+
+func parseIIS(p *listingPage) []FileInfo {
+	return p.anchors(p.Doc.Find("pre a"), func(s *goquery.Selection, fi *FileInfo) {
+		if m := iisLine.FindStringSubmatch(prevText(s)); m != nil {
+			fi.ModTime = m[1]
+			if !strings.EqualFold(m[2], "<dir>") {
+				fi.Size = m[2]
+			}
+		}
+	})
+}
+
+// lighttpd mod_dirlisting
+
+func matchLighttpd(p *listingPage) bool {
+	if p.Doc == nil {
+		return false
+	}
+	return p.Doc.Find(`table[summary="Directory Listing"]`).Length() > 0 ||
+		(strings.Contains(p.server(), "lighttpd") && p.Doc.Find("td.n a").Length() > 0)
+}
+
+func parseLighttpd(p *listingPage) []FileInfo {
+	var files []FileInfo
+	p.Doc.Find("tbody tr").Each(func(i int, row *goquery.Selection) {
+		href, ok := row.Find("td.n a").Attr("href")
+		if !ok {
+			return
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			return
+		}
+		fi.ModTime = cleanText(row.Find("td.m").Text())
+		if size := cleanText(row.Find("td.s").Text()); size != "-" {
+			fi.Size = size
+		}
+		files = append(files, fi)
+	})
+	return files
+}
+
+// Caddy file_server browse
+
+func matchCaddy(p *listingPage) bool {
+	if p.Doc == nil {
+		return false
+	}
+	return (strings.Contains(p.server(), "caddy") && p.Doc.Find("span.name").Length() > 0) ||
+		p.Doc.Find("tr.file span.name").Length() > 0
+}
+
+func parseCaddy(p *listingPage) []FileInfo {
+	var files []FileInfo
+	p.Doc.Find("tbody tr").Each(func(i int, row *goquery.Selection) {
+		link := row.Find("a").First()
+		href, ok := link.Attr("href")
+		if !ok {
+			return
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			return
+		}
+		// 大小列的 data-order 为字节数，目录为 -1
+		row.Find("td[data-order]").EachWithBreak(func(j int, td *goquery.Selection) bool {
+			if order, _ := td.Attr("data-order"); order != "-1" && order != "" {
+				fi.Size = order
+			}
+			return false
+		})
+		if t, ok := row.Find("time").Attr("datetime"); ok {
+			fi.ModTime = t
+		}
+		files = append(files, fi)
+	})
+	return files
+}
+
+// Caddy 在 Accept: application/json 时返回 JSON
+type caddyJSONEntry struct {
+	Name    string      `json:"name"`
+	URL     string      `json:"url"`
+	Size    json.Number `json:"size"`
+	ModTime string      `json:"mod_time"`
+	IsDir   bool        `json:"is_dir"`
+}
+
+func matchCaddyJSON(p *listingPage) bool {
+	if !p.isJSON() {
+		return false
+	}
+	var entries []map[string]interface{}
+	if json.Unmarshal(p.Body, &entries) != nil || len(entries) == 0 {
+		return false
+	}
+	_, hasModTime := entries[0]["mod_time"]
+	_, hasIsDir := entries[0]["is_dir"]
+	return hasModTime && hasIsDir
+}
+
+func parseCaddyJSON(p *listingPage) []FileInfo {
+	var entries []caddyJSONEntry
+	if json.Unmarshal(p.Body, &entries) != nil {
+		return nil
+	}
+	var files []FileInfo
+	for _, e := range entries {
+		href := e.URL
+		if href == "" {
+			href = url.PathEscape(e.Name)
+		}
+		if e.IsDir && !strings.HasSuffix(href, "/") {
+			href += "/"
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			continue
+		}
+		fi.ModTime = e.ModTime
+		if !e.IsDir {
+			fi.Size = e.Size.String()
+		}
+		files = append(files, fi)
+	}
+	return files
+}
+
+// Python http.server
+
+func matchPython(p *listingPage) bool {
+	return p.Doc != nil && titleHasPrefix(p, "Directory listing for")
+}
+
+func parsePython(p *listingPage) []FileInfo {
+	return p.anchors(p.Doc.Find("ul li a"), nil)
+}
+
+// Tomcat DefaultServlet
+
+func matchTomcat(p *listingPage) bool {
+	return p.Doc != nil && titleHasPrefix(p, "Directory Listing For")
+}
+
+func parseTomcat(p *listingPage) []FileInfo {
+	var files []FileInfo
+	p.Doc.Find("table tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		href, ok := cells.First().Find("a").Attr("href")
+		if !ok {
+			return
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			return
+		}
+		// 列顺序: 文件名、大小、修改时间
+		if cells.Length() > 1 {
+			fi.Size = cleanText(cells.Eq(1).Text())
+		}
+		if cells.Length() > 2 {
+			fi.ModTime = cleanText(cells.Eq(2).Text())
+		}
+		files = append(files, fi)
+	})
+	return files
+}
+
+// Jetty ResourceService
+
+func matchJetty(p *listingPage) bool {
+	if p.Doc == nil {
+		return false
+	}
+	return titleHasPrefix(p, "Directory: ") &&
+		(strings.Contains(p.server(), "jetty") || p.Doc.Find("table").Length() > 0)
+}
+
+func parseJetty(p *listingPage) []FileInfo {
+	var files []FileInfo
+	p.Doc.Find("table tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		link := cells.Find("a").First()
+		href, ok := link.Attr("href")
+		if !ok {
+			return
+		}
+		fi, ok := p.entry(href)
+		if !ok {
+			return
+		}
+		// 新版本使用 class 标记列，旧版本列顺序为: 文件名、大小、修改时间
+		if size := row.Find("td.size"); size.Length() > 0 {
+			fi.Size = cleanText(size.Text())
+			fi.ModTime = cleanText(row.Find("td.lastmodified").Text())
+		} else if cells.Length() > 2 {
+			fi.Size = cleanText(cells.Eq(1).Text())
+			fi.ModTime = cleanText(cells.Eq(2).Text())
+		}
+		if fi.IsDir || fi.Size == "-" {
+			fi.Size = ""
+		}
+		fi.Size = strings.TrimSuffix(fi.Size, " bytes")
+		files = append(files, fi)
+	})
+	return files
+}
+
+// 未识别的服务器: 包含父目录链接和至少一个其他链接的页面视为目录列表
+
+func matchGeneric(p *listingPage) bool {
+	if p.Doc == nil {
+		return false
+	}
+
+	hasParentDir := false
+	hasFiles := false
+	p.Doc.Find("a").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		if href == "../" || href == ".." {
+			hasParentDir = true
+		} else if !strings.HasPrefix(href, "?") && !strings.HasPrefix(href, "#") {
+			hasFiles = true
+		}
+	})
+
+	return hasParentDir && hasFiles
+}
+
+func parseGeneric(p *listingPage) []FileInfo {
+	return p.anchors(p.Doc.Find("a"), nil)
+}
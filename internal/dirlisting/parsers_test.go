@@ -0,0 +1,154 @@
+package dirlisting
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dumpall-go/internal/filter"
+)
+
+// wantEntry 为解析结果的期望值，大小和时间按 filter 的规则解析后比较
+type wantEntry struct {
+	name  string
+	dir   bool
+	size  int64
+	mtime string
+}
+
+var (
+	jan2    = "2024-01-02T03:04:05Z"
+	jan2Min = "2024-01-02T03:04:00Z"
+	feb1    = "2023-02-01T12:30:00Z"
+)
+
+// testdata 中每个文件对应一种服务器的目录列表，页面地址均为 http://example.com/files/
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		file        string
+		server      string
+		contentType string
+		parser      string
+		want        []wantEntry
+	}{
+		{"nginx.html", "nginx/1.24.0", "text/html", "nginx", []wantEntry{
+			{"images", true, -1, jan2Min},
+			{"backup.sql", false, 1048576, jan2Min},
+			{"中文.txt", false, 12, "2023-02-01T12:30:00Z"},
+		}},
+		{"nginx.json", "nginx/1.24.0", "application/json", "nginx-json", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"nginx.xml", "nginx/1.24.0", "text/xml", "nginx-xml", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"caddy.json", "Caddy", "application/json", "caddy-json", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"caddy.html", "Caddy", "text/html; charset=utf-8", "caddy", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"lighttpd.html", "lighttpd/1.4.59", "text/html", "lighttpd", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 102, feb1},
+		}},
+		{"tomcat.html", "", "text/html;charset=UTF-8", "tomcat", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 102, feb1},
+		}},
+		{"jetty.html", "Jetty(10.0.18)", "text/html;charset=utf-8", "jetty", []wantEntry{
+			{"images", true, -1, jan2},
+			{"backup.sql", false, 1048576, jan2},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"iis.html", "Microsoft-IIS/10.0", "text/html; charset=UTF-8", "iis", []wantEntry{
+			{"images", true, -1, jan2Min},
+			{"backup.sql", false, 1048576, jan2Min},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"apache-table.html", "Apache/2.4.41 (Ubuntu)", "text/html;charset=UTF-8", "apache-table", []wantEntry{
+			{"images", true, -1, jan2Min},
+			{"backup.sql", false, 1048576, jan2Min},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"apache.html", "Apache/2.4.41 (Ubuntu)", "text/html;charset=UTF-8", "apache", []wantEntry{
+			{"images", true, -1, jan2Min},
+			{"backup.sql", false, 1048576, jan2Min},
+			{"中文.txt", false, 12, feb1},
+		}},
+		{"apache-list.html", "Apache/2.4.41 (Ubuntu)", "text/html;charset=UTF-8", "apache", []wantEntry{
+			{"images", true, -1, ""},
+			{"backup.sql", false, -1, ""},
+			{"中文.txt", false, -1, ""},
+		}},
+		{"python.html", "SimpleHTTP/0.6 Python/3.11.4", "text/html; charset=utf-8", "python", []wantEntry{
+			{"images", true, -1, ""},
+			{"backup.sql", false, -1, ""},
+			{"中文.txt", false, -1, ""},
+		}},
+		{"generic.html", "", "text/html", "generic", []wantEntry{
+			{"images", true, -1, ""},
+			{"backup.sql", false, -1, ""},
+			{"中文.txt", false, -1, ""},
+		}},
+	}
+
+	pageURL, _ := url.Parse("http://example.com/files/")
+	for _, tt := range tests {
+		body, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{}
+		header.Set("Content-Type", tt.contentType)
+		if tt.server != "" {
+			header.Set("Server", tt.server)
+		}
+
+		page := newListingPage(pageURL, header, body)
+		parser := detectParser(page)
+		if parser == nil || parser.Name != tt.parser {
+			t.Errorf("%s: 识别的解析器 = %v, want %s", tt.file, parser, tt.parser)
+			continue
+		}
+		got := parser.Parse(page)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: 得到 %d 项, want %d: %+v", tt.file, len(got), len(tt.want), got)
+			continue
+		}
+		for i, w := range tt.want {
+			fi := got[i]
+			if fi.Name != w.name || fi.IsDir != w.dir {
+				t.Errorf("%s[%d]: Name = %q IsDir = %v, want %q %v", tt.file, i, fi.Name, fi.IsDir, w.name, w.dir)
+			}
+			if u, err := url.Parse(fi.URL); err != nil || u.Host != "example.com" || path.Dir(strings.TrimSuffix(u.Path, "/")) != "/files" {
+				t.Errorf("%s[%d]: URL = %q 不在 /files/ 下", tt.file, i, fi.URL)
+			}
+			if size := filter.ParseSize(fi.Size); size != w.size {
+				t.Errorf("%s[%d]: Size %q = %d, want %d", tt.file, i, fi.Size, size, w.size)
+			}
+			var mtime string
+			if tm := filter.ParseTime(fi.ModTime); !tm.IsZero() {
+				mtime = tm.UTC().Format(time.RFC3339)
+			}
+			if mtime != w.mtime {
+				t.Errorf("%s[%d]: ModTime %q = %q, want %q", tt.file, i, fi.ModTime, mtime, w.mtime)
+			}
+		}
+	}
+}
@@ -2,21 +2,26 @@ package dsstore
 
 import (
 	"bytes"
-	"encoding/binary"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 
 	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
 )
 
 // DsStoreDumper 实现 DS_Store 子命令
 type DsStoreDumper struct {
 	dumper.BaseDumper
+	// Filter 下载文件的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 .DS_Store 中的文件路径
+	Paths *dumper.PathSet
 }
 
 // NewDsStoreDumper 创建新的 DsStoreDumper 实例
@@ -36,6 +41,9 @@ type DSStore struct {
 	Records []Record // 记录列表
 }
 
+// 递归解析子目录 .DS_Store 的最大深度
+const maxCrawlDepth = 16
+
 // Record 表示一条记录
 type Record struct {
 	Name     string // 文件或目录名
@@ -51,34 +59,18 @@ func (d *DsStoreDumper) Check(targetURL string, client *http.Client) (bool, erro
 		targetURL += "/"
 	}
 
-	// 检查 .DS_Store 文件
+	// 检查 .DS_Store 文件，排除不存在的文件也返回200的情况
 	dsStoreURL := targetURL + ".DS_Store"
-	resp, err := client.Head(dsStoreURL)
-	if err != nil {
-		return false, nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
-	}
-
-	return false, nil
+	_, ok := dumper.Fetch(client, soft404.Calibrate(client, targetURL), dsStoreURL, 0, nil)
+	return ok, nil
 }
 
 // Execute 执行下载操作
 func (d *DsStoreDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
 	// 创建HTTP客户端
-	client := &http.Client{}
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return fmt.Errorf("代理设置错误: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		client.Transport = transport
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
 	}
 
 	// 确保URL以/结尾
@@ -91,46 +83,51 @@ func (d *DsStoreDumper) Execute(targetURL string, outdir string, proxy string, f
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
-	// 下载 .DS_Store 文件
-	fileURL := targetURL + ".DS_Store"
-	localPath := filepath.Join(outdir, ".DS_Store")
+	baseline := soft404.Calibrate(client, targetURL)
 
-	// 下载文件
-	resp, err := client.Get(fileURL)
-	if err != nil {
-		if progressCb != nil {
-			progressCb(fileURL, 0, "下载失败")
-		}
-		return err
-	}
-	defer resp.Body.Close()
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
 
-	// 调用进度回调
-	if progressCb != nil {
-		progressCb(fileURL, resp.StatusCode, localPath)
-	}
+	return d.crawl(client, baseline, pool, targetURL, outdir, "", 0, progressCb)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("文件不存在")
+// crawl 下载并解析当前目录的 .DS_Store，然后根据其中的记录下载文件并递归子目录
+// 文件交给 pool 并发下载，子目录的 .DS_Store 在当前 goroutine 中依次解析
+func (d *DsStoreDumper) crawl(client *http.Client, baseline *soft404.Baseline, pool *dumper.Pool, baseURL string, outdir string, relDir string, depth int, progressCb dumper.ProgressCallback) error {
+	fileURL := baseURL + ".DS_Store"
+
+	data, ok := dumper.Download(client, baseline, nil, fileURL, outdir, relDir+"/.DS_Store", 0, progressCb)
+	if !ok {
+		return fmt.Errorf("下载 .DS_Store 失败")
 	}
 
-	// 创建本地文件
-	f, err := os.Create(localPath)
+	ds, err := Parse(data)
 	if err != nil {
-		if progressCb != nil {
-			progressCb(fileURL, 0, "创建文件失败")
-		}
-		return err
+		return fmt.Errorf("解析 .DS_Store 失败: %v", err)
 	}
-	defer f.Close()
 
-	// 写入文件内容
-	_, err = io.Copy(f, resp.Body)
-	if err != nil {
-		if progressCb != nil {
-			progressCb(fileURL, 0, "写入失败")
+	for _, rec := range ds.Records {
+		// 记录名是当前目录下的文件名，跳过 . .. 和包含路径分隔符的名称
+		if rec.Name == "." || rec.Name == ".." || strings.ContainsAny(rec.Name, "/\\") {
+			continue
+		}
+
+		entryURL := baseURL + url.PathEscape(rec.Name)
+		entryPath := relDir + "/" + rec.Name
+
+		if rec.Type == "file" {
+			d.Paths.Add(entryPath)
+			if d.Filter.Match(rec.filterEntry(entryPath)) {
+				pool.Go(func() {
+					dumper.Download(client, baseline, d.Filter, entryURL, outdir, entryPath, 0, progressCb)
+				})
+			}
+		}
+
+		// 目录以及没有扩展名的记录都尝试作为子目录继续解析
+		if depth < maxCrawlDepth && (rec.Type == "dir" || path.Ext(rec.Name) == "") {
+			d.crawl(client, baseline, pool, entryURL+"/", outdir, entryPath, depth+1, progressCb)
 		}
-		return err
 	}
 
 	return nil
@@ -146,20 +143,10 @@ func (d *DsStoreDumper) Validate(url string) error {
 
 // Dump 下载并解析 .DS_Store 文件
 func (d *DsStoreDumper) Dump(targetURL, outdir, proxy string, force bool) error {
-	// 解析代理URL
-	var httpClient *http.Client
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return fmt.Errorf("解析代理URL失败: %v", err)
-		}
-		httpClient = &http.Client{
-			Transport: &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			},
-		}
-	} else {
-		httpClient = &http.Client{}
+	// 创建HTTP客户端
+	httpClient, err := httpclient.New(proxy)
+	if err != nil {
+		return err
 	}
 
 	// 下载 .DS_Store 文件
@@ -174,42 +161,20 @@ func (d *DsStoreDumper) Dump(targetURL, outdir, proxy string, force bool) error
 	}
 
 	// 读取文件内容
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
+	data, ok := dumper.ReadLimited(resp.Body, dumper.DefaultFetchLimit)
+	if !ok {
+		return fmt.Errorf("读取文件失败或文件过大")
 	}
 
 	// 保存原始文件
-	outPath := filepath.Join(outdir, ".DS_Store")
-	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-		return fmt.Errorf("创建目录失败: %v", err)
-	}
-
-	if err := os.WriteFile(outPath, data, 0644); err != nil {
+	if _, err := dumper.SaveFile(outdir, ".DS_Store", bytes.NewReader(data)); err != nil {
 		return fmt.Errorf("保存文件失败: %v", err)
 	}
 
 	// 解析文件内容
-	ds := &DSStore{}
-	reader := bytes.NewReader(data)
-
-	// 读取魔数
-	if err := binary.Read(reader, binary.BigEndian, &ds.Magic); err != nil {
-		return fmt.Errorf("读取魔数失败: %v", err)
+	if _, err := Parse(data); err != nil {
+		return fmt.Errorf("解析文件失败: %v", err)
 	}
 
-	// 验证魔数
-	if string(ds.Magic[:]) != "Bud1" {
-		return fmt.Errorf("无效的 .DS_Store 文件")
-	}
-
-	// 读取版本号
-	if err := binary.Read(reader, binary.BigEndian, &ds.Version); err != nil {
-		return fmt.Errorf("读取版本号失败: %v", err)
-	}
-
-	// TODO: 解析记录列表
-	// .DS_Store 文件格式比较复杂，需要实现完整的解析逻辑
-
 	return nil
 }
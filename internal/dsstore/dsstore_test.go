@@ -0,0 +1,125 @@
+package dsstore
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildDSStore 构造只包含一个叶子节点的 .DS_Store 文件，每个名称生成一条 lg1S 记录
+func buildDSStore(names ...string) []byte {
+	data := make([]byte, 0x1004+0x800)
+	binary.BigEndian.PutUint32(data[0:], 1)
+	copy(data[4:], "Bud1")
+	binary.BigEndian.PutUint32(data[8:], 0x1000)
+	binary.BigEndian.PutUint32(data[12:], 0x800)
+
+	// 数据块 0 为 DSDB，指向数据块 1 中的根节点
+	binary.BigEndian.PutUint32(data[0x104:], 1)
+
+	// 数据块 1 为叶子节点
+	node := data[0x204:0x204]
+	node = binary.BigEndian.AppendUint32(node, 0)
+	node = binary.BigEndian.AppendUint32(node, uint32(len(names)))
+	for _, name := range names {
+		units := utf16.Encode([]rune(name))
+		node = binary.BigEndian.AppendUint32(node, uint32(len(units)))
+		for _, u := range units {
+			node = binary.BigEndian.AppendUint16(node, u)
+		}
+		node = append(node, "lg1Scomp"...)
+		node = binary.BigEndian.AppendUint64(node, 5)
+	}
+
+	// 根数据块: 偏移表、目录表
+	root := data[0x1004:0x1004]
+	root = binary.BigEndian.AppendUint32(root, 2)
+	root = binary.BigEndian.AppendUint32(root, 0)
+	root = binary.BigEndian.AppendUint32(root, 0x100|5)
+	root = binary.BigEndian.AppendUint32(root, 0x200|11)
+	root = append(root, make([]byte, 254*4)...)
+	root = binary.BigEndian.AppendUint32(root, 1)
+	root = append(root, 4)
+	root = append(root, "DSDB"...)
+	binary.BigEndian.AppendUint32(root, 0)
+
+	return data
+}
+
+func TestParseNames(t *testing.T) {
+	names := []string{"a.txt", "b.php", "中文.txt"}
+	ds, err := Parse(buildDSStore(names...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, rec := range ds.Records {
+		got = append(got, rec.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Fatalf("records = %v, want %v", got, names)
+	}
+}
+
+func TestExecuteHostileDSStore(t *testing.T) {
+	store := buildDSStore(
+		"..",
+		"../../../evil1",
+		"..\\..\\..\\evil2",
+		"%2e%2e%2f%2e%2e%2fevil3",
+		"%252e%252e%252fevil4",
+		"..%5c..%5cevil5",
+		"evil6\x00.txt",
+		"/etc/evil7",
+		"C:..\\evil8",
+		"ok.txt",
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/.DS_Store":
+			w.Write(store)
+		case r.URL.Path == "/ok.txt":
+			w.Write([]byte("ok"))
+		case strings.Contains(r.URL.Path, "evil"):
+			w.Write([]byte("pwned"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	base := t.TempDir()
+	outdir := filepath.Join(base, "a", "b", "c", "out")
+
+	d := NewDsStoreDumper()
+	if err := d.Execute(srv.URL+"/", outdir, "", false, false, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []string
+	filepath.WalkDir(base, func(p string, e os.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outdir, p)
+		if err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("file written outside output directory: %s", p)
+			return nil
+		}
+		saved = append(saved, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(saved)
+
+	if got := strings.Join(saved, ","); got != ".DS_Store,ok.txt" {
+		t.Errorf("saved files = %s, want .DS_Store,ok.txt", got)
+	}
+}
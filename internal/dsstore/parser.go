@@ -0,0 +1,340 @@
+package dsstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"dumpall-go/internal/filter"
+)
+
+// .DS_Store 内部使用 Buddy Allocator 管理数据块，B-Tree 中存放以文件名为键的记录
+const (
+	headerSize   = 36
+	maxRecurse   = 64
+	macEpochDiff = 2082844800 // 1904-01-01 到 1970-01-01 的秒数
+)
+
+// 目录视图相关的结构ID，出现这些结构ID的记录通常是目录
+var dirStructIDs = map[string]bool{
+	"bwsp": true,
+	"icvp": true,
+	"lsvp": true,
+	"lsvP": true,
+	"lsvo": true,
+	"lsvt": true,
+	"icvo": true,
+	"vSrn": true,
+	"pict": true,
+	"fwi0": true,
+	"fwsw": true,
+	"fwvh": true,
+	"vstl": true,
+	"icgo": true,
+	"icsp": true,
+}
+
+// 记录文件大小的结构ID
+var sizeStructIDs = map[string]bool{
+	"lg1S": true,
+	"ph1S": true,
+	"logS": true,
+	"lgSz": true,
+	"phyS": true,
+	"phSz": true,
+}
+
+// 记录修改时间的结构ID
+var timeStructIDs = map[string]bool{
+	"moDD": true,
+	"modD": true,
+}
+
+// Parse 解析 .DS_Store 文件内容，返回按文件名去重后的记录
+func Parse(data []byte) (*DSStore, error) {
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("文件过短")
+	}
+
+	ds := &DSStore{}
+	copy(ds.Magic[:], data[4:8])
+	if string(ds.Magic[:]) != "Bud1" {
+		return nil, fmt.Errorf("无效的 .DS_Store 文件")
+	}
+	ds.Version = binary.BigEndian.Uint32(data[0:4])
+
+	p := &parser{data: data}
+
+	rootOffset := binary.BigEndian.Uint32(data[8:12])
+	rootSize := binary.BigEndian.Uint32(data[12:16])
+	root, err := p.slice(int(rootOffset)+4, int(rootSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取根数据块失败: %v", err)
+	}
+
+	if err := p.readAllocator(root); err != nil {
+		return nil, err
+	}
+
+	dsdb, ok := p.toc["DSDB"]
+	if !ok {
+		return nil, fmt.Errorf("未找到 DSDB 目录")
+	}
+
+	block, err := p.block(dsdb)
+	if err != nil {
+		return nil, fmt.Errorf("读取 DSDB 数据块失败: %v", err)
+	}
+	if len(block) < 20 {
+		return nil, fmt.Errorf("DSDB 数据块过短")
+	}
+
+	rootNode := binary.BigEndian.Uint32(block[0:4])
+	if err := p.readNode(rootNode, 0); err != nil {
+		return nil, err
+	}
+
+	ds.Records = p.records()
+	return ds, nil
+}
+
+type parser struct {
+	data    []byte
+	offsets []uint32
+	toc     map[string]uint32
+	byName  map[string]*Record
+	order   []string
+}
+
+// slice 返回 data 中的一段数据并进行边界检查
+func (p *parser) slice(offset, size int) ([]byte, error) {
+	if offset < 0 || size < 0 || offset+size > len(p.data) || offset+size < offset {
+		return nil, fmt.Errorf("偏移越界: %d+%d", offset, size)
+	}
+	return p.data[offset : offset+size], nil
+}
+
+// readAllocator 读取数据块偏移表和目录表
+func (p *parser) readAllocator(root []byte) error {
+	r := bytes.NewReader(root)
+
+	var count, unknown uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return fmt.Errorf("读取偏移数量失败: %v", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &unknown); err != nil {
+		return fmt.Errorf("读取偏移表失败: %v", err)
+	}
+	if int(count) > len(root)/4 {
+		return fmt.Errorf("偏移数量异常: %d", count)
+	}
+
+	p.offsets = make([]uint32, count)
+	if err := binary.Read(r, binary.BigEndian, p.offsets); err != nil {
+		return fmt.Errorf("读取偏移表失败: %v", err)
+	}
+
+	// 偏移表按 256 个为一组补齐
+	if pad := (256 - int(count)%256) % 256; pad > 0 {
+		if _, err := r.Seek(int64(pad*4), 1); err != nil {
+			return fmt.Errorf("读取偏移表失败: %v", err)
+		}
+	}
+
+	var tocCount uint32
+	if err := binary.Read(r, binary.BigEndian, &tocCount); err != nil {
+		return fmt.Errorf("读取目录数量失败: %v", err)
+	}
+
+	p.toc = make(map[string]uint32)
+	for i := uint32(0); i < tocCount; i++ {
+		nameLen, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("读取目录失败: %v", err)
+		}
+		name := make([]byte, nameLen)
+		if _, err := r.Read(name); err != nil {
+			return fmt.Errorf("读取目录失败: %v", err)
+		}
+		var value uint32
+		if err := binary.Read(r, binary.BigEndian, &value); err != nil {
+			return fmt.Errorf("读取目录失败: %v", err)
+		}
+		p.toc[string(name)] = value
+	}
+
+	return nil
+}
+
+// block 根据数据块编号返回对应的数据
+func (p *parser) block(id uint32) ([]byte, error) {
+	if int(id) >= len(p.offsets) {
+		return nil, fmt.Errorf("数据块编号越界: %d", id)
+	}
+	addr := p.offsets[id]
+	offset := int(addr &^ 0x1f)
+	size := 1 << (addr & 0x1f)
+	return p.slice(offset+4, size)
+}
+
+// readNode 递归读取 B-Tree 节点中的记录
+func (p *parser) readNode(id uint32, depth int) error {
+	if depth > maxRecurse {
+		return fmt.Errorf("B-Tree 层级过深")
+	}
+
+	block, err := p.block(id)
+	if err != nil {
+		return fmt.Errorf("读取节点失败: %v", err)
+	}
+	r := bytes.NewReader(block)
+
+	var next, count uint32
+	if err := binary.Read(r, binary.BigEndian, &next); err != nil {
+		return fmt.Errorf("读取节点失败: %v", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return fmt.Errorf("读取节点失败: %v", err)
+	}
+
+	for i := uint32(0); i < count; i++ {
+		if next != 0 {
+			var child uint32
+			if err := binary.Read(r, binary.BigEndian, &child); err != nil {
+				return fmt.Errorf("读取子节点失败: %v", err)
+			}
+			if err := p.readNode(child, depth+1); err != nil {
+				return err
+			}
+		}
+		if err := p.readRecord(r); err != nil {
+			return err
+		}
+	}
+
+	if next != 0 {
+		return p.readNode(next, depth+1)
+	}
+	return nil
+}
+
+// readRecord 读取一条记录并合并到对应文件名下
+func (p *parser) readRecord(r *bytes.Reader) error {
+	var nameLen uint32
+	if err := binary.Read(r, binary.BigEndian, &nameLen); err != nil {
+		return fmt.Errorf("读取记录失败: %v", err)
+	}
+	if int(nameLen)*2 > r.Len() {
+		return fmt.Errorf("记录名称长度异常: %d", nameLen)
+	}
+	units := make([]uint16, nameLen)
+	if err := binary.Read(r, binary.BigEndian, units); err != nil {
+		return fmt.Errorf("读取记录名称失败: %v", err)
+	}
+	name := string(utf16.Decode(units))
+
+	var structID, dataType [4]byte
+	if err := binary.Read(r, binary.BigEndian, &structID); err != nil {
+		return fmt.Errorf("读取记录类型失败: %v", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &dataType); err != nil {
+		return fmt.Errorf("读取记录类型失败: %v", err)
+	}
+
+	var value int64
+	switch string(dataType[:]) {
+	case "long", "shor", "type":
+		var v uint32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			return fmt.Errorf("读取记录数据失败: %v", err)
+		}
+		value = int64(v)
+	case "bool":
+		if _, err := r.ReadByte(); err != nil {
+			return fmt.Errorf("读取记录数据失败: %v", err)
+		}
+	case "comp", "dutc":
+		var v uint64
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			return fmt.Errorf("读取记录数据失败: %v", err)
+		}
+		value = int64(v)
+	case "blob":
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return fmt.Errorf("读取记录数据失败: %v", err)
+		}
+		if _, err := r.Seek(int64(n), 1); err != nil || int(n) > len(p.data) {
+			return fmt.Errorf("读取记录数据失败: blob 长度异常")
+		}
+	case "ustr":
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return fmt.Errorf("读取记录数据失败: %v", err)
+		}
+		if _, err := r.Seek(int64(n)*2, 1); err != nil || int(n) > len(p.data) {
+			return fmt.Errorf("读取记录数据失败: ustr 长度异常")
+		}
+	default:
+		return fmt.Errorf("未知的记录数据类型: %q", dataType[:])
+	}
+
+	p.merge(name, string(structID[:]), string(dataType[:]), value)
+	return nil
+}
+
+// merge 将同一文件名的多条记录合并
+func (p *parser) merge(name, structID, dataType string, value int64) {
+	if p.byName == nil {
+		p.byName = make(map[string]*Record)
+	}
+	rec, ok := p.byName[name]
+	if !ok {
+		rec = &Record{Name: name, Type: "file"}
+		p.byName[name] = rec
+		p.order = append(p.order, name)
+	}
+
+	switch {
+	case dirStructIDs[structID]:
+		rec.Type = "dir"
+	case sizeStructIDs[structID] && rec.Size == 0:
+		rec.Size = value
+	case timeStructIDs[structID] && dataType == "dutc":
+		rec.Modified = value/65536 - macEpochDiff
+	}
+}
+
+// records 返回按文件名排序的记录列表
+func (p *parser) records() []Record {
+	sort.Strings(p.order)
+	records := make([]Record, 0, len(p.order))
+	for _, name := range p.order {
+		if name == "." || name == "" {
+			continue
+		}
+		records = append(records, *p.byName[name])
+	}
+	return records
+}
+
+// ModTime 返回记录的修改时间，没有时间信息时返回零值
+func (r Record) ModTime() time.Time {
+	if r.Modified == 0 {
+		return time.Time{}
+	}
+	return time.Unix(r.Modified, 0)
+}
+
+// filterEntry 生成用于过滤的文件信息，.DS_Store 中没有记录大小时视为未知
+func (r Record) filterEntry(name string) filter.Entry {
+	size := r.Size
+	if size == 0 {
+		size = -1
+	}
+	return filter.Entry{Path: strings.TrimPrefix(name, "/"), Size: size, ModTime: r.ModTime()}
+}
@@ -0,0 +1,257 @@
+package dumper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/soft404"
+)
+
+// DefaultFetchLimit 下载单个文件时默认读取的最大长度
+const DefaultFetchLimit = 64 << 20
+
+// 流式下载时先读入内存检查的长度，更长的响应不会是错误页面
+const sniffSize = 1 << 20
+
+// EscapePath 对相对路径逐段进行URL编码
+func EscapePath(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Fetch 下载文件内容，状态码不是200、超过 limit 或内容被 baseline 判定为错误页面时返回 false
+// 不存在的文件也返回200的主机需要先用 soft404.Calibrate 得到 baseline，nil 的 baseline 只检查 WAF 拦截页面和文件类型
+// limit 不大于0时使用 DefaultFetchLimit，成功时不报告进度，调用方检查内容后用 SaveData 保存并报告
+func Fetch(client *http.Client, baseline *soft404.Baseline, fileURL string, limit int64, progressCb ProgressCallback) ([]byte, bool) {
+	if limit <= 0 {
+		limit = DefaultFetchLimit
+	}
+
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "下载失败")
+		}
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if progressCb != nil {
+			progressCb(fileURL, resp.StatusCode, "")
+		}
+		return nil, false
+	}
+
+	data, ok := ReadLimited(resp.Body, limit)
+	if !ok {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "下载失败或文件过大")
+		}
+		return nil, false
+	}
+
+	if !baseline.Accept(fileURL, data) {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "内容无效")
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// Download 下载文件并保存到输出目录下的 name，返回下载的内容
+// 响应不满足过滤条件或内容被 baseline 判定为错误页面时不保存，保存成功后才报告本地路径
+func Download(client *http.Client, baseline *soft404.Baseline, f *filter.Filter, fileURL string, outdir string, name string, limit int64, progressCb ProgressCallback) ([]byte, bool) {
+	return DownloadCheck(client, baseline, f, fileURL, outdir, name, limit, nil, progressCb)
+}
+
+// DownloadCheck 与 Download 相同，check 不为 nil 时还用它检查下载的内容，例如敏感文件的签名，检查不通过时不保存
+func DownloadCheck(client *http.Client, baseline *soft404.Baseline, f *filter.Filter, fileURL string, outdir string, name string, limit int64, check func(data []byte) bool, progressCb ProgressCallback) ([]byte, bool) {
+	if limit <= 0 {
+		limit = DefaultFetchLimit
+	}
+
+	// 先检查路径，越出输出目录时不发送请求
+	if _, err := SafePath(outdir, name); err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "非法路径")
+		}
+		return nil, false
+	}
+
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "下载失败")
+		}
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if progressCb != nil {
+			progressCb(fileURL, resp.StatusCode, "")
+		}
+		return nil, false
+	}
+	if !f.MatchResponse(resp) {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "已过滤")
+		}
+		return nil, false
+	}
+
+	// 被重定向到目录的请求不是文件
+	if strings.HasSuffix(resp.Request.URL.Path, "/") && !strings.HasSuffix(fileURL, "/") {
+		return nil, false
+	}
+
+	data, ok := ReadLimited(resp.Body, limit)
+	if !ok {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "下载失败或文件过大")
+		}
+		return nil, false
+	}
+
+	// 没有 Content-Length 时按实际长度再检查一次大小
+	if !f.MatchSize(int64(len(data))) {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "已过滤")
+		}
+		return nil, false
+	}
+	if !baseline.Accept(fileURL, data) || check != nil && !check(data) {
+		return nil, false
+	}
+
+	if _, ok := SaveData(outdir, name, data, fileURL, progressCb); !ok {
+		return nil, false
+	}
+	return data, true
+}
+
+// DownloadStream 下载文件并直接写入输出目录，不限制文件大小，用于目录列表等来源的普通文件
+// 不超过 sniffSize 的响应先经过 baseline 检查，其他检查与 Download 相同
+func DownloadStream(client *http.Client, baseline *soft404.Baseline, f *filter.Filter, fileURL string, outdir string, name string, progressCb ProgressCallback) bool {
+	return DownloadStreamCheck(client, baseline, f, fileURL, outdir, name, nil, progressCb)
+}
+
+// DownloadStreamCheck 与 DownloadStream 相同，check 不为 nil 时还用它检查响应开头的 sniffSize 字节，
+// 用于按文件头排除自定义404页面，检查不通过时不保存
+func DownloadStreamCheck(client *http.Client, baseline *soft404.Baseline, f *filter.Filter, fileURL string, outdir string, name string, check func(head []byte) bool, progressCb ProgressCallback) bool {
+	localPath, err := SafePath(outdir, name)
+	if err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "非法路径")
+		}
+		return false
+	}
+
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "下载失败")
+		}
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if progressCb != nil {
+			progressCb(fileURL, resp.StatusCode, "")
+		}
+		return false
+	}
+	if !f.MatchResponse(resp) {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "已过滤")
+		}
+		return false
+	}
+
+	if strings.HasSuffix(resp.Request.URL.Path, "/") && !strings.HasSuffix(fileURL, "/") {
+		return false
+	}
+
+	head, err := io.ReadAll(io.LimitReader(resp.Body, sniffSize+1))
+	if err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "下载失败")
+		}
+		return false
+	}
+	// 已读入完整内容时检查大小范围，否则只要超过 MaxSize 就不再下载
+	tooBig := f != nil && f.MaxSize > 0 && int64(len(head)) > f.MaxSize
+	if tooBig || len(head) <= sniffSize && !f.MatchSize(int64(len(head))) {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "已过滤")
+		}
+		return false
+	}
+	if len(head) <= sniffSize && !baseline.Accept(fileURL, head) {
+		return false
+	}
+	if check != nil && !check(head[:min(len(head), sniffSize)]) {
+		return false
+	}
+
+	// 没有 Content-Length 时最多读取 MaxSize+1 字节，超过后不再继续下载
+	body := io.MultiReader(bytes.NewReader(head), resp.Body)
+	if f != nil && f.MaxSize > 0 {
+		body = io.LimitReader(body, f.MaxSize+1)
+	}
+	if _, err := SaveFile(outdir, name, body); err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "写入失败")
+		}
+		return false
+	}
+
+	// 写完后才知道没有 Content-Length 的文件的大小
+	if fi, err := os.Stat(localPath); err == nil && !f.MatchSize(fi.Size()) {
+		os.Remove(localPath)
+		if progressCb != nil {
+			progressCb(fileURL, 0, "已过滤")
+		}
+		return false
+	}
+
+	if progressCb != nil {
+		progressCb(fileURL, resp.StatusCode, localPath)
+	}
+	return true
+}
+
+// ReadLimited 读取全部内容，读取失败或超过 limit 字节时返回 false
+func ReadLimited(r io.Reader, limit int64) ([]byte, bool) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil || int64(len(data)) > limit {
+		return nil, false
+	}
+	return data, true
+}
+
+// SaveData 将已下载的内容写入输出目录，并通过 progressCb 报告本地路径或写入失败
+func SaveData(outdir string, name string, data []byte, fileURL string, progressCb ProgressCallback) (string, bool) {
+	localPath, err := SaveFile(outdir, name, bytes.NewReader(data))
+	if err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "写入失败")
+		}
+		return "", false
+	}
+	if progressCb != nil {
+		progressCb(fileURL, http.StatusOK, localPath)
+	}
+	return localPath, true
+}
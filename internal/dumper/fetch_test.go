@@ -0,0 +1,321 @@
+package dumper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/soft404"
+)
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a/b.txt", "a/b.txt"},
+		{"a b/c#d?.txt", "a%20b/c%23d%3F.txt"},
+		{"中文/文件.txt", "%E4%B8%AD%E6%96%87/%E6%96%87%E4%BB%B6.txt"},
+		{"100%.txt", "100%25.txt"},
+	}
+	for _, tt := range tests {
+		if got := EscapePath(tt.name); got != tt.want {
+			t.Errorf("EscapePath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fetchServer 返回固定内容的测试服务器
+func fetchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.txt":
+			w.Write([]byte("hello"))
+		case "/big.txt":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/blocked.txt":
+			w.Write([]byte("<html><body>Access Denied by Web Application Firewall</body></html>"))
+		case "/dir":
+			http.Redirect(w, r, "/dir/", http.StatusMovedPermanently)
+		case "/dir/":
+			w.Write([]byte("index"))
+		case "/chunked.bin":
+			// 分块发送，响应中没有 Content-Length
+			for i := 0; i < 3; i++ {
+				w.Write([]byte(strings.Repeat("y", sniffSize)))
+				w.(http.Flusher).Flush()
+			}
+		case "/old.txt":
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2001 00:00:00 GMT")
+			w.Write([]byte("old"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// recorder 记录进度回调
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) cb(url string, status int, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprintf("%d %s", status, msg))
+}
+
+func TestFetch(t *testing.T) {
+	srv := fetchServer(t)
+
+	tests := []struct {
+		path  string
+		limit int64
+		ok    bool
+	}{
+		{"/ok.txt", 0, true},
+		{"/big.txt", 100, true},
+		{"/big.txt", 99, false},
+		{"/missing.txt", 0, false},
+		{"/blocked.txt", 0, false},
+	}
+	for _, tt := range tests {
+		var rec recorder
+		data, ok := Fetch(srv.Client(), nil, srv.URL+tt.path, tt.limit, rec.cb)
+		if ok != tt.ok {
+			t.Errorf("Fetch(%s, %d) ok = %v, want %v", tt.path, tt.limit, ok, tt.ok)
+		}
+		if !ok && data != nil {
+			t.Errorf("Fetch(%s, %d) 失败时返回了内容", tt.path, tt.limit)
+		}
+		// 成功时由保存文件的调用方报告，失败时报告一次原因
+		want := 1
+		if ok {
+			want = 0
+		}
+		if len(rec.calls) != want {
+			t.Errorf("Fetch(%s, %d) 回调 = %q", tt.path, tt.limit, rec.calls)
+		}
+	}
+}
+
+func TestSaveData(t *testing.T) {
+	outdir := t.TempDir()
+	var rec recorder
+	if _, ok := SaveData(outdir, "a/b.txt", []byte("x"), "http://x/a/b.txt", rec.cb); !ok {
+		t.Fatal("保存失败")
+	}
+	if _, ok := SaveData(outdir, "../c.txt", []byte("x"), "http://x/c.txt", rec.cb); ok {
+		t.Error("非法路径不应保存")
+	}
+	want := "200 " + filepath.Join(outdir, "a", "b.txt") + ",0 写入失败"
+	if got := strings.Join(rec.calls, ","); got != want {
+		t.Errorf("回调 = %q, want %q", got, want)
+	}
+}
+
+func TestFetchBaseline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><title>Not Found</title>" + r.URL.Path + "</html>"))
+	}))
+	defer srv.Close()
+
+	baseline := soft404.Calibrate(srv.Client(), srv.URL+"/")
+	if baseline == nil {
+		t.Fatal("校准失败")
+	}
+	if _, ok := Fetch(srv.Client(), baseline, srv.URL+"/config.php", 0, nil); ok {
+		t.Error("错误页面不应被当作文件")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	srv := fetchServer(t)
+	f := &filter.Filter{MaxSize: 50}
+	f.After, _ = filter.ParseTimeFlag("2020-01-01")
+
+	tests := []struct {
+		path  string
+		ok    bool
+		calls string // 进度回调，200 时附带本地路径，被过滤时为 0
+	}{
+		{"/ok.txt", true, "200 ok.txt"},
+		{"/big.txt", false, "0 已过滤"},
+		{"/old.txt", false, "0 已过滤"},
+		{"/missing.txt", false, "404 "},
+		{"/dir", false, ""},
+	}
+	for _, tt := range tests {
+		outdir := t.TempDir()
+		var rec recorder
+		name := strings.TrimPrefix(tt.path, "/")
+		_, ok := Download(srv.Client(), nil, f, srv.URL+tt.path, outdir, name, 0, rec.cb)
+		if ok != tt.ok {
+			t.Errorf("Download(%s) ok = %v, want %v", tt.path, ok, tt.ok)
+		}
+
+		calls := strings.ReplaceAll(strings.Join(rec.calls, ","), outdir+string(filepath.Separator), "")
+		if calls != tt.calls {
+			t.Errorf("Download(%s) 回调 = %q, want %q", tt.path, calls, tt.calls)
+		}
+
+		_, err := os.Stat(filepath.Join(outdir, name))
+		if saved := err == nil; saved != tt.ok {
+			t.Errorf("Download(%s) saved = %v, want %v", tt.path, saved, tt.ok)
+		}
+	}
+
+	// 越出输出目录的路径不发送请求
+	var rec recorder
+	if _, ok := Download(srv.Client(), nil, nil, srv.URL+"/ok.txt", t.TempDir(), "../x", 0, rec.cb); ok {
+		t.Error("非法路径不应下载")
+	}
+	if got := strings.Join(rec.calls, ","); got != "0 非法路径" {
+		t.Errorf("非法路径回调 = %q", got)
+	}
+}
+
+func TestDownloadCheck(t *testing.T) {
+	srv := fetchServer(t)
+	for _, tt := range []struct {
+		check string
+		ok    bool
+	}{
+		{"hello", true},
+		{"APP_KEY", false},
+	} {
+		outdir := t.TempDir()
+		var rec recorder
+		check := func(data []byte) bool { return strings.Contains(string(data), tt.check) }
+		data, ok := DownloadCheck(srv.Client(), nil, nil, srv.URL+"/ok.txt", outdir, "ok.txt", 0, check, rec.cb)
+		if ok != tt.ok || ok && string(data) != "hello" {
+			t.Errorf("DownloadCheck(%s) = %q, %v, want %v", tt.check, data, ok, tt.ok)
+		}
+		if _, err := os.Stat(filepath.Join(outdir, "ok.txt")); (err == nil) != tt.ok {
+			t.Errorf("DownloadCheck(%s) saved = %v, want %v", tt.check, err == nil, tt.ok)
+		}
+		// 保存成功时只报告一次本地路径，检查不通过时不报告
+		want := ""
+		if tt.ok {
+			want = "200 ok.txt"
+		}
+		if calls := strings.ReplaceAll(strings.Join(rec.calls, ","), outdir+string(filepath.Separator), ""); calls != want {
+			t.Errorf("DownloadCheck(%s) 回调 = %q, want %q", tt.check, calls, want)
+		}
+	}
+}
+
+func TestDownloadStream(t *testing.T) {
+	srv := fetchServer(t)
+
+	tests := []struct {
+		path    string
+		maxSize int64
+		ok      bool
+		calls   string
+	}{
+		{"/ok.txt", 0, true, "200 ok.txt"},
+		{"/big.txt", 50, false, "0 已过滤"},
+		{"/blocked.txt", 0, false, ""},
+		{"/dir", 0, false, ""},
+		{"/chunked.bin", 0, true, "200 chunked.bin"},
+		{"/chunked.bin", 2 * sniffSize, false, "0 已过滤"},
+		{"/chunked.bin", sniffSize / 2, false, "0 已过滤"},
+	}
+	for _, tt := range tests {
+		outdir := t.TempDir()
+		var rec recorder
+		name := strings.TrimPrefix(tt.path, "/")
+		ok := DownloadStream(srv.Client(), nil, &filter.Filter{MaxSize: tt.maxSize}, srv.URL+tt.path, outdir, name, rec.cb)
+		if ok != tt.ok {
+			t.Errorf("DownloadStream(%s, %d) ok = %v, want %v", tt.path, tt.maxSize, ok, tt.ok)
+		}
+
+		calls := strings.ReplaceAll(strings.Join(rec.calls, ","), outdir+string(filepath.Separator), "")
+		if calls != tt.calls {
+			t.Errorf("DownloadStream(%s, %d) 回调 = %q, want %q", tt.path, tt.maxSize, calls, tt.calls)
+		}
+
+		fi, err := os.Stat(filepath.Join(outdir, name))
+		if saved := err == nil; saved != tt.ok {
+			t.Errorf("DownloadStream(%s, %d) saved = %v, want %v", tt.path, tt.maxSize, saved, tt.ok)
+		}
+		if tt.path == "/chunked.bin" && err == nil && fi.Size() != 3*sniffSize {
+			t.Errorf("DownloadStream(%s) size = %d, want %d", tt.path, fi.Size(), 3*sniffSize)
+		}
+	}
+}
+
+func TestDownloadStreamCheck(t *testing.T) {
+	srv := fetchServer(t)
+	prefix := func(p string) func([]byte) bool {
+		return func(head []byte) bool { return strings.HasPrefix(string(head), p) }
+	}
+
+	tests := []struct {
+		path  string
+		check func([]byte) bool
+		ok    bool
+	}{
+		{"/ok.txt", prefix("hello"), true},
+		{"/ok.txt", prefix("PK"), false},
+		// 较长的响应只检查开头
+		{"/chunked.bin", prefix("yyyy"), true},
+		{"/chunked.bin", func(head []byte) bool { return len(head) <= sniffSize }, true},
+		{"/chunked.bin", prefix("PK"), false},
+	}
+	for _, tt := range tests {
+		outdir := t.TempDir()
+		var rec recorder
+		name := strings.TrimPrefix(tt.path, "/")
+		if ok := DownloadStreamCheck(srv.Client(), nil, nil, srv.URL+tt.path, outdir, name, tt.check, rec.cb); ok != tt.ok {
+			t.Errorf("DownloadStreamCheck(%s) ok = %v, want %v", tt.path, ok, tt.ok)
+		}
+		if _, err := os.Stat(filepath.Join(outdir, name)); (err == nil) != tt.ok {
+			t.Errorf("DownloadStreamCheck(%s) saved = %v, want %v", tt.path, err == nil, tt.ok)
+		}
+		// 检查不通过时不报告
+		if !tt.ok && len(rec.calls) != 0 {
+			t.Errorf("DownloadStreamCheck(%s) 回调 = %q", tt.path, rec.calls)
+		}
+	}
+}
+
+// 没有 Content-Length 的响应超过 MaxSize 后停止读取，不会下载整个响应
+func TestDownloadStreamAbort(t *testing.T) {
+	sent := make(chan int, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 0
+		chunk := []byte(strings.Repeat("z", sniffSize))
+		for i := 0; i < 64; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				break
+			}
+			w.(http.Flusher).Flush()
+			n += len(chunk)
+		}
+		sent <- n
+	}))
+	defer srv.Close()
+
+	outdir := t.TempDir()
+	if DownloadStream(srv.Client(), nil, &filter.Filter{MaxSize: 2 * sniffSize}, srv.URL+"/huge.bin", outdir, "huge.bin", nil) {
+		t.Fatal("超过 MaxSize 的文件不应保存")
+	}
+	if n := <-sent; n >= 64*sniffSize {
+		t.Errorf("服务器发送了全部 %d 字节，下载没有提前结束", n)
+	}
+	if _, err := os.Stat(filepath.Join(outdir, "huge.bin")); err == nil {
+		t.Error("超过 MaxSize 的文件应被删除")
+	}
+}
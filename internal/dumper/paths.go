@@ -0,0 +1,60 @@
+package dumper
+
+import "sync"
+
+// PathSet 记录各个 dumper 从泄露文件中得知的文件路径，供备份文件探测等后续步骤使用
+// 路径均相对于目标URL，nil 的 PathSet 忽略所有操作
+type PathSet struct {
+	mu    sync.Mutex
+	seen  map[string]bool
+	paths []string
+}
+
+// NewPathSet 创建 PathSet 实例
+func NewPathSet() *PathSet {
+	return &PathSet{seen: make(map[string]bool)}
+}
+
+// Add 记录一个相对路径，非法路径和重复路径会被忽略
+func (s *PathSet) Add(name string) {
+	if s == nil {
+		return
+	}
+	clean, err := CleanPath(name)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.seen[clean] {
+		s.seen[clean] = true
+		s.paths = append(s.paths, clean)
+	}
+}
+
+// Contains 判断路径是否已记录
+func (s *PathSet) Contains(name string) bool {
+	if s == nil {
+		return false
+	}
+	clean, err := CleanPath(name)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen[clean]
+}
+
+// List 按记录顺序返回所有路径
+func (s *PathSet) List() []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
@@ -0,0 +1,39 @@
+package dumper
+
+import "sync"
+
+// DefaultDownloadWorkers 每个目标默认同时下载的文件数量
+const DefaultDownloadWorkers = 8
+
+// Pool 限制同一目标同时进行的下载数量
+// 提交的任务中不能再向同一个 Pool 提交任务，目录递归应在调用方进行，只把文件下载交给 Pool
+type Pool struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// NewPool 创建 Pool 实例，workers 小于 1 时按 1 处理
+func NewPool(workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Pool{sem: make(chan struct{}, workers)}
+}
+
+// Go 等待空闲名额后在新的 goroutine 中执行 fn
+func (p *Pool) Go(fn func()) {
+	p.sem <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.sem
+			p.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// Wait 等待所有已提交的任务完成
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
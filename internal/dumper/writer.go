@@ -0,0 +1,105 @@
+package dumper
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 检查路径时最多解码的次数，用于处理多重URL编码
+const maxUnescape = 4
+
+// SafePath 将远程提供的相对路径转换为输出目录下的本地路径
+// 任何越出输出目录的路径都会被拒绝
+func SafePath(outdir string, name string) (string, error) {
+	clean, err := CleanPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	localPath := filepath.Join(outdir, filepath.FromSlash(clean))
+
+	// 再次确认拼接后的路径仍位于输出目录内
+	rel, err := filepath.Rel(outdir, localPath)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("非法路径: %q", name)
+	}
+
+	return localPath, nil
+}
+
+// CleanPath 规范化远程提供的相对路径，返回以 / 分隔的安全路径
+// 路径的每一层URL解码结果都会检查，防止通过编码绕过目录穿越检测
+func CleanPath(name string) (string, error) {
+	decoded := name
+	for i := 0; i <= maxUnescape; i++ {
+		if hasTraversal(decoded) {
+			return "", fmt.Errorf("非法路径: %q", name)
+		}
+		next, err := url.PathUnescape(decoded)
+		if err != nil || next == decoded {
+			break
+		}
+		decoded = next
+	}
+
+	clean := path.Clean(trimRoot(strings.ReplaceAll(name, "\\", "/")))
+	if clean == "." || clean == "" || !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", fmt.Errorf("非法路径: %q", name)
+	}
+
+	return clean, nil
+}
+
+// hasTraversal 判断路径是否包含 NUL 字符或 .. 路径段
+func hasTraversal(name string) bool {
+	if strings.ContainsRune(name, 0) {
+		return true
+	}
+	for _, part := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// trimRoot 去掉路径开头的盘符和分隔符
+func trimRoot(name string) string {
+	if len(name) >= 2 && name[1] == ':' && isLetter(name[0]) {
+		name = name[2:]
+	}
+	return strings.TrimLeft(name, "/")
+}
+
+// SaveFile 将内容写入输出目录下的相对路径，自动创建父目录，返回写入的本地路径
+func SaveFile(outdir string, name string, r io.Reader) (string, error) {
+	localPath, err := SafePath(outdir, name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return localPath, fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	f, err := os.Create(localPath)
+	if err != nil {
+		return localPath, fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return localPath, fmt.Errorf("写入文件失败: %v", err)
+	}
+
+	return localPath, nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
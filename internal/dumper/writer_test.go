@@ -0,0 +1,98 @@
+package dumper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSafePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // 空字符串表示应被拒绝
+	}{
+		{"a/b.txt", "a/b.txt"},
+		{"/a/./b.txt", "a/b.txt"},
+		{"a\\b.txt", "a/b.txt"},
+		{"/etc/passwd", "etc/passwd"},
+		{"//server/share/x", "server/share/x"},
+		{"C:\\Windows\\win.ini", "Windows/win.ini"},
+		{"C:/x", "x"},
+		{"a%20b.txt", "a%20b.txt"},
+
+		{"", ""},
+		{"/", ""},
+		{".", ""},
+		{"..", ""},
+		{"../etc/passwd", ""},
+		{"a/../../x", ""},
+		{"a/..", ""},
+		{"..\\..\\x", ""},
+		{"a\\..\\..\\x", ""},
+		{"%2e%2e/x", ""},
+		{"%2E%2E%2Fx", ""},
+		{"..%2fx", ""},
+		{"..%5cx", ""},
+		{"%252e%252e%252fx", ""},
+		{"%25252e%25252e/x", ""},
+		{"a\x00b", ""},
+		{"a%00b", ""},
+		{"a%2500b", ""},
+		{"C:..\\x", ""},
+	}
+
+	outdir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafePath(outdir, tt.name)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("SafePath(%q) = %q, want error", tt.name, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SafePath(%q): %v", tt.name, err)
+			}
+			if want := filepath.Join(outdir, filepath.FromSlash(tt.want)); got != want {
+				t.Fatalf("SafePath(%q) = %q, want %q", tt.name, got, want)
+			}
+		})
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	base := t.TempDir()
+	outdir := filepath.Join(base, "a", "b", "out")
+
+	for _, name := range []string{"../../../evil", "..%2f..%2f..%2fevil", "..\\..\\..\\evil", "evil\x00.txt"} {
+		if _, err := SaveFile(outdir, name, strings.NewReader("pwned")); err == nil {
+			t.Errorf("SaveFile(%q) succeeded", name)
+		}
+	}
+
+	localPath, err := SaveFile(outdir, "/sub/ok.txt", strings.NewReader("ok"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(outdir, "sub", "ok.txt"); localPath != want {
+		t.Fatalf("SaveFile returned %q, want %q", localPath, want)
+	}
+
+	assertContained(t, base, outdir)
+}
+
+// assertContained 检查 base 下的所有文件都位于 outdir 中
+func assertContained(t *testing.T, base string, outdir string) {
+	t.Helper()
+	filepath.WalkDir(base, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if rel, err := filepath.Rel(outdir, p); err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("file written outside output directory: %s", p)
+		}
+		return nil
+	})
+}
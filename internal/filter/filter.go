@@ -0,0 +1,247 @@
+// Package filter 实现各个 dumper 共用的下载过滤规则
+package filter
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter 定义下载文件的过滤条件，nil 表示不过滤
+type Filter struct {
+	Include    []string  // 包含的通配符，为空时包含所有文件
+	Exclude    []string  // 排除的通配符
+	Extensions []string  // 允许的扩展名，为空时不限制
+	MinSize    int64     // 最小文件大小，0 表示不限制
+	MaxSize    int64     // 最大文件大小，0 表示不限制
+	After      time.Time // 修改时间下限
+	Before     time.Time // 修改时间上限
+}
+
+// Entry 表示一个待过滤的文件
+type Entry struct {
+	Path    string    // 相对路径，使用 / 分隔
+	Size    int64     // 文件大小，未知时为 -1
+	ModTime time.Time // 修改时间，未知时为零值
+}
+
+// IsEmpty 判断是否没有设置任何过滤条件
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.Extensions) == 0 &&
+		f.MinSize == 0 && f.MaxSize == 0 && f.After.IsZero() && f.Before.IsZero())
+}
+
+// Match 判断文件是否满足所有过滤条件，大小和时间未知时视为满足
+func (f *Filter) Match(e Entry) bool {
+	return f.MatchPath(e.Path) && f.MatchSize(e.Size) && f.MatchTime(e.ModTime)
+}
+
+// MatchPath 根据通配符和扩展名判断路径是否满足条件
+func (f *Filter) MatchPath(p string) bool {
+	if f == nil {
+		return true
+	}
+	p = strings.TrimPrefix(p, "/")
+
+	if len(f.Extensions) > 0 {
+		// 按后缀比较，tar.gz 这样包含多个点的扩展名也能匹配
+		name := strings.ToLower(path.Base(p))
+		matched := false
+		for _, e := range f.Extensions {
+			ext := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(e)), ".")
+			if ext != "" && strings.HasSuffix(name, "."+ext) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(f.Include) > 0 && !matchAny(f.Include, p) {
+		return false
+	}
+
+	return !matchAny(f.Exclude, p)
+}
+
+// MatchSize 判断文件大小是否满足条件，size 小于 0 表示未知
+func (f *Filter) MatchSize(size int64) bool {
+	if f == nil || size < 0 {
+		return true
+	}
+	if f.MinSize > 0 && size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return false
+	}
+	return true
+}
+
+// MatchTime 判断修改时间是否满足条件，零值表示未知
+func (f *Filter) MatchTime(t time.Time) bool {
+	if f == nil || t.IsZero() {
+		return true
+	}
+	if !f.After.IsZero() && t.Before(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && t.After(f.Before) {
+		return false
+	}
+	return true
+}
+
+// MatchResponse 根据响应头中的 Content-Length 和 Last-Modified 判断是否满足条件
+// 用于在目录列表等来源没有提供大小和时间时，在写入文件前再次过滤
+func (f *Filter) MatchResponse(resp *http.Response) bool {
+	if f == nil {
+		return true
+	}
+	if !f.MatchSize(resp.ContentLength) {
+		return false
+	}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		if t, err := http.ParseTime(lm); err == nil && !f.MatchTime(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny 判断路径是否匹配任意一个通配符
+// 不含 / 的通配符匹配文件名，含 / 的通配符匹配完整路径
+func matchAny(patterns []string, p string) bool {
+	base := path.Base(p)
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = p
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+		// 以 / 结尾的通配符匹配目录下的所有文件
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(p, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSize 将大小文本转换为字节数，无法识别时返回 -1
+// 支持 "1234"、"1,234 bytes"、"1.2K"、"4.0 MB"、"1.2 KiB" 等写法
+func ParseSize(s string) int64 {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if s == "" || s == "-" {
+		return -1
+	}
+
+	// 拆分数字和单位
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	num, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return -1
+	}
+
+	unit := strings.ToLower(strings.TrimSpace(s[i:]))
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "bytes"), "byte")
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "ib"), "b")
+
+	multiplier := float64(1)
+	switch strings.TrimSpace(unit) {
+	case "":
+	case "k":
+		multiplier = 1 << 10
+	case "m":
+		multiplier = 1 << 20
+	case "g":
+		multiplier = 1 << 30
+	case "t":
+		multiplier = 1 << 40
+	default:
+		return -1
+	}
+
+	return int64(num * multiplier)
+}
+
+// 目录列表中常见的时间格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.RFC1123,
+	time.RFC1123Z,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02-Jan-2006 15:04:05",
+	"02-Jan-2006 15:04",
+	"2006-Jan-02 15:04:05",
+	"1/2/2006 3:04 PM",
+	"Jan 2, 2006, 3:04:05 PM",
+	"Jan 2, 2006 3:04:05 PM",
+	"Mon Jan 2 15:04:05 MST 2006",
+}
+
+// ParseTime 解析目录列表和命令行参数中的时间，无法识别时返回零值
+func ParseTime(s string) time.Time {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" || s == "-" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ParseSizeFlag 解析命令行中的大小参数，空字符串表示不限制
+func ParseSizeFlag(s string) (int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	size := ParseSize(s)
+	if size < 0 {
+		return 0, fmt.Errorf("无效的大小: %s", s)
+	}
+	return size, nil
+}
+
+// ParseTimeFlag 解析命令行中的时间参数，空字符串表示不限制
+func ParseTimeFlag(s string) (time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return time.Time{}, nil
+	}
+	t := ParseTime(s)
+	if t.IsZero() {
+		return t, fmt.Errorf("无效的时间: %s", s)
+	}
+	return t, nil
+}
+
+// ParseBeforeFlag 解析 --before 参数，只有日期时表示当天结束，当天修改的文件也满足条件
+func ParseBeforeFlag(s string) (time.Time, error) {
+	t, err := ParseTimeFlag(s)
+	if err != nil || t.IsZero() {
+		return t, err
+	}
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err == nil {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
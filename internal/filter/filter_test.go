@@ -0,0 +1,77 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBeforeFlag(t *testing.T) {
+	tests := []struct {
+		flag string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2024-01-02", time.Date(2024, 1, 2, 23, 59, 59, 999999999, time.UTC)},
+		{" 2024-01-02 ", time.Date(2024, 1, 2, 23, 59, 59, 999999999, time.UTC)},
+		{"2024-01-02 12:00", time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)},
+		{"2024-01-02T00:00:00Z", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseBeforeFlag(tt.flag)
+		if err != nil {
+			t.Errorf("ParseBeforeFlag(%q) error: %v", tt.flag, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseBeforeFlag(%q) = %v, want %v", tt.flag, got, tt.want)
+		}
+	}
+
+	if _, err := ParseBeforeFlag("yesterday"); err == nil {
+		t.Error("无效的时间应返回错误")
+	}
+}
+
+// --before 只写日期时，当天修改的文件也满足条件
+func TestMatchTimeBeforeDate(t *testing.T) {
+	before, _ := ParseBeforeFlag("2024-01-02")
+	f := &Filter{Before: before}
+
+	tests := []struct {
+		mtime time.Time
+		want  bool
+	}{
+		{time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 2, 18, 30, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := f.MatchTime(tt.mtime); got != tt.want {
+			t.Errorf("MatchTime(%v) = %v, want %v", tt.mtime, got, tt.want)
+		}
+	}
+}
+
+func TestMatchPathExtensions(t *testing.T) {
+	f := &Filter{Extensions: []string{"tar.gz", ".SQL", "zip"}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"backup/site.tar.gz", true},
+		{"SITE.TAR.GZ", true},
+		{"db.sql", true},
+		{"/a/b.zip", true},
+		{"site.gz", false},
+		{"site.tar", false},
+		{"mysql", false},
+		{"a.zip/readme.txt", false},
+	}
+	for _, tt := range tests {
+		if got := f.MatchPath(tt.path); got != tt.want {
+			t.Errorf("MatchPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
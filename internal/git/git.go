@@ -2,19 +2,24 @@ package git
 
 import (
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
 )
 
 // GitDumper 实现 .git 源代码下载
 type GitDumper struct {
 	dumper.BaseDumper
+	// Filter 还原工作区文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 index 中的文件路径
+	Paths *dumper.PathSet
 }
 
 // NewGitDumper 创建 GitDumper 实例
@@ -58,20 +63,10 @@ func (d *GitDumper) Validate(url string) error {
 
 // Dump 下载 Git 源代码
 func (g *GitDumper) Dump(targetURL, outdir, proxy string, force bool) error {
-	// 解析代理URL
-	var httpClient *http.Client
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return fmt.Errorf("解析代理URL失败: %v", err)
-		}
-		httpClient = &http.Client{
-			Transport: &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			},
-		}
-	} else {
-		httpClient = &http.Client{}
+	// 创建HTTP客户端
+	httpClient, err := httpclient.New(proxy)
+	if err != nil {
+		return err
 	}
 
 	// 确保目标URL以/结尾
@@ -82,34 +77,23 @@ func (g *GitDumper) Dump(targetURL, outdir, proxy string, force bool) error {
 	// 下载常见的Git文件
 	for _, file := range gitFiles {
 		fileURL := targetURL + file
-		outPath := filepath.Join(outdir, file)
-
-		// 创建目录
-		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-			return fmt.Errorf("创建目录失败: %v", err)
-		}
 
 		// 下载文件
 		resp, err := httpClient.Get(fileURL)
 		if err != nil {
 			continue // 忽略下载失败的文件
 		}
-		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
 			continue // 忽略不存在的文件
 		}
 
-		// 创建文件
-		f, err := os.Create(outPath)
-		if err != nil {
-			return fmt.Errorf("创建文件失败: %v", err)
-		}
-		defer f.Close()
-
 		// 写入文件
-		if _, err := io.Copy(f, resp.Body); err != nil {
-			return fmt.Errorf("写入文件失败: %v", err)
+		_, err = dumper.SaveFile(outdir, file, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -123,34 +107,17 @@ func (d *GitDumper) Check(targetURL string, client *http.Client) (bool, error) {
 		targetURL += "/"
 	}
 
-	// 检查 .git/HEAD 文件
-	headURL := targetURL + ".git/HEAD"
-	resp, err := client.Head(headURL)
-	if err != nil {
-		return false, nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
-	}
-
-	return false, nil
+	// 检查 .git/HEAD 文件，排除不存在的文件也返回200的情况
+	_, ok := dumper.Fetch(client, soft404.Calibrate(client, targetURL), targetURL+".git/HEAD", 0, nil)
+	return ok, nil
 }
 
 // Execute 执行下载操作
 func (d *GitDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
 	// 创建HTTP客户端
-	client := &http.Client{}
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return fmt.Errorf("代理设置错误: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		client.Transport = transport
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
 	}
 
 	// 确保URL以/结尾
@@ -181,57 +148,32 @@ func (d *GitDumper) Execute(targetURL string, outdir string, proxy string, force
 		".git/info/exclude",
 	}
 
+	// 不存在的文件也返回200时，记录错误页面的特征用于排除误报
+	baseline := soft404.Calibrate(client, targetURL)
+
 	// 下载文件
 	for _, file := range gitFiles {
 		fileURL := targetURL + file
-		localPath := filepath.Join(outdir, file)
-
-		// 创建目录
-		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
-			if progressCb != nil {
-				progressCb(fileURL, 0, "创建目录失败")
-			}
-			continue
-		}
 
-		// 下载文件
-		resp, err := client.Get(fileURL)
-		if err != nil {
-			if progressCb != nil {
-				progressCb(fileURL, 0, "下载失败")
-			}
+		// 错误页面和内容与文件类型不符的文件不保存
+		data, ok := dumper.Fetch(client, baseline, fileURL, 0, progressCb)
+		if !ok {
 			continue
 		}
+		dumper.SaveData(outdir, file, data, fileURL, progressCb)
+	}
 
-		// 调用进度回调
-		if progressCb != nil {
-			progressCb(fileURL, resp.StatusCode, localPath)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			continue
-		}
-
-		// 创建本地文件
-		f, err := os.Create(localPath)
-		if err != nil {
-			resp.Body.Close()
-			continue
-		}
-
-		// 写入文件内容
-		_, err = io.Copy(f, resp.Body)
-		resp.Body.Close()
-		f.Close()
-
-		if err != nil {
-			if progressCb != nil {
-				progressCb(fileURL, 0, "写入失败")
-			}
-			continue
-		}
+	// 根据 index 文件下载对象并还原源代码
+	data, err := os.ReadFile(filepath.Join(outdir, ".git", "index"))
+	if err != nil {
+		return nil
 	}
+	idx, err := ParseIndex(data)
+	if err != nil {
+		return fmt.Errorf("解析index文件失败: %v", err)
+	}
+
+	d.restore(client, targetURL, outdir, idx, workers, progressCb)
 
 	return nil
 }
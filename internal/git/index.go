@@ -0,0 +1,158 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dumpall-go/internal/dumper"
+)
+
+// 解压后对象的最大长度
+const maxObjectSize = dumper.DefaultFetchLimit
+
+// IndexEntry 表示 .git/index 中的一条文件记录
+type IndexEntry struct {
+	Name    string    // 文件路径
+	SHA1    string    // 对象哈希
+	Mode    uint32    // 文件模式
+	Size    uint32    // 文件大小
+	ModTime time.Time // 修改时间
+}
+
+// Index 表示解析后的 .git/index 文件
+type Index struct {
+	Version uint32
+	Entries []IndexEntry
+}
+
+// ParseIndex 解析 .git/index 文件内容，支持 v2、v3、v4 格式
+func ParseIndex(data []byte) (*Index, error) {
+	if len(data) < 12 || string(data[0:4]) != "DIRC" {
+		return nil, fmt.Errorf("无效的 index 文件")
+	}
+
+	idx := &Index{Version: binary.BigEndian.Uint32(data[4:8])}
+	if idx.Version < 2 || idx.Version > 4 {
+		return nil, fmt.Errorf("不支持的 index 版本: %d", idx.Version)
+	}
+
+	count := binary.BigEndian.Uint32(data[8:12])
+	offset := 12
+	prevName := ""
+
+	for i := uint32(0); i < count; i++ {
+		// 固定部分: ctime(8) mtime(8) dev ino mode uid gid size(各4) sha1(20) flags(2)
+		if offset+62 > len(data) {
+			return nil, fmt.Errorf("第 %d 条记录越界", i)
+		}
+		start := offset
+		entry := IndexEntry{
+			ModTime: time.Unix(int64(binary.BigEndian.Uint32(data[offset+8:])), int64(binary.BigEndian.Uint32(data[offset+12:]))),
+			Mode:    binary.BigEndian.Uint32(data[offset+24:]),
+			Size:    binary.BigEndian.Uint32(data[offset+36:]),
+			SHA1:    hex.EncodeToString(data[offset+40 : offset+60]),
+		}
+		flags := binary.BigEndian.Uint16(data[offset+60:])
+		offset += 62
+
+		// v3 及以上的扩展标志位
+		if idx.Version >= 3 && flags&0x4000 != 0 {
+			offset += 2
+		}
+		if offset > len(data) {
+			return nil, fmt.Errorf("第 %d 条记录越界", i)
+		}
+
+		if idx.Version == 4 {
+			// v4 使用前缀压缩: 先读取需要从上一个文件名删除的字节数
+			strip, n := readOffsetVarint(data[offset:])
+			if n == 0 || strip > uint64(len(prevName)) {
+				return nil, fmt.Errorf("第 %d 条记录名称压缩异常", i)
+			}
+			offset += n
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("第 %d 条记录名称越界", i)
+			}
+			entry.Name = prevName[:len(prevName)-int(strip)] + string(data[offset:offset+end])
+			offset += end + 1
+		} else {
+			end := bytes.IndexByte(data[offset:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("第 %d 条记录名称越界", i)
+			}
+			entry.Name = string(data[offset : offset+end])
+			offset += end + 1
+			// 记录长度按8字节对齐
+			for (offset-start)%8 != 0 {
+				offset++
+			}
+		}
+
+		prevName = entry.Name
+		idx.Entries = append(idx.Entries, entry)
+	}
+
+	return idx, nil
+}
+
+// readOffsetVarint 读取 git 的偏移量变长整数编码
+func readOffsetVarint(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	b := data[0]
+	value := uint64(b & 0x7f)
+	n := 1
+	for b&0x80 != 0 {
+		if n >= len(data) || n > 9 {
+			return 0, 0
+		}
+		b = data[n]
+		n++
+		value = ((value + 1) << 7) | uint64(b&0x7f)
+	}
+	return value, n
+}
+
+// ParseObject 解压 loose object 并返回对象类型和内容
+func ParseObject(data []byte) (string, []byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, fmt.Errorf("解压对象失败: %v", err)
+	}
+	defer r.Close()
+
+	// 限制解压后的长度，防止压缩炸弹
+	raw, ok := dumper.ReadLimited(r, maxObjectSize)
+	if !ok {
+		return "", nil, fmt.Errorf("解压对象失败或对象过大")
+	}
+
+	nul := bytes.IndexByte(raw, 0)
+	if nul < 0 {
+		return "", nil, fmt.Errorf("无效的对象头")
+	}
+	header := string(raw[:nul])
+	sp := bytes.IndexByte(raw[:nul], ' ')
+	if sp < 0 {
+		return "", nil, fmt.Errorf("无效的对象头: %s", header)
+	}
+
+	// 对象头中的长度与内容不符时说明对象被截断
+	if size, err := strconv.Atoi(header[sp+1:]); err != nil || size != len(raw)-nul-1 {
+		return "", nil, fmt.Errorf("对象长度不符: %s", header)
+	}
+
+	return header[:sp], raw[nul+1:], nil
+}
+
+// ObjectPath 返回对象在 .git 目录中的相对路径
+func ObjectPath(sha1 string) string {
+	return "objects/" + sha1[:2] + "/" + sha1[2:]
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"testing"
+	"time"
+)
+
+// testdata 中的 index 由 git 2.39 生成: v2 为 git add 的结果，
+// v3 在此基础上用 git add -N 加入 new.txt，v4 再经过 git update-index --index-version 4
+func TestParseIndex(t *testing.T) {
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := []IndexEntry{
+		{Name: "README.md", SHA1: "ce013625030ba8dba906f756967f9e9ca394464a", Mode: 0100644, Size: 6, ModTime: mtime},
+		{Name: "src/index.php", SHA1: "c8ab8e0e325892f86ed0e23aa8b86432a6717656", Mode: 0100644, Size: 14, ModTime: mtime},
+		{Name: "src/lib/中文.txt", SHA1: "c1b0730e0133447badcfd47fd144e254807b06e1", Mode: 0100644, Size: 1, ModTime: mtime},
+	}
+	intentToAdd := IndexEntry{Name: "new.txt", SHA1: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", Mode: 0100644, ModTime: time.Unix(0, 0)}
+	withNew := []IndexEntry{base[0], intentToAdd, base[1], base[2]}
+
+	tests := []struct {
+		file    string
+		version uint32
+		want    []IndexEntry
+	}{
+		{"testdata/index.v2", 2, base},
+		{"testdata/index.v3", 3, withNew},
+		{"testdata/index.v4", 4, withNew},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx, err := ParseIndex(data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if idx.Version != tt.version {
+			t.Errorf("%s: Version = %d, want %d", tt.file, idx.Version, tt.version)
+		}
+		if len(idx.Entries) != len(tt.want) {
+			t.Errorf("%s: got %d entries, want %d: %+v", tt.file, len(idx.Entries), len(tt.want), idx.Entries)
+			continue
+		}
+		for i, want := range tt.want {
+			got := idx.Entries[i]
+			if got.Name != want.Name || got.SHA1 != want.SHA1 || got.Mode != want.Mode || got.Size != want.Size || !got.ModTime.Equal(want.ModTime) {
+				t.Errorf("%s: entry %d = %+v, want %+v", tt.file, i, got, want)
+			}
+		}
+	}
+}
+
+func TestParseIndexMalformed(t *testing.T) {
+	data, err := os.ReadFile("testdata/index.v4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"空文件", nil},
+		{"文件头错误", append([]byte("DIRX"), data[4:]...)},
+		{"不支持的版本", append([]byte("DIRC\x00\x00\x00\x05"), data[8:]...)},
+		{"截断的记录", data[:12+40]},
+		{"截断的名称", data[:12+62+3]},
+		{"条目数过大", append(append([]byte("DIRC\x00\x00\x00\x04"), 0xff, 0xff, 0xff, 0xff), data[12:]...)},
+	}
+	for _, tt := range tests {
+		if _, err := ParseIndex(tt.data); err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+		}
+	}
+}
+
+func TestParseObject(t *testing.T) {
+	// README.md 对应的 loose object
+	data, err := os.ReadFile("testdata/ce013625030ba8dba906f756967f9e9ca394464a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, content, err := ParseObject(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != "blob" || string(content) != "hello\n" {
+		t.Errorf("ParseObject = %q, %q", typ, content)
+	}
+
+	compress := func(raw []byte) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(raw)
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"不是 zlib 数据", []byte("<html>not found</html>")},
+		{"缺少对象头", compress([]byte("blob 5"))},
+		{"长度不符", compress([]byte("blob 9\x00hello"))},
+		{"解压后过大", compress(append([]byte("blob 1\x00"), make([]byte, maxObjectSize)...))},
+	}
+	for _, tt := range tests {
+		if _, _, err := ParseObject(tt.data); err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+		}
+	}
+
+	if got := ObjectPath("ce013625030ba8dba906f756967f9e9ca394464a"); got != "objects/ce/013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("ObjectPath = %q", got)
+	}
+}
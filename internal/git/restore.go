@@ -0,0 +1,71 @@
+package git
+
+import (
+	"bytes"
+	"net/http"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+)
+
+// restore 下载 index 中记录的对象并写出工作区文件
+func (d *GitDumper) restore(client *http.Client, targetURL string, outdir string, idx *Index, workers int, progressCb dumper.ProgressCallback) {
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	for _, entry := range idx.Entries {
+		// 跳过子模块
+		if entry.Mode&0170000 == 0160000 {
+			continue
+		}
+		d.Paths.Add(entry.Name)
+		if !d.Filter.Match(filter.Entry{Path: entry.Name, Size: int64(entry.Size), ModTime: entry.ModTime}) {
+			continue
+		}
+		// 文件名来自远程文件，跳过越出输出目录的路径
+		localPath, err := dumper.SafePath(outdir, entry.Name)
+		if err != nil {
+			if progressCb != nil {
+				progressCb(entry.Name, 0, "非法路径")
+			}
+			continue
+		}
+
+		pool.Go(func() {
+			restoreObject(client, targetURL, outdir, entry, localPath, progressCb)
+		})
+	}
+}
+
+// restoreObject 下载一个对象，解析后写出对应的工作区文件
+func restoreObject(client *http.Client, targetURL string, outdir string, entry IndexEntry, localPath string, progressCb dumper.ProgressCallback) {
+	objectPath := ".git/" + ObjectPath(entry.SHA1)
+	fileURL := targetURL + objectPath
+
+	raw, ok := dumper.Fetch(client, nil, fileURL, 0, progressCb)
+	if !ok {
+		return
+	}
+
+	// 无法解析的内容可能是错误页面，不保存
+	objType, content, err := ParseObject(raw)
+	if err != nil || objType != "blob" {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "解析对象失败")
+		}
+		return
+	}
+
+	// 保存原始对象
+	dumper.SaveFile(outdir, objectPath, bytes.NewReader(raw))
+
+	if _, err := dumper.SaveFile(outdir, entry.Name, bytes.NewReader(content)); err != nil {
+		if progressCb != nil {
+			progressCb(fileURL, 0, "写入失败")
+		}
+		return
+	}
+	if progressCb != nil {
+		progressCb(fileURL, http.StatusOK, localPath)
+	}
+}
@@ -0,0 +1,282 @@
+// Package hg 实现 .hg (Mercurial) 仓库泄露的下载和工作区还原
+package hg
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// HgDumper 实现 .hg 源代码下载
+type HgDumper struct {
+	dumper.BaseDumper
+	// Filter 还原工作区文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 manifest 中的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewHgDumper 创建 HgDumper 实例
+func NewHgDumper() *HgDumper {
+	return &HgDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "hg",
+			Description: "下载 .hg 源代码",
+		},
+	}
+}
+
+// .hg 目录下的常见文件 (requires 单独下载)
+var hgFiles = []string{
+	"dirstate",
+	"branch",
+	"hgrc",
+	"undo.desc",
+	"last-message.txt",
+}
+
+// store 目录下的常见文件，路径相对于 store 目录
+var storeFiles = []string{
+	"requires",
+	"fncache",
+	"00changelog.i",
+	"00changelog.d",
+	"00manifest.i",
+	"00manifest.d",
+}
+
+// Check 检查目标是否存在 .hg 信息泄露
+func (d *HgDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	// 检查 .hg/requires 文件，排除不存在的文件也返回200的情况
+	fileURL := targetURL + ".hg/requires"
+	_, ok := dumper.Fetch(client, soft404.Calibrate(client, targetURL), fileURL, 0, nil)
+	return ok, nil
+}
+
+// Execute 执行下载操作
+func (d *HgDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+	hgURL := targetURL + ".hg/"
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	// 没有 requires 文件或内容不像 requires 时认为不存在 .hg 泄露
+	data, ok := dumper.Fetch(client, baseline, hgURL+"requires", 0, progressCb)
+	if !ok {
+		return nil
+	}
+	requires := ParseRequires(data)
+	if !requires["revlogv1"] && !requires["store"] {
+		return nil
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	files := map[string][]byte{"requires": data}
+	for _, file := range hgFiles {
+		if content, ok := dumper.Fetch(client, baseline, hgURL+file, 0, progressCb); ok {
+			files[file] = content
+		}
+	}
+	for _, file := range storeFiles {
+		name := requires.StoreDir() + file
+		if _, ok := files[name]; ok {
+			continue
+		}
+		if content, ok := dumper.Fetch(client, baseline, hgURL+name, 0, progressCb); ok {
+			files[name] = content
+		}
+	}
+
+	// 保存原始文件
+	for name, content := range files {
+		dumper.SaveData(outdir, ".hg/"+name, content, hgURL+name, progressCb)
+	}
+
+	entries, err := d.manifest(requires, files)
+	if err != nil {
+		// 无法解析 manifest 时，根据 fncache 和 dirstate 还原各文件的最新版本
+		entries = fallbackEntries(files[requires.StoreDir()+"fncache"], files["dirstate"])
+		if len(entries) == 0 {
+			return fmt.Errorf("解析 manifest 失败: %v", err)
+		}
+	}
+
+	d.restore(client, baseline, hgURL, outdir, requires, entries, parseDirstateInfo(files["dirstate"]), workers, progressCb)
+	return nil
+}
+
+// manifest 根据 changelog 最新版本找到对应的 manifest 并解析
+func (d *HgDumper) manifest(requires Requires, files map[string][]byte) ([]ManifestEntry, error) {
+	store := requires.StoreDir()
+	manifest, err := ParseRevlog(files[store+"00manifest.i"], files[store+"00manifest.d"])
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Len() == 0 {
+		return nil, fmt.Errorf("manifest 为空")
+	}
+
+	// 优先使用 changelog 最新提交引用的 manifest，否则使用最后一个 manifest 版本
+	rev := manifest.Len() - 1
+	if changelog, err := ParseRevlog(files[store+"00changelog.i"], files[store+"00changelog.d"]); err == nil && changelog.Len() > 0 {
+		if text, err := changelog.Revision(changelog.Len() - 1); err == nil {
+			if r := manifest.FindNode(ManifestNode(text)); r >= 0 {
+				rev = r
+			}
+		}
+	}
+
+	text, err := manifest.Revision(rev)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(text), nil
+}
+
+// fallbackEntries 从 fncache 或 dirstate 中获取文件列表，节点ID为空表示使用最新版本
+func fallbackEntries(fncache []byte, dirstate []byte) []ManifestEntry {
+	var entries []ManifestEntry
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			entries = append(entries, ManifestEntry{Path: name})
+		}
+	}
+
+	for _, name := range ParseFncache(fncache) {
+		add(name)
+	}
+	if ds, err := ParseDirstate(dirstate); err == nil {
+		for _, e := range ds {
+			if e.State != 'r' {
+				add(e.Path)
+			}
+		}
+	}
+	return entries
+}
+
+// parseDirstateInfo 返回 dirstate 中记录的文件大小和修改时间，用于过滤
+func parseDirstateInfo(data []byte) map[string]DirstateEntry {
+	info := make(map[string]DirstateEntry)
+	if entries, err := ParseDirstate(data); err == nil {
+		for _, e := range entries {
+			info[e.Path] = e
+		}
+	}
+	return info
+}
+
+// restore 下载每个文件的 filelog 并还原对应版本的内容
+func (d *HgDumper) restore(client *http.Client, baseline *soft404.Baseline, hgURL string, outdir string, requires Requires, entries []ManifestEntry, info map[string]DirstateEntry, workers int, progressCb dumper.ProgressCallback) {
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	for _, entry := range entries {
+		d.Paths.Add(entry.Path)
+		fe := filter.Entry{Path: entry.Path, Size: -1}
+		if ds, ok := info[entry.Path]; ok {
+			fe.ModTime = ds.ModTime
+			if ds.Size >= 0 {
+				fe.Size = ds.Size
+			}
+		}
+		if !d.Filter.Match(fe) {
+			continue
+		}
+
+		// manifest 中的路径可能被构造为越出输出目录
+		if _, err := dumper.SafePath(outdir, entry.Path); err != nil {
+			if progressCb != nil {
+				progressCb(entry.Path, 0, "非法路径")
+			}
+			continue
+		}
+
+		pool.Go(func() {
+			restoreFile(client, baseline, hgURL, outdir, requires, entry, progressCb)
+		})
+	}
+}
+
+// restoreFile 下载一个文件的 filelog 并写出对应版本的内容
+func restoreFile(client *http.Client, baseline *soft404.Baseline, hgURL string, outdir string, requires Requires, entry ManifestEntry, progressCb dumper.ProgressCallback) {
+	indexName := requires.FilelogPath(entry.Path, ".i")
+	indexURL := hgURL + dumper.EscapePath(indexName)
+	index, ok := dumper.Fetch(client, baseline, indexURL, 0, progressCb)
+	if !ok {
+		return
+	}
+	dumper.SaveData(outdir, ".hg/"+indexName, index, indexURL, progressCb)
+
+	rl, err := ParseRevlog(index, nil)
+	if err == nil && !rl.Inline {
+		dataName := requires.FilelogPath(entry.Path, ".d")
+		dataURL := hgURL + dumper.EscapePath(dataName)
+		if data, ok := dumper.Fetch(client, baseline, dataURL, 0, progressCb); ok {
+			dumper.SaveData(outdir, ".hg/"+dataName, data, dataURL, progressCb)
+			rl, err = ParseRevlog(index, data)
+		}
+	}
+	if err != nil || rl.Len() == 0 {
+		if progressCb != nil {
+			progressCb(indexURL, 0, "解析 filelog 失败")
+		}
+		return
+	}
+
+	rev := rl.Len() - 1
+	if entry.Node != "" {
+		if rev = rl.FindNode(entry.Node); rev < 0 {
+			if progressCb != nil {
+				progressCb(indexURL, 0, "未找到对应版本")
+			}
+			return
+		}
+	}
+
+	text, err := rl.Revision(rev)
+	if err != nil {
+		if progressCb != nil {
+			progressCb(indexURL, 0, "还原文件失败")
+		}
+		return
+	}
+
+	if _, err := dumper.SaveFile(outdir, entry.Path, bytes.NewReader(FileText(text))); err != nil {
+		if progressCb != nil {
+			progressCb(indexURL, 0, "写入失败")
+		}
+	}
+}
+
+// Validate 验证URL是否有效
+func (d *HgDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package hg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dumpall-go/internal/dumper"
+)
+
+func TestExecute(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/repo")))
+	defer srv.Close()
+
+	d := NewHgDumper()
+	d.Paths = dumper.NewPathSet()
+	outdir := t.TempDir()
+	if err := d.Execute(srv.URL, outdir, "", false, false, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"README.md":       "hello\nworld\n",
+		"docs/README.txt": "hello\nworld\n",
+		"src/Config.PHP":  configPHP,
+		// 原始的 revlog 同样保存
+		".hg/store/data/src/_config._p_h_p.d": "",
+	}
+	for name, text := range want {
+		data, err := os.ReadFile(filepath.Join(outdir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if text != "" && string(data) != text {
+			t.Errorf("%s = %q, want %q", name, data, text)
+		}
+	}
+}
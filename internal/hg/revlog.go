@@ -0,0 +1,241 @@
+package hg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// revlog 索引项的固定长度和标志位
+const (
+	indexEntrySize = 64
+	flagInline     = 1 << 16
+	flagGeneral    = 1 << 17
+)
+
+// 单个版本还原后允许的最大长度和增量链的最大长度
+const (
+	maxRevisionSize = 256 << 20
+	maxChainLength  = 100000
+)
+
+// RevlogEntry 表示 revlog 索引中的一项
+type RevlogEntry struct {
+	Offset   int64  // 数据在数据文件中的偏移
+	Length   int    // 压缩后的数据长度
+	Size     int    // 还原后的完整长度
+	Base     int    // 增量基准版本 (generaldelta 时为增量父版本)
+	LinkRev  int    // 对应的 changelog 版本
+	Parent1  int    // 父版本1，-1 表示没有
+	Parent2  int    // 父版本2，-1 表示没有
+	Node     string // 40 位十六进制节点ID
+	dataFrom int    // inline 时数据在 .i 文件中的位置
+}
+
+// Revlog 表示一个已解析的 revlog (.i 索引和可选的 .d 数据文件)
+type Revlog struct {
+	Version      int
+	Inline       bool
+	GeneralDelta bool
+	Entries      []RevlogEntry
+	index        []byte
+	data         []byte
+}
+
+// ParseRevlog 解析 revlog 索引，非 inline 的 revlog 需要同时提供 .d 文件内容
+func ParseRevlog(index []byte, data []byte) (*Revlog, error) {
+	if len(index) == 0 {
+		return &Revlog{Version: 1, index: index, data: data}, nil
+	}
+	if len(index) < indexEntrySize {
+		return nil, fmt.Errorf("revlog 索引过短")
+	}
+
+	header := binary.BigEndian.Uint32(index[0:4])
+	rl := &Revlog{
+		Version:      int(header & 0xffff),
+		Inline:       header&flagInline != 0,
+		GeneralDelta: header&flagGeneral != 0,
+		index:        index,
+		data:         data,
+	}
+	if rl.Version != 1 {
+		return nil, fmt.Errorf("不支持的 revlog 版本: %d", rl.Version)
+	}
+
+	for pos := 0; pos+indexEntrySize <= len(index); {
+		e := index[pos : pos+indexEntrySize]
+		entry := RevlogEntry{
+			Offset:  int64(binary.BigEndian.Uint64(e[0:8]) >> 16),
+			Length:  int(binary.BigEndian.Uint32(e[8:12])),
+			Size:    int(int32(binary.BigEndian.Uint32(e[12:16]))),
+			Base:    int(int32(binary.BigEndian.Uint32(e[16:20]))),
+			LinkRev: int(int32(binary.BigEndian.Uint32(e[20:24]))),
+			Parent1: int(int32(binary.BigEndian.Uint32(e[24:28]))),
+			Parent2: int(int32(binary.BigEndian.Uint32(e[28:32]))),
+			Node:    hex.EncodeToString(e[32:52]),
+		}
+		// 第一项的前4个字节被版本头占用，偏移固定为0
+		if len(rl.Entries) == 0 {
+			entry.Offset = 0
+		}
+		pos += indexEntrySize
+
+		if rl.Inline {
+			entry.dataFrom = pos
+			if entry.Length < 0 || pos+entry.Length > len(index) {
+				return nil, fmt.Errorf("revlog 数据越界: 版本 %d", len(rl.Entries))
+			}
+			pos += entry.Length
+		}
+		rl.Entries = append(rl.Entries, entry)
+	}
+
+	return rl, nil
+}
+
+// Len 返回版本数量
+func (rl *Revlog) Len() int {
+	return len(rl.Entries)
+}
+
+// FindNode 根据节点ID查找版本号，找不到时返回 -1
+func (rl *Revlog) FindNode(node string) int {
+	for i := len(rl.Entries) - 1; i >= 0; i-- {
+		if rl.Entries[i].Node == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// chunk 返回指定版本解压后的数据块 (完整内容或增量)
+func (rl *Revlog) chunk(rev int) ([]byte, error) {
+	e := rl.Entries[rev]
+
+	var raw []byte
+	if rl.Inline {
+		raw = rl.index[e.dataFrom : e.dataFrom+e.Length]
+	} else {
+		start := e.Offset
+		end := start + int64(e.Length)
+		if e.Length < 0 || start < 0 || end > int64(len(rl.data)) {
+			return nil, fmt.Errorf("revlog 数据越界: 版本 %d", rev)
+		}
+		raw = rl.data[start:end]
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case 0:
+		return raw, nil
+	case 'u':
+		return raw[1:], nil
+	case 'x':
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("解压版本 %d 失败: %v", rev, err)
+		}
+		defer zr.Close()
+		out, err := io.ReadAll(io.LimitReader(zr, maxRevisionSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("解压版本 %d 失败: %v", rev, err)
+		}
+		if len(out) > maxRevisionSize {
+			return nil, fmt.Errorf("版本 %d 过大", rev)
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("不支持的压缩格式: 0x%02x", raw[0])
+}
+
+// deltaChain 返回还原指定版本需要依次应用的版本号，第一个为完整内容
+func (rl *Revlog) deltaChain(rev int) ([]int, error) {
+	var chain []int
+	for cur := rev; ; {
+		if len(chain) > maxChainLength {
+			return nil, fmt.Errorf("增量链过长")
+		}
+		chain = append(chain, cur)
+		base := rl.Entries[cur].Base
+		if base == cur {
+			break
+		}
+		if base < 0 || base > cur {
+			return nil, fmt.Errorf("无效的增量基准: %d", base)
+		}
+		// 未开启 generaldelta 时，增量总是基于前一个版本
+		if rl.GeneralDelta {
+			cur = base
+		} else {
+			cur--
+		}
+	}
+
+	// 反转为从完整内容开始的顺序
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// Revision 还原指定版本的完整内容
+func (rl *Revlog) Revision(rev int) ([]byte, error) {
+	if rev < 0 || rev >= len(rl.Entries) {
+		return nil, fmt.Errorf("版本不存在: %d", rev)
+	}
+	chain, err := rl.deltaChain(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := rl.chunk(chain[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range chain[1:] {
+		delta, err := rl.chunk(r)
+		if err != nil {
+			return nil, err
+		}
+		if text, err = applyDelta(text, delta); err != nil {
+			return nil, fmt.Errorf("应用版本 %d 的增量失败: %v", r, err)
+		}
+	}
+
+	return text, nil
+}
+
+// applyDelta 应用 bdiff 格式的增量: 若干个 (start, end, length, data) 片段
+func applyDelta(text []byte, delta []byte) ([]byte, error) {
+	var out bytes.Buffer
+	last := 0
+	for pos := 0; pos < len(delta); {
+		if pos+12 > len(delta) {
+			return nil, fmt.Errorf("增量数据截断")
+		}
+		start := int(binary.BigEndian.Uint32(delta[pos:]))
+		end := int(binary.BigEndian.Uint32(delta[pos+4:]))
+		n := int(binary.BigEndian.Uint32(delta[pos+8:]))
+		pos += 12
+		if start < last || end < start || end > len(text) || n < 0 || pos+n > len(delta) {
+			return nil, fmt.Errorf("增量片段越界")
+		}
+		out.Write(text[last:start])
+		out.Write(delta[pos : pos+n])
+		if out.Len() > maxRevisionSize {
+			return nil, fmt.Errorf("还原后的内容过大")
+		}
+		pos += n
+		last = end
+	}
+	out.Write(text[last:])
+	return out.Bytes(), nil
+}
@@ -0,0 +1,198 @@
+package hg
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testdata/repo/.hg 为一个使用 store、fncache、dotencode 和 generaldelta 的仓库，包含三次提交:
+// README.md 为 inline 的 filelog，src/Config.PHP 为分离的 .i 和 .d，第三个版本的增量基于第一个版本
+const testStore = "testdata/repo/.hg/store/"
+
+var configPHP = "<?php\n$db_host = 'localhost';\n$db_pass = 's3cret';\n" +
+	string(bytes.Repeat([]byte("// padding\n"), 40)) + "$debug = true;\n"
+
+func readRevlog(t *testing.T, name string, split bool) *Revlog {
+	index, err := os.ReadFile(filepath.Join(testStore, name+".i"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	if split {
+		if data, err = os.ReadFile(filepath.Join(testStore, name+".d")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rl, err := ParseRevlog(index, data)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return rl
+}
+
+// nodeOf 按 Mercurial 的规则计算节点ID: sha1(较小的父节点 + 较大的父节点 + 内容)
+func nodeOf(rl *Revlog, rev int, text []byte) string {
+	parent := func(r int) []byte {
+		if r < 0 {
+			return make([]byte, 20)
+		}
+		b, _ := hex.DecodeString(rl.Entries[r].Node)
+		return b
+	}
+	p1, p2 := parent(rl.Entries[rev].Parent1), parent(rl.Entries[rev].Parent2)
+	if bytes.Compare(p1, p2) > 0 {
+		p1, p2 = p2, p1
+	}
+	h := sha1.New()
+	h.Write(p1)
+	h.Write(p2)
+	h.Write(text)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestRevlog(t *testing.T) {
+	tests := []struct {
+		name   string
+		split  bool
+		inline bool
+		want   []string
+	}{
+		{"data/_r_e_a_d_m_e.md", false, true, []string{"hello\n", "hello\nworld\n"}},
+		{"data/src/_config._p_h_p", true, false, []string{
+			"<?php\n$db_host = 'localhost';\n$db_pass = 'secret';\n" + string(bytes.Repeat([]byte("// padding\n"), 40)),
+			"<?php\n$db_host = 'localhost';\n$db_pass = 's3cret';\n" + string(bytes.Repeat([]byte("// padding\n"), 40)),
+			configPHP,
+		}},
+		{"data/docs/_r_e_a_d_m_e.txt", false, true, nil},
+	}
+	for _, tt := range tests {
+		rl := readRevlog(t, tt.name, tt.split)
+		if rl.Inline != tt.inline || !rl.GeneralDelta || rl.Version != 1 {
+			t.Errorf("%s: Inline = %v GeneralDelta = %v Version = %d", tt.name, rl.Inline, rl.GeneralDelta, rl.Version)
+		}
+		for rev := 0; rev < rl.Len(); rev++ {
+			text, err := rl.Revision(rev)
+			if err != nil {
+				t.Errorf("%s@%d: %v", tt.name, rev, err)
+				continue
+			}
+			if tt.want != nil && string(text) != tt.want[rev] {
+				t.Errorf("%s@%d = %q, want %q", tt.name, rev, text, tt.want[rev])
+			}
+			if node := nodeOf(rl, rev, text); node != rl.Entries[rev].Node {
+				t.Errorf("%s@%d: 节点ID %s 与内容不符 %s", tt.name, rev, rl.Entries[rev].Node, node)
+			}
+			if rl.FindNode(rl.Entries[rev].Node) != rev {
+				t.Errorf("%s@%d: FindNode 失败", tt.name, rev)
+			}
+		}
+	}
+
+	// 增量链从完整内容开始，generaldelta 时沿增量基准回溯
+	rl := readRevlog(t, "data/src/_config._p_h_p", true)
+	chain, err := rl.deltaChain(2)
+	if err != nil || !reflect.DeepEqual(chain, []int{0, 2}) {
+		t.Errorf("deltaChain(2) = %v, %v", chain, err)
+	}
+	if rl.FindNode("0000000000000000000000000000000000000000") != -1 {
+		t.Error("FindNode 应返回 -1")
+	}
+}
+
+func TestManifestAndChangelog(t *testing.T) {
+	changelog := readRevlog(t, "00changelog", false)
+	manifest := readRevlog(t, "00manifest", false)
+	text, err := changelog.Revision(changelog.Len() - 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := manifest.FindNode(ManifestNode(text))
+	if rev != 2 {
+		t.Fatalf("changelog 最新版本引用的 manifest = %d, want 2", rev)
+	}
+	mtext, err := manifest.Revision(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := ParseManifest(mtext)
+	paths := []string{"README.md", "docs/README.txt", "src/Config.PHP"}
+	if len(entries) != len(paths) {
+		t.Fatalf("manifest = %+v", entries)
+	}
+	config := readRevlog(t, "data/src/_config._p_h_p", true)
+	for i, e := range entries {
+		if e.Path != paths[i] || len(e.Node) != 40 {
+			t.Errorf("entries[%d] = %+v", i, e)
+		}
+	}
+	if e := entries[2]; e.Flags != "x" || config.FindNode(e.Node) != 2 {
+		t.Errorf("src/Config.PHP = %+v", e)
+	}
+}
+
+func TestParseRevlogMalformed(t *testing.T) {
+	index, err := os.ReadFile(filepath.Join(testStore, "data/_r_e_a_d_m_e.md.i"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRevlog(index[:indexEntrySize-1], nil); err == nil {
+		t.Error("索引过短应返回错误")
+	}
+	if _, err := ParseRevlog(index[:len(index)-1], nil); err == nil {
+		t.Error("inline 数据截断应返回错误")
+	}
+	v2 := append([]byte(nil), index...)
+	v2[3] = 2
+	if _, err := ParseRevlog(v2, nil); err == nil {
+		t.Error("不支持的版本应返回错误")
+	}
+
+	// 分离的 revlog 缺少 .d 时无法还原
+	split, _ := os.ReadFile(filepath.Join(testStore, "data/src/_config._p_h_p.i"))
+	rl, err := ParseRevlog(split, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rl.Revision(0); err == nil {
+		t.Error("缺少数据文件应返回错误")
+	}
+	if _, err := rl.Revision(3); err == nil {
+		t.Error("不存在的版本应返回错误")
+	}
+
+	// 增量基准指向之后的版本
+	rl = readRevlog(t, "data/_r_e_a_d_m_e.md", false)
+	rl.Entries[1].Base = 5
+	if _, err := rl.Revision(1); err == nil {
+		t.Error("无效的增量基准应返回错误")
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	frag := func(start, end int, data string) []byte {
+		b := []byte{0, 0, 0, byte(start), 0, 0, 0, byte(end), 0, 0, 0, byte(len(data))}
+		return append(b, data...)
+	}
+	text := []byte("line1\nline2\nline3\n")
+	out, err := applyDelta(text, append(frag(0, 6, "first\n"), frag(12, 18, "")...))
+	if err != nil || string(out) != "first\nline2\n" {
+		t.Errorf("applyDelta = %q, %v", out, err)
+	}
+
+	for name, delta := range map[string][]byte{
+		"截断":   frag(0, 6, "x")[:11],
+		"越界":   frag(0, 99, ""),
+		"片段倒序": append(frag(6, 12, ""), frag(0, 6, "")...),
+		"长度越界": frag(0, 6, "x")[:12],
+	} {
+		if _, err := applyDelta(text, delta); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
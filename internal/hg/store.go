@@ -0,0 +1,292 @@
+package hg
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// store 中路径的最大长度，超过时使用哈希编码 (与 Mercurial 保持一致)
+const (
+	maxStorePathLen  = 120
+	dirPrefixLen     = 8
+	maxShortDirsLen  = 8*(dirPrefixLen+1) - 4
+	dirstateHeadSize = 40
+)
+
+// Requires 表示 .hg/requires 中声明的仓库特性
+type Requires map[string]bool
+
+// ParseRequires 解析 requires 文件，每行一个特性
+func ParseRequires(data []byte) Requires {
+	req := make(Requires)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			req[line] = true
+		}
+	}
+	return req
+}
+
+// StoreDir 返回 revlog 所在目录 (相对于 .hg)
+func (r Requires) StoreDir() string {
+	if r["store"] {
+		return "store/"
+	}
+	return ""
+}
+
+// FilelogPath 返回被跟踪文件对应的 filelog 路径 (相对于 .hg)，ext 为 ".i" 或 ".d"
+func (r Requires) FilelogPath(name string, ext string) string {
+	p := "data/" + name + ext
+	switch {
+	case r["fncache"]:
+		return r.StoreDir() + hybridEncode(p, r["dotencode"])
+	case r["store"]:
+		return r.StoreDir() + encodeFilename(encodeDir(p))
+	}
+	return p
+}
+
+// encodeDir 为以 .hg、.i、.d 结尾的目录名追加 .hg，避免与 revlog 文件冲突
+func encodeDir(p string) string {
+	if !strings.Contains(p, ".hg/") && !strings.Contains(p, ".i/") && !strings.Contains(p, ".d/") {
+		return p
+	}
+	p = strings.ReplaceAll(p, ".hg/", ".hg.hg/")
+	p = strings.ReplaceAll(p, ".i/", ".i.hg/")
+	p = strings.ReplaceAll(p, ".d/", ".d.hg/")
+	return p
+}
+
+// isUnsafe 判断字符是否需要编码为 ~XX
+func isUnsafe(c byte) bool {
+	return c < 32 || c >= 126 || strings.IndexByte(`\:*?"<>|`, c) >= 0
+}
+
+// encodeFilename 对大写字母和特殊字符进行编码: A -> _a，_ -> __，其他 -> ~XX
+func encodeFilename(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte('_')
+			b.WriteByte(c + 32)
+		case c == '_':
+			b.WriteString("__")
+		case isUnsafe(c):
+			fmt.Fprintf(&b, "~%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// lowerEncode 用于哈希编码，大写字母直接转为小写
+func lowerEncode(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte(c + 32)
+		case isUnsafe(c):
+			fmt.Fprintf(&b, "~%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// auxEncode 编码 Windows 保留名称和路径段首尾的点与空格
+func auxEncode(parts []string, dotencode bool) []string {
+	out := make([]string, len(parts))
+	for i, n := range parts {
+		if n == "" {
+			out[i] = n
+			continue
+		}
+		if dotencode && (n[0] == '.' || n[0] == ' ') {
+			n = fmt.Sprintf("~%02x", n[0]) + n[1:]
+		} else {
+			l := strings.IndexByte(n, '.')
+			if l == -1 {
+				l = len(n)
+			}
+			prefix := ""
+			if len(n) >= 3 {
+				prefix = n[:3]
+			}
+			if (l == 3 && (prefix == "aux" || prefix == "con" || prefix == "prn" || prefix == "nul")) ||
+				(l == 4 && (prefix == "com" || prefix == "lpt") && n[3] >= '1' && n[3] <= '9') {
+				n = n[:2] + fmt.Sprintf("~%02x", n[2]) + n[3:]
+			}
+		}
+		if c := n[len(n)-1]; c == '.' || c == ' ' {
+			n = n[:len(n)-1] + fmt.Sprintf("~%02x", c)
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// hybridEncode 实现 fncache 仓库的路径编码，过长的路径使用哈希编码
+func hybridEncode(p string, dotencode bool) string {
+	p = encodeDir(p)
+	res := strings.Join(auxEncode(strings.Split(encodeFilename(p), "/"), dotencode), "/")
+	if len(res) > maxStorePathLen {
+		res = hashEncode(p, dotencode)
+	}
+	return res
+}
+
+// hashEncode 生成 dh/ 开头的哈希路径
+func hashEncode(p string, dotencode bool) string {
+	sum := sha1.Sum([]byte(p))
+	digest := hex.EncodeToString(sum[:])
+
+	// 去掉 data/ 前缀
+	parts := auxEncode(strings.Split(lowerEncode(p[5:]), "/"), dotencode)
+	basename := parts[len(parts)-1]
+	ext := path.Ext(basename)
+
+	var sdirs []string
+	sdirsLen := 0
+	for _, part := range parts[:len(parts)-1] {
+		d := part
+		if d == "" {
+			continue
+		}
+		if len(d) > dirPrefixLen {
+			d = d[:dirPrefixLen]
+		}
+		if c := d[len(d)-1]; c == '.' || c == ' ' {
+			d = d[:len(d)-1] + "_"
+		}
+		t := len(d)
+		if sdirsLen > 0 {
+			t = sdirsLen + 1 + len(d)
+			if t > maxShortDirsLen {
+				break
+			}
+		}
+		sdirs = append(sdirs, d)
+		sdirsLen = t
+	}
+
+	dirs := strings.Join(sdirs, "/")
+	if dirs != "" {
+		dirs += "/"
+	}
+	res := "dh/" + dirs + digest + ext
+	if left := maxStorePathLen - len(res); left > 0 {
+		filler := basename
+		if len(filler) > left {
+			filler = filler[:left]
+		}
+		res = "dh/" + dirs + filler + digest + ext
+	}
+	return res
+}
+
+// ParseFncache 解析 fncache，返回被跟踪文件的路径列表
+func ParseFncache(data []byte) []string {
+	var files []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "data/") || !strings.HasSuffix(line, ".i") {
+			continue
+		}
+		files = append(files, strings.TrimSuffix(strings.TrimPrefix(line, "data/"), ".i"))
+	}
+	return files
+}
+
+// ManifestEntry 表示 manifest 中的一个文件
+type ManifestEntry struct {
+	Path  string
+	Node  string // filelog 中对应版本的节点ID
+	Flags string // x 可执行，l 符号链接
+}
+
+// ParseManifest 解析 manifest 内容，每行为 "路径\0节点ID[标志]"
+func ParseManifest(text []byte) []ManifestEntry {
+	var entries []ManifestEntry
+	for _, line := range bytes.Split(text, []byte("\n")) {
+		i := bytes.IndexByte(line, 0)
+		if i <= 0 || len(line) < i+41 {
+			continue
+		}
+		entries = append(entries, ManifestEntry{
+			Path:  string(line[:i]),
+			Node:  string(line[i+1 : i+41]),
+			Flags: string(line[i+41:]),
+		})
+	}
+	return entries
+}
+
+// ManifestNode 返回 changelog 版本内容中记录的 manifest 节点ID (第一行)
+func ManifestNode(changeset []byte) string {
+	line, _, _ := bytes.Cut(changeset, []byte("\n"))
+	return string(line)
+}
+
+// FileText 去掉 filelog 内容开头的元数据 (复制来源等)
+func FileText(text []byte) []byte {
+	if !bytes.HasPrefix(text, []byte("\x01\n")) {
+		return text
+	}
+	if i := bytes.Index(text[2:], []byte("\x01\n")); i >= 0 {
+		return text[i+4:]
+	}
+	return text
+}
+
+// DirstateEntry 表示 dirstate 中的一个文件
+type DirstateEntry struct {
+	State   byte // n 正常，a 新增，r 删除，m 合并
+	Mode    uint32
+	Size    int64
+	ModTime time.Time
+	Path    string
+}
+
+// ParseDirstate 解析 v1 格式的 dirstate
+func ParseDirstate(data []byte) ([]DirstateEntry, error) {
+	if len(data) < dirstateHeadSize {
+		return nil, fmt.Errorf("dirstate 文件过短")
+	}
+
+	var entries []DirstateEntry
+	for pos := dirstateHeadSize; pos < len(data); {
+		if pos+17 > len(data) {
+			return nil, fmt.Errorf("dirstate 记录截断")
+		}
+		n := int(binary.BigEndian.Uint32(data[pos+13:]))
+		if n < 0 || pos+17+n > len(data) {
+			return nil, fmt.Errorf("dirstate 记录越界")
+		}
+		entry := DirstateEntry{
+			State: data[pos],
+			Mode:  binary.BigEndian.Uint32(data[pos+1:]),
+			Size:  int64(int32(binary.BigEndian.Uint32(data[pos+5:]))),
+		}
+		if mtime := int32(binary.BigEndian.Uint32(data[pos+9:])); mtime > 0 {
+			entry.ModTime = time.Unix(int64(mtime), 0)
+		}
+		// 复制的文件名后以 \0 分隔记录来源
+		name, _, _ := bytes.Cut(data[pos+17:pos+17+n], []byte{0})
+		entry.Path = string(name)
+		entries = append(entries, entry)
+		pos += 17 + n
+	}
+	return entries, nil
+}
@@ -0,0 +1,105 @@
+package hg
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilelogPath(t *testing.T) {
+	fncache := Requires{"store": true, "fncache": true, "dotencode": true}
+	tests := []struct {
+		requires Requires
+		name     string
+		want     string
+	}{
+		{Requires{}, "src/Config.PHP", "data/src/Config.PHP.i"},
+		{Requires{"store": true}, "src/Config.PHP", "store/data/src/_config._p_h_p.i"},
+		{Requires{"store": true}, "a.i/b", "store/data/a.i.hg/b.i"},
+		{fncache, "src/Config.PHP", "store/data/src/_config._p_h_p.i"},
+		{fncache, "foo_bar/x:y.txt", "store/data/foo__bar/x~3ay.txt.i"},
+		{fncache, ".htaccess", "store/data/~2ehtaccess.i"},
+		{Requires{"store": true, "fncache": true}, ".htaccess", "store/data/.htaccess.i"},
+		{fncache, "aux.c", "store/data/au~78.c.i"},
+		{fncache, "中", "store/data/~e4~b8~ad.i"},
+	}
+	for _, tt := range tests {
+		if got := tt.requires.FilelogPath(tt.name, ".i"); got != tt.want {
+			t.Errorf("FilelogPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// 期望值来自 Mercurial 的 test-hybridencode.py
+func TestHybridEncode(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"data/ABCDEFGHIJKLMNOPQRSTUVWXYZ", "data/_a_b_c_d_e_f_g_h_i_j_k_l_m_n_o_p_q_r_s_t_u_v_w_x_y_z"},
+		{"data/aux.bla/bla.aux/prn/PRN/lpt/com3/nul/coma/foo.NUL/normal.c.i",
+			"data/au~78.bla/bla.aux/pr~6e/_p_r_n/lpt/co~6d3/nu~6c/coma/foo._n_u_l/normal.c.i"},
+		{"data/AUX/SECOND/X.PRN/FOURTH/FI:FTH/SIXTH/SEVENTH/EIGHTH/NINETH/TENTH/ELEVENTH/LOREMIPSUM.TXT.i",
+			"dh/au~78/second/x.prn/fourth/fi~3afth/sixth/seventh/eighth/nineth/tenth/loremia20419e358ddff1bf8751e38288aff1d7c32ec05.i"},
+	}
+	for _, tt := range tests {
+		if got := hybridEncode(tt.path, true); got != tt.want {
+			t.Errorf("hybridEncode(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseStoreFiles(t *testing.T) {
+	requires, err := os.ReadFile("testdata/repo/.hg/requires")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := ParseRequires(requires)
+	for _, f := range []string{"store", "fncache", "dotencode", "generaldelta", "revlogv1"} {
+		if !req[f] {
+			t.Errorf("requires 缺少 %s", f)
+		}
+	}
+
+	fncache, _ := os.ReadFile(testStore + "fncache")
+	if got, want := ParseFncache(fncache), []string{"README.md", "src/Config.PHP", "docs/README.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFncache = %q, want %q", got, want)
+	}
+	for _, name := range ParseFncache(fncache) {
+		if _, err := os.Stat("testdata/repo/.hg/" + req.FilelogPath(name, ".i")); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	dirstate, _ := os.ReadFile("testdata/repo/.hg/dirstate")
+	entries, err := ParseDirstate(dirstate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1704164765, 0)
+	want := []DirstateEntry{
+		{'n', 0100644, 12, mtime, "README.md"},
+		{'n', 0100644, 12, mtime, "docs/README.txt"},
+		{'n', 0100755, int64(len(configPHP)), mtime, "src/Config.PHP"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseDirstate = %+v, want %+v", entries, want)
+	}
+	if _, err := ParseDirstate(dirstate[:len(dirstate)-1]); err == nil {
+		t.Error("截断的 dirstate 应返回错误")
+	}
+}
+
+func TestFileText(t *testing.T) {
+	tests := map[string]string{
+		"hello\n": "hello\n",
+		"\x01\ncopy: README.md\ncopyrev: 0123\n\x01\nhello\n": "hello\n",
+		"\x01\nbroken": "\x01\nbroken",
+	}
+	for in, want := range tests {
+		if got := string(FileText([]byte(in))); got != want {
+			t.Errorf("FileText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package httpclient 提供所有 dumper 共用的 HTTP 客户端
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	DefaultConnectTimeout      = 10 * time.Second
+	DefaultReadTimeout         = 30 * time.Second
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultMaxRedirects        = 10
+)
+
+// Options 配置共用的 HTTP 客户端，零值的超时表示不限制
+type Options struct {
+	// ConnectTimeout 建立 TCP 连接和 TLS 握手的超时时间
+	ConnectTimeout time.Duration
+	// ReadTimeout 等待服务器发送数据的超时时间，每次读取都会重新计时
+	ReadTimeout time.Duration
+	// Timeout 单个请求从连接到读完响应的总超时时间
+	Timeout time.Duration
+	// MaxIdleConnsPerHost 每个主机保留的空闲连接数量
+	MaxIdleConnsPerHost int
+	// Insecure 不验证服务器证书
+	Insecure bool
+	// DisableHTTP2 禁用 HTTP/2
+	DisableHTTP2 bool
+	// MaxRedirects 最多跟随的重定向次数，0 表示不跟随重定向
+	MaxRedirects int
+
+	// Targets 命令行中指定的目标URL，Headers、Cookie 和认证信息只发送给这些目标所在的主机
+	Targets []string
+	// Headers 添加到目标请求的请求头，Host 会替换请求的主机名
+	Headers http.Header
+	// Cookie 添加到目标请求的 Cookie，格式为 name=value; name2=value2
+	Cookie string
+	// CookieFile Netscape 格式的 Cookie 文件，服务器设置的 Cookie 会在之后的请求中一并发送
+	CookieFile string
+	// UserAgent 固定的 User-Agent
+	UserAgent string
+	// RandomUserAgent 每个请求随机使用一个常见浏览器的 User-Agent
+	RandomUserAgent bool
+	// BasicAuth HTTP Basic 认证，格式为 user:password
+	BasicAuth string
+	// BearerToken Bearer 认证的令牌
+	BearerToken string
+	// CertFile 和 KeyFile 为 PEM 格式的客户端证书和私钥
+	CertFile string
+	KeyFile  string
+
+	// ProxyPool 不为空时从代理池中选择代理
+	ProxyPool *ProxyPool
+	// RotatePerRequest 每个请求轮换代理，否则每个目标固定使用一个代理，直到该代理不可用
+	RotatePerRequest bool
+	// RateLimit 所有主机合计每秒最多发送的请求数，0 表示不限制
+	RateLimit float64
+	// HostRateLimit 每个主机每秒最多发送的请求数，0 表示不限制
+	HostRateLimit float64
+	// Jitter 每个请求发送前随机等待的最长时间
+	Jitter time.Duration
+	// MaxConnsPerHost 每个主机同时进行的请求数量，0 表示不限制
+	MaxConnsPerHost int
+
+	// Retries 网络错误和 429、502、503、504 响应的最多重试次数
+	Retries int
+	// RetryBackoff 第一次重试前的等待时间，之后每次加倍
+	RetryBackoff time.Duration
+	// RetryMaxBackoff 重试等待时间的上限
+	RetryMaxBackoff time.Duration
+}
+
+// DefaultOptions 返回默认配置
+func DefaultOptions() Options {
+	return Options{
+		ConnectTimeout:      DefaultConnectTimeout,
+		ReadTimeout:         DefaultReadTimeout,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		MaxRedirects:        DefaultMaxRedirects,
+		Retries:             DefaultRetries,
+		RetryBackoff:        DefaultRetryBackoff,
+		RetryMaxBackoff:     DefaultRetryMaxBackoff,
+	}
+}
+
+var (
+	mu      sync.Mutex
+	options = DefaultOptions()
+	// 按代理地址缓存 Transport，使所有 dumper 共用连接池
+	transports = make(map[string]*http.Transport)
+	// 所有请求共用的 Cookie、客户端证书和限速状态
+	jar         http.CookieJar
+	clientCerts []tls.Certificate
+	limit       *limiter
+)
+
+// SetOptions 设置共用客户端的配置，之前创建的连接池会被关闭
+func SetOptions(opts Options) error {
+	var certs []tls.Certificate
+	if opts.CertFile != "" {
+		keyFile := opts.KeyFile
+		if keyFile == "" {
+			// 私钥和证书可以保存在同一个 PEM 文件中
+			keyFile = opts.CertFile
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("读取客户端证书失败: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	var cookieJar http.CookieJar
+	if opts.CookieFile != "" {
+		var err error
+		if cookieJar, err = LoadCookieFile(opts.CookieFile); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	options = opts
+	jar = cookieJar
+	clientCerts = certs
+	limit = newLimiter(opts)
+	resetStats()
+	for key, t := range transports {
+		t.CloseIdleConnections()
+		delete(transports, key)
+	}
+	return nil
+}
+
+// New 返回使用指定代理的客户端，proxy 为空时使用环境变量中的代理
+// 设置了代理池时，proxy 为该目标分配的代理，按请求轮换时忽略
+func New(proxy string) (*http.Client, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var base http.RoundTripper
+	if options.ProxyPool != nil {
+		rt := &rotatingTransport{pool: options.ProxyPool}
+		if !options.RotatePerRequest {
+			rt.sticky = proxy
+		}
+		base = rt
+	} else {
+		transport, err := transportFor(proxy)
+		if err != nil {
+			return nil, err
+		}
+		base = transport
+	}
+	if limit != nil {
+		base = &limitTransport{base: base, limiter: limit}
+	}
+	if options.Retries > 0 {
+		base = &retryTransport{
+			base:       base,
+			retries:    options.Retries,
+			backoff:    options.RetryBackoff,
+			maxBackoff: options.RetryMaxBackoff,
+		}
+	}
+
+	return &http.Client{
+		Transport:     &headerTransport{base: base, opts: options, hosts: targetHosts(options.Targets)},
+		Jar:           jar,
+		Timeout:       options.Timeout,
+		CheckRedirect: redirectPolicy(options.MaxRedirects),
+	}, nil
+}
+
+// transportFor 返回使用指定代理的 Transport，调用前需要持有 mu
+func transportFor(proxy string) (*http.Transport, error) {
+	if t, ok := transports[proxy]; ok {
+		return t, nil
+	}
+	t, err := newTransport(proxy, options, clientCerts)
+	if err != nil {
+		return nil, err
+	}
+	transports[proxy] = t
+	return t, nil
+}
+
+// newTransport 根据配置创建 Transport
+func newTransport(proxy string, opts Options, certs []tls.Certificate) (*http.Transport, error) {
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer(opts),
+		TLSHandshakeTimeout:   opts.ConnectTimeout,
+		ResponseHeaderTimeout: opts.ReadTimeout,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+	}
+	if opts.Insecure || len(certs) > 0 {
+		t.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: opts.Insecure,
+			Certificates:       certs,
+		}
+	}
+	if opts.DisableHTTP2 {
+		// TLSNextProto 为空 map 时不会协商 HTTP/2
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	if proxy != "" {
+		proxyURL, err := ParseProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		switch proxyURL.Scheme {
+		case "socks4", "socks4a":
+			// net/http 不支持 SOCKS4，通过拨号函数连接代理
+			t.Proxy = nil
+			t.DialContext = newSocks4Dialer(proxyURL, t.DialContext, opts.ConnectTimeout).DialContext
+		default:
+			// HTTP、HTTPS 和 SOCKS5 (包括用户名密码认证) 由 net/http 处理
+			t.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	return t, nil
+}
+
+// redirectPolicy 返回重定向策略，超过次数时返回最后一次的响应而不是错误
+func redirectPolicy(max int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// dialer 返回带连接超时和读取超时的拨号函数
+func dialer(opts Options) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := d.DialContext(ctx, network, addr)
+		if err != nil || opts.ReadTimeout <= 0 {
+			return conn, err
+		}
+		return &timeoutConn{Conn: conn, readTimeout: opts.ReadTimeout}, nil
+	}
+}
+
+// timeoutConn 在每次读写前重新设置读取截止时间，避免服务器不再发送数据时一直阻塞
+// 连接池中的空闲连接一直处于读取状态，发送请求时同样需要延长截止时间
+type timeoutConn struct {
+	net.Conn
+	readTimeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
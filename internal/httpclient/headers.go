@@ -0,0 +1,195 @@
+package httpclient
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 随机 User-Agent 时使用的常见浏览器标识
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+}
+
+// ParseHeaders 解析 "Name: value" 形式的请求头
+func ParseHeaders(lines []string) (http.Header, error) {
+	header := make(http.Header)
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("无效的请求头: %s", line)
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	return header, nil
+}
+
+// headerTransport 为每个请求添加 User-Agent，为目标主机的请求添加自定义请求头、Cookie 和认证信息
+type headerTransport struct {
+	base  http.RoundTripper
+	opts  Options
+	hosts map[string]bool
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper 不能修改传入的请求
+	req = req.Clone(req.Context())
+
+	// 请求头、Cookie 和认证信息可能包含凭据，只发送给命令行中指定的目标主机
+	// 从泄露文件中得知的其他主机和重定向到其他主机的请求都不发送
+	trusted := t.hosts[hostKey(req.URL)] && sameHost(req)
+
+	switch {
+	case t.opts.RandomUserAgent:
+		req.Header.Set("User-Agent", userAgents[rand.Intn(len(userAgents))])
+	case t.opts.UserAgent != "" && req.Header.Get("User-Agent") == "":
+		req.Header.Set("User-Agent", t.opts.UserAgent)
+	}
+
+	if !trusted {
+		return t.base.RoundTrip(req)
+	}
+
+	// dumper 自己设置的请求头优先，例如 WebDAV 的 Depth
+	for name, values := range t.opts.Headers {
+		if textproto.CanonicalMIMEHeaderKey(name) == "Host" {
+			req.Host = values[0]
+			continue
+		}
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
+
+	if t.opts.Cookie != "" {
+		if c := req.Header.Get("Cookie"); c != "" {
+			req.Header.Set("Cookie", c+"; "+t.opts.Cookie)
+		} else {
+			req.Header.Set("Cookie", t.opts.Cookie)
+		}
+	}
+
+	if req.Header.Get("Authorization") == "" {
+		if t.opts.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+t.opts.BearerToken)
+		} else if t.opts.BasicAuth != "" {
+			user, pass, _ := strings.Cut(t.opts.BasicAuth, ":")
+			req.SetBasicAuth(user, pass)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// sameHost 判断重定向链中的每一个请求是否都属于同一主机
+func sameHost(req *http.Request) bool {
+	key := hostKey(req.URL)
+	for r := req; r.Response != nil && r.Response.Request != nil; {
+		r = r.Response.Request
+		if hostKey(r.URL) != key {
+			return false
+		}
+	}
+	return true
+}
+
+// targetHosts 返回目标URL所在的主机
+func targetHosts(targets []string) map[string]bool {
+	hosts := make(map[string]bool)
+	for _, target := range targets {
+		if u, err := url.Parse(target); err == nil && u.Host != "" {
+			hosts[hostKey(u)] = true
+		}
+	}
+	return hosts
+}
+
+// hostKey 返回小写的主机名和端口，没有端口时使用协议的默认端口
+func hostKey(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "https":
+			port = "443"
+		default:
+			port = "80"
+		}
+	}
+	return strings.ToLower(u.Hostname()) + ":" + port
+}
+
+// LoadCookieFile 读取 Netscape 格式 (curl、浏览器扩展导出) 的 Cookie 文件
+func LoadCookieFile(file string) (http.CookieJar, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("打开 Cookie 文件失败: %v", err)
+	}
+	defer f.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		// curl 用 #HttpOnly_ 前缀标记 HttpOnly 的 Cookie
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("Cookie 文件第 %d 行格式错误", lineNo)
+		}
+		domain := fields[0]
+		host := strings.TrimPrefix(domain, ".")
+		if host == "" {
+			return nil, fmt.Errorf("Cookie 文件第 %d 行缺少域名", lineNo)
+		}
+
+		cookie := &http.Cookie{
+			Name:   fields[5],
+			Value:  fields[6],
+			Path:   fields[2],
+			Secure: strings.EqualFold(fields[3], "TRUE"),
+		}
+		// 包含子域名的 Cookie 需要设置 Domain，否则只发送给该主机
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: "/"}, []*http.Cookie{cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 Cookie 文件失败: %v", err)
+	}
+	return jar, nil
+}
@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordServer 记录收到的凭据相关请求头
+type recordServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	seen map[string]http.Header
+}
+
+func newRecordServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *recordServer {
+	s := &recordServer{seen: make(map[string]http.Header)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.seen[r.URL.Path] = r.Header.Clone()
+		s.mu.Unlock()
+		if handler != nil {
+			handler(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *recordServer) header(path string) http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen[path]
+}
+
+func TestCredentialsOnlyForTargets(t *testing.T) {
+	foreign := newRecordServer(t, nil)
+	target := newRecordServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, foreign.URL+"/redirected", http.StatusFound)
+		case "/local-redirect":
+			http.Redirect(w, r, "/local", http.StatusFound)
+		}
+	})
+
+	opts := DefaultOptions()
+	opts.Targets = []string{target.URL + "/app/"}
+	opts.Headers = http.Header{"X-Token": {"secret"}}
+	opts.Cookie = "session=secret"
+	opts.BearerToken = "secret"
+	opts.UserAgent = "test-agent"
+	if err := SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetOptions(DefaultOptions()) })
+
+	client, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{target.URL + "/direct", target.URL + "/redirect", target.URL + "/local-redirect", foreign.URL + "/direct"} {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		trusted bool
+	}{
+		{"target", target.header("/direct"), true},
+		{"target before redirect", target.header("/redirect"), true},
+		{"same host redirect", target.header("/local"), true},
+		{"cross host redirect", foreign.header("/redirected"), false},
+		{"direct foreign request", foreign.header("/direct"), false},
+	}
+	for _, tt := range tests {
+		if tt.header == nil {
+			t.Fatalf("%s: request not received", tt.name)
+		}
+		for _, name := range []string{"X-Token", "Cookie", "Authorization"} {
+			if got := tt.header.Get(name) != ""; got != tt.trusted {
+				t.Errorf("%s: %s sent = %v, want %v", tt.name, name, got, tt.trusted)
+			}
+		}
+		if ua := tt.header.Get("User-Agent"); ua != "test-agent" {
+			t.Errorf("%s: User-Agent = %q", tt.name, ua)
+		}
+	}
+}
+
+func TestHostKey(t *testing.T) {
+	hosts := targetHosts([]string{"http://Example.com/", "https://example.com:8443/a", "ftp://"})
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://example.com/x", true},
+		{"http://EXAMPLE.com:80/x", true},
+		{"https://example.com/x", false},
+		{"https://example.com:8443/x", true},
+		{"http://example.com:8080/x", false},
+		{"http://sub.example.com/x", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		if got := hosts[hostKey(req.URL)]; got != tt.want {
+			t.Errorf("%s: trusted = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bucket 是容量为 1 的令牌桶，每隔 interval 生成一个令牌，使请求均匀分布
+type bucket struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newBucket(rate float64) *bucket {
+	return &bucket{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait 等待获取一个令牌
+func (b *bucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	delay := b.next.Sub(now)
+	b.next = b.next.Add(b.interval)
+	b.mu.Unlock()
+
+	return sleep(ctx, delay)
+}
+
+// sleep 等待指定时间，请求被取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limiter 记录所有客户端共用的限速状态
+type limiter struct {
+	global   *bucket
+	hostRate float64
+	jitter   time.Duration
+	maxConns int
+
+	mu    sync.Mutex
+	hosts map[string]*bucket
+	conns map[string]chan struct{}
+}
+
+// newLimiter 根据配置创建限速器，没有任何限制时返回 nil
+func newLimiter(opts Options) *limiter {
+	if opts.RateLimit <= 0 && opts.HostRateLimit <= 0 && opts.Jitter <= 0 && opts.MaxConnsPerHost <= 0 {
+		return nil
+	}
+	l := &limiter{
+		hostRate: opts.HostRateLimit,
+		jitter:   opts.Jitter,
+		maxConns: opts.MaxConnsPerHost,
+		hosts:    make(map[string]*bucket),
+		conns:    make(map[string]chan struct{}),
+	}
+	if opts.RateLimit > 0 {
+		l.global = newBucket(opts.RateLimit)
+	}
+	return l
+}
+
+// hostBucket 返回主机的令牌桶
+func (l *limiter) hostBucket(host string) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.hosts[host]
+	if !ok {
+		b = newBucket(l.hostRate)
+		l.hosts[host] = b
+	}
+	return b
+}
+
+// hostSlots 返回限制主机并发请求数的信号量
+func (l *limiter) hostSlots(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok := l.conns[host]
+	if !ok {
+		c = make(chan struct{}, l.maxConns)
+		l.conns[host] = c
+	}
+	return c
+}
+
+// limitTransport 在发送请求前按配置限速
+type limitTransport struct {
+	base    http.RoundTripper
+	limiter *limiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.limiter
+	ctx := req.Context()
+	host := req.URL.Host
+
+	// 先占用主机的并发名额，再等待令牌，避免排队时消耗令牌
+	var release func()
+	if l.maxConns > 0 {
+		slots := l.hostSlots(host)
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			closeBody(req)
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-slots }) }
+	}
+
+	err := t.wait(ctx, host)
+	if err != nil {
+		if release != nil {
+			release()
+		}
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if release != nil {
+		if err != nil {
+			release()
+		} else {
+			// 读完或关闭响应内容后才释放并发名额
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		}
+	}
+	return resp, err
+}
+
+// wait 依次等待全局令牌、主机令牌和随机延迟
+func (t *limitTransport) wait(ctx context.Context, host string) error {
+	l := t.limiter
+	if l.global != nil {
+		if err := l.global.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.hostRate > 0 {
+		if err := l.hostBucket(host).wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.jitter > 0 {
+		return sleep(ctx, time.Duration(rand.Int63n(int64(l.jitter))))
+	}
+	return nil
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// releaseBody 在响应内容读完或关闭时释放并发名额
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	b.release()
+	return b.ReadCloser.Close()
+}
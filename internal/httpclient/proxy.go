@@ -0,0 +1,238 @@
+package httpclient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 连续失败达到该次数的代理被认为不可用
+const maxProxyFailures = 3
+
+// 支持的代理类型
+var proxySchemes = map[string]bool{
+	"http":    true,
+	"https":   true,
+	"socks5":  true,
+	"socks5h": true,
+	"socks4":  true,
+	"socks4a": true,
+}
+
+// ParseProxy 解析并检查代理地址，没有协议时默认为 HTTP 代理
+func ParseProxy(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("代理设置错误: %v", err)
+	}
+	if !proxySchemes[u.Scheme] {
+		return nil, fmt.Errorf("不支持的代理类型: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("代理设置错误: 缺少代理地址")
+	}
+	return u, nil
+}
+
+// LoadProxyFile 读取代理列表文件，每行一个代理地址，# 开头的行为注释
+func LoadProxyFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("打开代理列表失败: %v", err)
+	}
+	defer f.Close()
+
+	var proxies []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := ParseProxy(line); err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取代理列表失败: %v", err)
+	}
+	return proxies, nil
+}
+
+// ProxyPool 轮换使用多个代理，并移除连续连接失败的代理
+type ProxyPool struct {
+	mu       sync.Mutex
+	proxies  []string
+	failures map[string]int
+	dead     map[string]bool
+	next     int
+}
+
+// NewProxyPool 创建 ProxyPool 实例
+func NewProxyPool(proxies []string) (*ProxyPool, error) {
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("代理列表为空")
+	}
+	for _, p := range proxies {
+		if _, err := ParseProxy(p); err != nil {
+			return nil, err
+		}
+	}
+	return &ProxyPool{
+		proxies:  proxies,
+		failures: make(map[string]int),
+		dead:     make(map[string]bool),
+	}, nil
+}
+
+// Next 按顺序返回下一个可用的代理
+func (p *ProxyPool) Next() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := 0; i < len(p.proxies); i++ {
+		proxy := p.proxies[p.next%len(p.proxies)]
+		p.next++
+		if !p.dead[proxy] {
+			return proxy, nil
+		}
+	}
+	return "", fmt.Errorf("没有可用的代理")
+}
+
+// Alive 返回可用的代理
+func (p *ProxyPool) Alive() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var alive []string
+	for _, proxy := range p.proxies {
+		if !p.dead[proxy] {
+			alive = append(alive, proxy)
+		}
+	}
+	return alive
+}
+
+// IsDead 判断代理是否已被移除
+func (p *ProxyPool) IsDead(proxy string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dead[proxy]
+}
+
+// Remove 移除代理
+func (p *ProxyPool) Remove(proxy string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dead[proxy] = true
+}
+
+// report 记录一次请求的结果，只有连接代理失败才计入失败次数
+func (p *ProxyPool) report(proxy string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil || !isProxyError(err) {
+		p.failures[proxy] = 0
+		return
+	}
+	p.failures[proxy]++
+	if p.failures[proxy] >= maxProxyFailures {
+		p.dead[proxy] = true
+	}
+}
+
+// isProxyError 判断是否为连接代理失败，目标站点返回的错误不计入
+func isProxyError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial" || opErr.Op == "proxyconnect" || opErr.Op == "socks connect"
+	}
+	return false
+}
+
+// Check 通过每个代理请求 checkURL，移除无法连接的代理，返回可用代理的数量
+// 代理能返回任意 HTTP 响应即认为可用
+func (p *ProxyPool) Check(checkURL string, concurrency int) int {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, proxy := range p.proxies {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(proxy string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if !checkProxy(proxy, checkURL) {
+				p.Remove(proxy)
+			}
+		}(proxy)
+	}
+	wg.Wait()
+	return len(p.Alive())
+}
+
+// checkProxy 通过代理发送一个 HEAD 请求
+func checkProxy(proxy string, checkURL string) bool {
+	mu.Lock()
+	t, err := transportFor(proxy)
+	timeout := options.ConnectTimeout + options.ReadTimeout
+	mu.Unlock()
+	if err != nil {
+		return false
+	}
+
+	client := &http.Client{
+		Transport: t,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Head(checkURL)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// rotatingTransport 从代理池中选择代理发送请求
+// sticky 不为空时固定使用该代理，直到它被移除
+type rotatingTransport struct {
+	pool   *ProxyPool
+	sticky string
+}
+
+func (t *rotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxy := t.sticky
+	if proxy == "" || t.pool.IsDead(proxy) {
+		var err error
+		if proxy, err = t.pool.Next(); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+	}
+
+	mu.Lock()
+	transport, err := transportFor(proxy)
+	mu.Unlock()
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := transport.RoundTrip(req)
+	t.pool.report(proxy, err)
+	return resp, err
+}
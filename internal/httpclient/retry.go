@@ -0,0 +1,330 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 重试的默认配置
+const (
+	DefaultRetries         = 2
+	DefaultRetryBackoff    = 500 * time.Millisecond
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// 服务器要求的 Retry-After 超过该时间时不再等待
+const maxRetryAfter = 5 * time.Minute
+
+// Stats 记录一个主机的请求统计
+type Stats struct {
+	Requests int // 请求数量，不包括重试
+	Retries  int // 重试次数
+	Failures int // 重试后仍然失败的请求数量
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = make(map[string]*Stats)
+)
+
+// HostStats 返回URL所在主机的请求统计，同一主机的多个目标共用统计
+func HostStats(targetURL string) Stats {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return Stats{}
+	}
+
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	if s, ok := stats[u.Host]; ok {
+		return *s
+	}
+	return Stats{}
+}
+
+// record 更新主机的请求统计
+func record(host string, update func(s *Stats)) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	s, ok := stats[host]
+	if !ok {
+		s = &Stats{}
+		stats[host] = s
+	}
+	update(s)
+}
+
+// resetStats 清空请求统计
+func resetStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	stats = make(map[string]*Stats)
+}
+
+// retryTransport 在网络错误和 429、502、503、504 响应时按指数退避重试
+type retryTransport struct {
+	base       http.RoundTripper
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	record(host, func(s *Stats) { s.Requests++ })
+
+	// 请求内容无法重新读取时不重试
+	retries := t.retries
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if !shouldRetry(resp, err) {
+			if err == nil && attempt < retries && resumable(req, resp) {
+				resp.Body = newRetryBody(t, req, resp, attempt, retries)
+			}
+			return resp, err
+		}
+
+		// 重试次数用完、请求已取消或 Retry-After 要求等待的时间过长时，直接返回该结果
+		delay := t.delay(attempt, resp)
+		if attempt >= retries || req.Context().Err() != nil || delay < 0 {
+			if retries > 0 {
+				record(host, func(s *Stats) { s.Failures++ })
+			}
+			return resp, err
+		}
+		if resp != nil {
+			// 读完响应内容以便复用连接
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		record(host, func(s *Stats) { s.Retries++ })
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// resumable 判断读取响应内容时连接中断能否重新请求
+func resumable(req *http.Request, resp *http.Response) bool {
+	return req.Method == http.MethodGet && resp.StatusCode == http.StatusOK &&
+		(req.Body == nil || req.Body == http.NoBody)
+}
+
+// retryBody 在读取响应内容时连接中断的情况下重新请求，并从中断的位置继续读取
+// 服务器支持 Range 时只请求剩余的部分，否则重新下载并跳过已读取的部分
+type retryBody struct {
+	t       *retryTransport
+	req     *http.Request
+	body    io.ReadCloser
+	read    int64
+	attempt int
+	retries int
+	// 自动解压的响应无法按 Range 继续读取
+	uncompressed bool
+	// If-Range 的值，确保继续读取的是同一个文件
+	validator string
+}
+
+func newRetryBody(t *retryTransport, req *http.Request, resp *http.Response, attempt int, retries int) *retryBody {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	return &retryBody{
+		t:            t,
+		req:          req,
+		body:         resp.Body,
+		attempt:      attempt,
+		retries:      retries,
+		uncompressed: resp.Uncompressed,
+		validator:    validator,
+	}
+}
+
+func (b *retryBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.body.Read(p)
+		b.read += int64(n)
+		if err == nil || err == io.EOF || !retryableError(err) || b.req.Context().Err() != nil {
+			return n, err
+		}
+		if resumeErr := b.resume(); resumeErr != nil {
+			record(b.req.URL.Host, func(s *Stats) { s.Failures++ })
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (b *retryBody) Close() error {
+	return b.body.Close()
+}
+
+// resume 重新请求文件，成功时替换 body 并定位到已读取的位置
+func (b *retryBody) resume() error {
+	b.body.Close()
+	for b.attempt < b.retries {
+		if err := sleep(b.req.Context(), b.t.delay(b.attempt, nil)); err != nil {
+			return err
+		}
+		b.attempt++
+		record(b.req.URL.Host, func(s *Stats) { s.Retries++ })
+
+		r := b.req.Clone(b.req.Context())
+		if b.read > 0 && !b.uncompressed {
+			r.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.read))
+			if b.validator != "" {
+				r.Header.Set("If-Range", b.validator)
+			}
+		}
+		resp, err := b.t.base.RoundTrip(r)
+		if err != nil {
+			if retryableError(err) {
+				continue
+			}
+			return err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusPartialContent && rangeStart(resp) == b.read:
+			b.body = resp.Body
+			return nil
+		case resp.StatusCode == http.StatusOK:
+			// 服务器忽略了 Range 或文件已变化时重新下载，跳过已读取的部分
+			if _, err := io.CopyN(io.Discard, resp.Body, b.read); err != nil {
+				resp.Body.Close()
+				if retryableError(err) {
+					continue
+				}
+				return err
+			}
+			b.body = resp.Body
+			return nil
+		}
+
+		resp.Body.Close()
+		if !shouldRetry(resp, nil) {
+			return fmt.Errorf("继续下载失败: %d", resp.StatusCode)
+		}
+	}
+	return fmt.Errorf("重试次数已用完")
+}
+
+// rangeStart 返回 Content-Range 中的起始位置，无法解析时返回 -1
+func rangeStart(resp *http.Response) int64 {
+	value, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// delay 计算第 attempt 次重试前的等待时间，服务器指定了 Retry-After 时优先使用
+// 返回负数表示服务器要求等待的时间超过上限
+func (t *retryTransport) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if d > maxRetryAfter {
+				return -1
+			}
+			return d
+		}
+	}
+
+	d := t.backoff << attempt
+	if d <= 0 || (t.maxBackoff > 0 && d > t.maxBackoff) {
+		d = t.maxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// 在 [d/2, d) 之间随机，避免多个线程同时重试
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter 解析秒数或 HTTP 日期格式的 Retry-After
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(value); err == nil {
+		if sec < 0 {
+			return 0, false
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// shouldRetry 判断请求结果是否需要重试
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return retryableError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableError 判断是否为可能恢复的网络错误，证书错误等每次都会失败的错误不重试
+func retryableError(err error) bool {
+	var certErr *tls.CertificateVerificationError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuth) || errors.As(err, &hostErr) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,128 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var content = bytes.Repeat([]byte("0123456789"), 1000)
+
+// cutServer 在前 cuts 次请求中只发送一半内容后断开连接
+// ranges 为 true 时按 Range 请求头返回剩余部分
+func cutServer(t *testing.T, cuts int32, ranges bool) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if n <= cuts {
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nETag: \"v1\"\r\n\r\n", len(content))
+			buf.Write(content[:len(content)/2])
+			buf.Flush()
+			conn.Close()
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		if start, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok && ranges && r.Header.Get("If-Range") == `"v1"` {
+			var from int
+			fmt.Sscanf(start, "%d-", &from)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[from:])
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func retryClient(t *testing.T, retries int) *http.Client {
+	opts := DefaultOptions()
+	opts.Retries = retries
+	opts.RetryBackoff = time.Millisecond
+	opts.RetryMaxBackoff = time.Millisecond
+	if err := SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetOptions(DefaultOptions()) })
+
+	client, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestRetryBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		cuts     int32
+		ranges   bool
+		retries  int
+		ok       bool
+		requests int32
+		stats    Stats
+	}{
+		{"resume with range", 1, true, 2, true, 2, Stats{Requests: 1, Retries: 1}},
+		{"restart without range", 1, false, 2, true, 2, Stats{Requests: 1, Retries: 1}},
+		{"cut twice", 2, true, 2, true, 3, Stats{Requests: 1, Retries: 2}},
+		{"retries exhausted", 3, true, 2, false, 3, Stats{Requests: 1, Retries: 2, Failures: 1}},
+		{"no retries", 1, true, 0, false, 1, Stats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := cutServer(t, tt.cuts, tt.ranges)
+			client := retryClient(t, tt.retries)
+
+			resp, err := client.Get(srv.URL + "/file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if tt.ok {
+				if err != nil || !bytes.Equal(data, content) {
+					t.Fatalf("read %d bytes, err %v; want full content", len(data), err)
+				}
+			} else if err == nil {
+				t.Fatal("expected read error")
+			}
+			if got := atomic.LoadInt32(requests); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+			if got := HostStats(srv.URL); got != tt.stats {
+				t.Errorf("stats = %+v, want %+v", got, tt.stats)
+			}
+		})
+	}
+}
+
+func TestRangeStart(t *testing.T) {
+	tests := map[string]int64{
+		"bytes 100-199/200": 100,
+		"bytes 0-9/*":       0,
+		"bytes */200":       -1,
+		"":                  -1,
+		"items 1-2/3":       -1,
+	}
+	for value, want := range tests {
+		resp := &http.Response{Header: http.Header{"Content-Range": {value}}}
+		if got := rangeStart(resp); got != want {
+			t.Errorf("rangeStart(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
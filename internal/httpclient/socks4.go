@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// socks4RejectError 表示代理正常响应但拒绝连接目标 (响应码不是 90)
+type socks4RejectError struct {
+	code byte
+}
+
+func (e *socks4RejectError) Error() string {
+	return fmt.Sprintf("SOCKS4 代理拒绝连接目标: %d", e.code)
+}
+
+// socks4Dialer 通过 SOCKS4 或 SOCKS4a 代理建立连接，net/http 只内置了 SOCKS5 的支持
+type socks4Dialer struct {
+	proxyAddr string
+	userID    string
+	// remoteDNS 为 true 时 (SOCKS4a) 由代理解析域名
+	remoteDNS bool
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+	timeout   time.Duration
+}
+
+func newSocks4Dialer(u *url.URL, dial func(ctx context.Context, network, addr string) (net.Conn, error), timeout time.Duration) *socks4Dialer {
+	return &socks4Dialer{
+		proxyAddr: u.Host,
+		userID:    u.User.Username(),
+		remoteDNS: u.Scheme == "socks4a",
+		dial:      dial,
+		timeout:   timeout,
+	}
+}
+
+// DialContext 连接代理并发送 CONNECT 请求
+func (d *socks4Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("无效的端口: %s", portStr)
+	}
+
+	// SOCKS4 只支持 IPv4 地址，需要在本地解析域名
+	ip := net.ParseIP(host).To4()
+	if ip == nil && !d.remoteDNS {
+		addrs, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("解析域名失败: %s", host)
+		}
+		ip = addrs[0].To4()
+	}
+
+	req := []byte{4, 1, 0, 0}
+	binary.BigEndian.PutUint16(req[2:], uint16(port))
+	if ip != nil {
+		req = append(req, ip...)
+	} else {
+		// SOCKS4a 使用 0.0.0.x 表示目标地址为后面附加的域名
+		req = append(req, 0, 0, 0, 1)
+	}
+	req = append(req, d.userID...)
+	req = append(req, 0)
+	if ip == nil {
+		req = append(req, host...)
+		req = append(req, 0)
+	}
+
+	conn, err := d.dial(ctx, "tcp", d.proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if d.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(d.timeout))
+	}
+
+	if err := d.handshake(conn, req); err != nil {
+		conn.Close()
+		// 代理拒绝连接目标时代理本身是可用的，不能作为连接代理失败
+		var reject *socks4RejectError
+		if errors.As(err, &reject) {
+			return nil, err
+		}
+		return nil, &net.OpError{Op: "proxyconnect", Net: "tcp", Err: err}
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// handshake 发送请求并检查代理的响应
+func (d *socks4Dialer) handshake(conn net.Conn, req []byte) error {
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	var resp [8]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return err
+	}
+	if resp[0] != 0 {
+		return fmt.Errorf("无效的 SOCKS4 响应")
+	}
+	if resp[1] != 90 {
+		return &socks4RejectError{code: resp[1]}
+	}
+	return nil
+}
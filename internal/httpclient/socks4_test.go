@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// socks4Server 模拟 SOCKS4 代理，记录收到的请求并按 reply 响应
+// reply 为 90 时把连接转发到 target，其他值表示拒绝连接目标，为 0 时不响应直接断开
+type socks4Server struct {
+	ln     net.Listener
+	reply  byte
+	target string
+
+	mu   sync.Mutex
+	reqs [][]byte
+}
+
+func newSocks4Server(t *testing.T, reply byte, target string) *socks4Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &socks4Server{ln: ln, reply: reply, target: target}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks4Server) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	req := make([]byte, 8)
+	if _, err := io.ReadFull(r, req); err != nil {
+		return
+	}
+	userID, err := r.ReadBytes(0)
+	if err != nil {
+		return
+	}
+	req = append(req, userID...)
+	// SOCKS4a 的 0.0.0.x 地址后附加域名
+	if bytes.Equal(req[4:7], []byte{0, 0, 0}) && req[7] != 0 {
+		host, err := r.ReadBytes(0)
+		if err != nil {
+			return
+		}
+		req = append(req, host...)
+	}
+	s.mu.Lock()
+	s.reqs = append(s.reqs, req)
+	s.mu.Unlock()
+
+	if s.reply == 0 {
+		return
+	}
+	conn.Write([]byte{0, s.reply, 0, 0, 0, 0, 0, 0})
+	if s.reply != 90 {
+		return
+	}
+	upstream, err := net.Dial("tcp", s.target)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	go io.Copy(upstream, r)
+	io.Copy(conn, upstream)
+}
+
+func (s *socks4Server) requests() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reqs
+}
+
+func (s *socks4Server) proxy(scheme string) string {
+	return scheme + "://user@" + s.ln.Addr().String()
+}
+
+func okServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSocks4Dialer(t *testing.T) {
+	target := okServer(t)
+	tests := []struct {
+		name   string
+		scheme string
+		url    string
+		want   []byte
+	}{
+		{"SOCKS4 IPv4 地址", "socks4", "http://127.0.0.1:8080/",
+			[]byte{4, 1, 0x1f, 0x90, 127, 0, 0, 1, 'u', 's', 'e', 'r', 0}},
+		{"SOCKS4a 由代理解析域名", "socks4a", "http://example.test:8080/",
+			append([]byte{4, 1, 0x1f, 0x90, 0, 0, 0, 1, 'u', 's', 'e', 'r', 0}, "example.test\x00"...)},
+	}
+	for _, tt := range tests {
+		srv := newSocks4Server(t, 90, target.Listener.Addr().String())
+		transport, err := newTransport(srv.proxy(tt.scheme), DefaultOptions(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(tt.url)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		transport.CloseIdleConnections()
+		if string(body) != "ok" {
+			t.Errorf("%s: body = %q", tt.name, body)
+		}
+		if reqs := srv.requests(); len(reqs) != 1 || !bytes.Equal(reqs[0], tt.want) {
+			t.Errorf("%s: 请求 = %v, want %v", tt.name, reqs, tt.want)
+		}
+	}
+}
+
+func TestSocks4Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  byte
+		reject bool
+	}{
+		{"拒绝连接目标", 91, true},
+		{"代理未响应", 0, false},
+	}
+	for _, tt := range tests {
+		srv := newSocks4Server(t, tt.reply, "")
+		transport, err := newTransport(srv.proxy("socks4"), DefaultOptions(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = (&http.Client{Transport: transport}).Get("http://127.0.0.1:8080/")
+		if err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+			continue
+		}
+		var reject *socks4RejectError
+		if errors.As(err, &reject) != tt.reject {
+			t.Errorf("%s: err = %v, 拒绝错误 = %v", tt.name, err, !tt.reject)
+		}
+		if isProxyError(err) == tt.reject {
+			t.Errorf("%s: isProxyError(%v) = %v", tt.name, err, !tt.reject)
+		}
+	}
+}
+
+func TestProxyPoolRotation(t *testing.T) {
+	target := okServer(t)
+	good := newSocks4Server(t, 90, target.Listener.Addr().String())
+	reject := newSocks4Server(t, 91, "")
+	down := newSocks4Server(t, 90, "")
+	down.ln.Close()
+
+	proxies := []string{good.proxy("socks4"), reject.proxy("socks4"), down.proxy("socks4")}
+	pool, err := NewProxyPool(proxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultOptions()
+	opts.Retries = 0
+	opts.ProxyPool = pool
+	opts.RotatePerRequest = true
+	if err := SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetOptions(DefaultOptions()) })
+
+	client, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每个代理各使用 maxProxyFailures 次，之后只有无法连接的代理被移除
+	var ok int
+	for i := 0; i < len(proxies)*maxProxyFailures; i++ {
+		resp, err := client.Get(target.URL)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		ok++
+	}
+	if ok != maxProxyFailures {
+		t.Errorf("成功请求 = %d, want %d", ok, maxProxyFailures)
+	}
+	if !pool.IsDead(proxies[2]) {
+		t.Error("无法连接的代理应被移除")
+	}
+	if alive := pool.Alive(); len(alive) != 2 || alive[0] != proxies[0] || alive[1] != proxies[1] {
+		t.Errorf("Alive = %q", alive)
+	}
+	if len(reject.requests()) != maxProxyFailures {
+		t.Errorf("拒绝连接的代理收到 %d 个请求, want %d", len(reject.requests()), maxProxyFailures)
+	}
+
+	// 移除后轮换跳过该代理
+	for i := 0; i < 4; i++ {
+		if proxy, _ := pool.Next(); proxy == proxies[2] {
+			t.Fatal("Next 返回了已移除的代理")
+		}
+	}
+}
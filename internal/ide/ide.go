@@ -0,0 +1,197 @@
+// Package ide 实现 IDE 项目配置 (.idea、.vscode、nbproject) 泄露的下载
+package ide
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// 提取到的敏感信息保存到输出目录下的该文件中
+const findingsFile = "ide-findings.txt"
+
+// 根据配置文件最多下载的项目文件数量
+const maxProjectFiles = 10000
+
+// IdeDumper 下载 IDE 项目配置，并根据其中记录的路径下载项目文件
+type IdeDumper struct {
+	dumper.BaseDumper
+	// Filter 下载项目文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录配置中引用的项目文件路径
+	Paths *dumper.PathSet
+}
+
+// NewIdeDumper 创建 IdeDumper 实例
+func NewIdeDumper() *IdeDumper {
+	return &IdeDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "ide",
+			Description: "下载 IDE 项目配置及其记录的文件",
+		},
+	}
+}
+
+// 常见的 IDE 项目配置文件
+var ideFiles = []string{
+	".idea/workspace.xml",
+	".idea/modules.xml",
+	".idea/misc.xml",
+	".idea/vcs.xml",
+	".idea/dataSources.xml",
+	".idea/dataSources.local.xml",
+	".idea/deployment.xml",
+	".idea/webServers.xml",
+	".vscode/settings.json",
+	".vscode/sftp.json",
+	".vscode/launch.json",
+	"nbproject/project.properties",
+	"nbproject/project.xml",
+	"nbproject/private/private.properties",
+}
+
+// Check 检查目标是否存在 IDE 配置泄露
+func (d *IdeDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+	for _, file := range []string{".idea/workspace.xml", ".vscode/settings.json", "nbproject/project.properties"} {
+		data, ok := dumper.Fetch(client, baseline, targetURL+file, 0, nil)
+		if ok && looksLikeConfig(file, data) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Execute 执行下载操作
+func (d *IdeDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	var findings []Finding
+	var projectPaths []string
+	seen := make(map[string]bool)
+	addPaths := func(paths []string) {
+		for _, p := range paths {
+			if !seen[p] {
+				seen[p] = true
+				projectPaths = append(projectPaths, p)
+			}
+		}
+	}
+
+	found := false
+	for _, file := range ideFiles {
+		data, ok := dumper.Fetch(client, baseline, targetURL+file, 0, progressCb)
+		if !ok || !looksLikeConfig(file, data) {
+			continue
+		}
+		if !found {
+			// 创建输出目录
+			if err := os.MkdirAll(outdir, 0755); err != nil {
+				return fmt.Errorf("创建输出目录失败: %v", err)
+			}
+			found = true
+		}
+		seen[file] = true
+		dumper.SaveData(outdir, file, data, targetURL+file, progressCb)
+
+		switch path.Ext(file) {
+		case ".xml":
+			addPaths(ProjectPaths(data))
+			findings = append(findings, ParseDataSources(file, data)...)
+			findings = append(findings, ParseWebServers(file, data)...)
+		case ".json":
+			if f, err := ParseJSON(file, data); err == nil {
+				findings = append(findings, f...)
+			}
+		case ".properties":
+			findings = append(findings, ParseProperties(file, data)...)
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	if len(findings) > 0 {
+		var buf bytes.Buffer
+		for _, f := range findings {
+			buf.WriteString(f.String())
+			buf.WriteByte('\n')
+		}
+		if localPath, err := dumper.SaveFile(outdir, findingsFile, &buf); err == nil && progressCb != nil {
+			progressCb(targetURL+findingsFile, http.StatusOK, localPath)
+		}
+	}
+
+	// 下载配置中引用的项目文件，.iml 等模块文件中的路径也一并下载
+	// 每一轮并发下载已知的文件，.iml 中新发现的路径在下一轮下载
+	pool := dumper.NewPool(workers)
+	var mu sync.Mutex
+	for start := 0; start < len(projectPaths) && start < maxProjectFiles; {
+		end := min(len(projectPaths), maxProjectFiles)
+		for _, name := range projectPaths[start:end] {
+			// 没有扩展名的一般是源码目录，不作为文件下载
+			if path.Ext(name) == "" {
+				continue
+			}
+			d.Paths.Add(name)
+			if !d.Filter.Match(filter.Entry{Path: name, Size: -1}) {
+				continue
+			}
+			pool.Go(func() {
+				data, ok := dumper.Download(client, baseline, d.Filter, targetURL+dumper.EscapePath(name), outdir, name, 0, progressCb)
+				if ok && strings.HasSuffix(name, ".iml") {
+					mu.Lock()
+					addPaths(ProjectPaths(data))
+					mu.Unlock()
+				}
+			})
+		}
+		pool.Wait()
+		start = end
+	}
+
+	return nil
+}
+
+// looksLikeConfig 根据文件类型粗略检查内容，排除返回 HTML 的自定义404页面
+func looksLikeConfig(file string, data []byte) bool {
+	data = bytes.TrimSpace(data)
+	switch path.Ext(file) {
+	case ".xml":
+		return bytes.Contains(data, []byte("<project")) || bytes.Contains(data, []byte("<module"))
+	case ".json":
+		return bytes.HasPrefix(data, []byte("{")) || bytes.HasPrefix(data, []byte("/"))
+	default:
+		return len(data) > 0 && !bytes.HasPrefix(data, []byte("<"))
+	}
+}
+
+// Validate 验证URL是否有效
+func (d *IdeDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,266 @@
+package ide
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Finding 表示从 IDE 配置中提取的一条敏感信息
+type Finding struct {
+	Source string // 来源文件
+	Kind   string // 类型: database, deployment, secret
+	Detail string // 具体内容
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Kind, f.Source, f.Detail)
+}
+
+// JetBrains 配置中以 $PROJECT_DIR$ 开头的项目文件路径
+var projectDirRe = regexp.MustCompile(`\$PROJECT_DIR\$/([^"'<>\s]+)`)
+
+// 键名中包含这些词时认为是敏感配置
+var secretKeyRe = regexp.MustCompile(`(?i)(pass(word|wd|phrase)?|secret|token|api[_.-]?key|private[_.-]?key|credential|user(name)?|login|host|server|url)$`)
+
+// ProjectPaths 提取 JetBrains 配置文件中引用的项目文件路径
+func ProjectPaths(data []byte) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range projectDirRe.FindAllSubmatch(data, -1) {
+		p := strings.TrimSuffix(xmlUnescape(string(m[1])), "/")
+		if p == "" || seen[p] || strings.ContainsAny(p, "$*?") {
+			continue
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func xmlUnescape(s string) string {
+	var out string
+	if err := xml.Unmarshal([]byte("<a>"+s+"</a>"), &out); err != nil {
+		return s
+	}
+	return out
+}
+
+// ParseDataSources 解析 dataSources.xml 和 dataSources.local.xml 中的数据库连接
+func ParseDataSources(source string, data []byte) []Finding {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var findings []Finding
+	var cur map[string]string
+	var field string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "data-source" {
+				cur = map[string]string{}
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						cur["name"] = attr.Value
+					}
+				}
+				continue
+			}
+			field = t.Name.Local
+		case xml.CharData:
+			if cur != nil && field != "" {
+				if v := strings.TrimSpace(string(t)); v != "" {
+					cur[field] = v
+				}
+			}
+		case xml.EndElement:
+			field = ""
+			if t.Name.Local == "data-source" && cur != nil {
+				if f := dataSourceFinding(source, cur); f != nil {
+					findings = append(findings, *f)
+				}
+				cur = nil
+			}
+		}
+	}
+	return findings
+}
+
+func dataSourceFinding(source string, fields map[string]string) *Finding {
+	var parts []string
+	for _, key := range []string{"name", "driver-ref", "jdbc-url", "user-name", "password"} {
+		if v := fields[key]; v != "" {
+			parts = append(parts, key+"="+v)
+		}
+	}
+	if fields["jdbc-url"] == "" && fields["user-name"] == "" {
+		return nil
+	}
+	return &Finding{Source: source, Kind: "database", Detail: strings.Join(parts, " ")}
+}
+
+// ParseWebServers 解析 webServers.xml 和 deployment.xml 中的部署服务器
+func ParseWebServers(source string, data []byte) []Finding {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var findings []Finding
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "webServer", "fileTransfer", "paths", "mapping":
+		default:
+			continue
+		}
+
+		var parts []string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "name", "url", "host", "port", "accessType", "username", "rootFolder", "deploy", "web":
+				if attr.Value != "" {
+					parts = append(parts, attr.Name.Local+"="+attr.Value)
+				}
+			}
+		}
+		if len(parts) > 0 {
+			findings = append(findings, Finding{Source: source, Kind: "deployment", Detail: start.Name.Local + " " + strings.Join(parts, " ")})
+		}
+	}
+	return findings
+}
+
+// ParseJSON 解析 .vscode 下的 JSON 配置，返回敏感键值
+// sftp.json 中的每个连接作为一条部署信息，其他键名匹配时作为敏感信息
+func ParseJSON(source string, data []byte) ([]Finding, error) {
+	var v interface{}
+	if err := json.Unmarshal(stripJSONComments(data), &v); err != nil {
+		return nil, fmt.Errorf("解析 JSON 失败: %v", err)
+	}
+
+	kind := "secret"
+	if strings.HasSuffix(source, "sftp.json") {
+		kind = "deployment"
+	}
+
+	var findings []Finding
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			var parts []string
+			for _, k := range keys {
+				switch val := t[k].(type) {
+				case map[string]interface{}, []interface{}:
+					walk(joinKey(prefix, k), val)
+				default:
+					if secretKeyRe.MatchString(k) && fmt.Sprint(val) != "" {
+						parts = append(parts, fmt.Sprintf("%s=%v", k, val))
+					}
+				}
+			}
+			if len(parts) > 0 {
+				detail := strings.Join(parts, " ")
+				if prefix != "" {
+					detail = prefix + ": " + detail
+				}
+				findings = append(findings, Finding{Source: source, Kind: kind, Detail: detail})
+			}
+		case []interface{}:
+			for i, item := range t {
+				walk(fmt.Sprintf("%s[%d]", prefix, i), item)
+			}
+		}
+	}
+	walk("", v)
+	return findings, nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// stripJSONComments 去掉 VS Code 配置中允许的 // 和 /* */ 注释
+func stripJSONComments(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out.Bytes()
+			}
+			i += end + 3
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
+// ParseProperties 解析 NetBeans 的 .properties 文件，返回敏感键值
+func ParseProperties(source string, data []byte) []Finding {
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		if value == "" || !secretKeyRe.MatchString(key) {
+			continue
+		}
+		findings = append(findings, Finding{Source: source, Kind: "secret", Detail: key + "=" + value})
+	}
+	return findings
+}
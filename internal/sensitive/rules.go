@@ -0,0 +1,172 @@
+package sensitive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Rule 表示一个待探测的敏感文件及其内容签名
+type Rule struct {
+	Path      string         // 相对于目标URL的路径
+	Signature *regexp.Regexp // 内容签名，为 nil 时只排除 HTML 错误页面
+	Magic     []byte         // 文件头，非空时内容必须以此开头
+}
+
+// 内置的签名
+var (
+	envSig      = regexp.MustCompile(`(?m)^\s*(export\s+)?[A-Za-z_][A-Za-z0-9_.]*\s*=`)
+	jsonSig     = regexp.MustCompile(`^\s*[{\[]`)
+	composerSig = regexp.MustCompile(`"(require|require-dev|autoload|packages|name)"\s*:`)
+	packageSig  = regexp.MustCompile(`"(dependencies|devDependencies|scripts|lockfileVersion|name)"\s*:`)
+	yarnSig     = regexp.MustCompile(`(?m)^(# yarn lockfile|__metadata:)`)
+	dockerSig   = regexp.MustCompile(`(?mi)^\s*FROM\s+\S+`)
+	composeSig  = regexp.MustCompile(`(?m)^(services|version|volumes|networks)\s*:`)
+	npmrcSig    = regexp.MustCompile(`(?m)(_auth(Token)?|registry|always-auth|email)\s*=`)
+	htpasswdSig = regexp.MustCompile(`(?m)^[^:\s<>]+:(\$apr1\$|\$2[aby]?\$|\{SHA\}|\$[156]\$|[./0-9A-Za-z]{13}\s*$)`)
+	htaccessSig = regexp.MustCompile(`(?mi)^\s*(RewriteEngine|RewriteRule|Deny|Allow|Order|AuthType|AuthUserFile|Options|DirectoryIndex|<IfModule|<Files)`)
+	webConfig   = regexp.MustCompile(`<configuration[\s>]`)
+	webXMLSig   = regexp.MustCompile(`<web-app[\s>]`)
+	phpinfoSig  = regexp.MustCompile(`(<title>phpinfo\(\)</title>|PHP Version \d)`)
+	propsSig    = regexp.MustCompile(`(?m)^\s*[A-Za-z][\w.-]*\s*[=:]`)
+	yamlSig     = regexp.MustCompile(`(?m)^[A-Za-z_][\w.-]*\s*:`)
+	credSig     = regexp.MustCompile(`(?m)^https?://[^:/\s]+:[^@\s]+@`)
+	awsSig      = regexp.MustCompile(`(?mi)^\s*aws_(access_key_id|secret_access_key)\s*=`)
+	keySig      = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	sqlSig      = regexp.MustCompile(`(?i)(CREATE TABLE|INSERT INTO|DROP TABLE|-- MySQL dump|PostgreSQL database dump)`)
+)
+
+// 二进制文件的文件头
+var (
+	sqliteMagic = []byte("SQLite format 3\x00")
+	zipMagic    = []byte("PK\x03\x04")
+	jksMagic    = []byte("\xfe\xed\xfe\xed")
+	pkcs12Magic = []byte("\x30\x82") // DER 编码的 SEQUENCE
+)
+
+// DefaultRules 返回内置的敏感文件列表
+func DefaultRules() []Rule {
+	return []Rule{
+		{Path: ".env", Signature: envSig},
+		{Path: ".env.local", Signature: envSig},
+		{Path: ".env.dev", Signature: envSig},
+		{Path: ".env.development", Signature: envSig},
+		{Path: ".env.prod", Signature: envSig},
+		{Path: ".env.production", Signature: envSig},
+		{Path: ".env.staging", Signature: envSig},
+		{Path: ".env.test", Signature: envSig},
+		{Path: ".env.backup", Signature: envSig},
+		{Path: ".env.bak", Signature: envSig},
+		{Path: ".env.old", Signature: envSig},
+		{Path: ".env.example", Signature: envSig},
+		{Path: "web.config", Signature: webConfig},
+		{Path: "WEB-INF/web.xml", Signature: webXMLSig},
+		{Path: "composer.json", Signature: composerSig},
+		{Path: "composer.lock", Signature: composerSig},
+		{Path: "package.json", Signature: packageSig},
+		{Path: "package-lock.json", Signature: packageSig},
+		{Path: "yarn.lock", Signature: yarnSig},
+		{Path: "Dockerfile", Signature: dockerSig},
+		{Path: "docker-compose.yml", Signature: composeSig},
+		{Path: "docker-compose.yaml", Signature: composeSig},
+		{Path: ".npmrc", Signature: npmrcSig},
+		{Path: ".htpasswd", Signature: htpasswdSig},
+		{Path: ".htaccess", Signature: htaccessSig},
+		{Path: "phpinfo.php", Signature: phpinfoSig},
+		{Path: "info.php", Signature: phpinfoSig},
+		{Path: "appsettings.json", Signature: jsonSig},
+		{Path: "config.json", Signature: jsonSig},
+		{Path: "application.properties", Signature: propsSig},
+		{Path: "application.yml", Signature: yamlSig},
+		{Path: "application.yaml", Signature: yamlSig},
+		{Path: ".git-credentials", Signature: credSig},
+		{Path: ".aws/credentials", Signature: awsSig},
+		{Path: "id_rsa", Signature: keySig},
+		{Path: ".ssh/id_rsa", Signature: keySig},
+		{Path: "backup.sql", Signature: sqlSig},
+		{Path: "dump.sql", Signature: sqlSig},
+		{Path: "database.sql", Signature: sqlSig},
+		{Path: "db.sqlite", Magic: sqliteMagic},
+		{Path: "db.sqlite3", Magic: sqliteMagic},
+		{Path: "database.sqlite", Magic: sqliteMagic},
+		{Path: "database.db", Magic: sqliteMagic},
+		{Path: "www.zip", Magic: zipMagic},
+		{Path: "web.zip", Magic: zipMagic},
+		{Path: "backup.zip", Magic: zipMagic},
+		{Path: "site.zip", Magic: zipMagic},
+		{Path: ".keystore", Magic: jksMagic},
+		{Path: "keystore.jks", Magic: jksMagic},
+		{Path: "keystore.p12", Magic: pkcs12Magic},
+	}
+}
+
+// LoadWordlist 从文件读取敏感文件列表
+// 每行一个路径，可在路径后用空白分隔内容签名的正则表达式，# 开头的行为注释
+func LoadWordlist(filename string) ([]Rule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取字典文件失败: %v", err)
+	}
+
+	var rules []Rule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := Rule{Path: strings.TrimPrefix(line, "/")}
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			rule.Path = strings.TrimPrefix(line[:i], "/")
+			sig, err := regexp.Compile(strings.TrimSpace(line[i:]))
+			if err != nil {
+				return nil, fmt.Errorf("字典第 %d 行签名错误: %v", lineNo, err)
+			}
+			rule.Signature = sig
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取字典文件失败: %v", err)
+	}
+	return rules, nil
+}
+
+// Match 检查内容是否符合规则的签名
+// 除了 .php 等页面外，返回 HTML 的内容都认为是自定义404页面
+func (r Rule) Match(data []byte) bool {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return false
+	}
+	if !isPage(r.Path) && looksLikeHTML(data) {
+		return false
+	}
+	if len(r.Magic) > 0 && !bytes.HasPrefix(data, r.Magic) {
+		return false
+	}
+	return r.Signature == nil || r.Signature.Match(data)
+}
+
+// isPage 判断路径是否为本身就会返回 HTML 的页面
+func isPage(p string) bool {
+	for _, ext := range []string{".html", ".htm", ".php", ".asp", ".aspx", ".jsp"} {
+		if strings.HasSuffix(strings.ToLower(p), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func looksLikeHTML(data []byte) bool {
+	head := bytes.ToLower(bytes.TrimSpace(data))
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html")) || bytes.Contains(head, []byte("<body"))
+}
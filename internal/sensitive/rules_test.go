@@ -0,0 +1,44 @@
+package sensitive
+
+import "testing"
+
+func TestRuleMatchMagic(t *testing.T) {
+	rules := make(map[string]Rule)
+	for _, r := range DefaultRules() {
+		rules[r.Path] = r
+	}
+
+	tests := []struct {
+		path string
+		data string
+		want bool
+	}{
+		{"db.sqlite", "SQLite format 3\x00\x10\x00", true},
+		{"db.sqlite", "<html><body>not found</body></html>", false},
+		{"db.sqlite", "SQLite format 2", false},
+		{"www.zip", "PK\x03\x04\x14\x00", true},
+		{"www.zip", "PK", false},
+		{".keystore", "\xfe\xed\xfe\xed\x00\x00\x00\x02", true},
+		{".keystore", "keystore", false},
+		{"keystore.p12", "\x30\x82\x0a\x00", true},
+		{".env", "APP_KEY=secret", true},
+	}
+	for _, tt := range tests {
+		rule, ok := rules[tt.path]
+		if !ok {
+			t.Fatalf("缺少内置规则 %s", tt.path)
+		}
+		if got := rule.Match([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: Match(%q) = %v, want %v", tt.path, tt.data, got, tt.want)
+		}
+	}
+}
+
+// 二进制规则都需要设置文件头，否则任何非 HTML 的内容都会被当作命中
+func TestBinaryRulesHaveMagic(t *testing.T) {
+	for _, r := range DefaultRules() {
+		if r.Signature == nil && len(r.Magic) == 0 {
+			t.Errorf("规则 %s 既没有内容签名也没有文件头", r.Path)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package sensitive 实现常见敏感配置文件的探测和下载
+package sensitive
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// SensitiveDumper 探测并下载常见的敏感配置文件
+type SensitiveDumper struct {
+	dumper.BaseDumper
+	// Rules 待探测的文件列表，默认为内置列表
+	Rules []Rule
+	// Filter 下载文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录找到的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewSensitiveDumper 创建 SensitiveDumper 实例
+func NewSensitiveDumper() *SensitiveDumper {
+	return &SensitiveDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "sensitive",
+			Description: "探测常见的敏感配置文件",
+		},
+		Rules: DefaultRules(),
+	}
+}
+
+// Check 检查目标是否存在任意一个敏感文件
+func (d *SensitiveDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+	for _, rule := range d.Rules {
+		fileURL := targetURL + dumper.EscapePath(rule.Path)
+		if data, ok := dumper.Fetch(client, baseline, fileURL, 0, nil); ok && rule.Match(data) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Execute 执行下载操作
+func (d *SensitiveDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	seen := make(map[string]bool)
+	for _, rule := range d.Rules {
+		if seen[rule.Path] {
+			continue
+		}
+		seen[rule.Path] = true
+
+		if !d.Filter.Match(filter.Entry{Path: rule.Path, Size: -1}) {
+			continue
+		}
+
+		// 内容还需要匹配规则的签名，排除返回200的其他页面
+		fileURL := targetURL + dumper.EscapePath(rule.Path)
+		pool.Go(func() {
+			if _, ok := dumper.DownloadCheck(client, baseline, d.Filter, fileURL, outdir, rule.Path, 0, rule.Match, progressCb); ok {
+				d.Paths.Add(rule.Path)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Validate 验证URL是否有效
+func (d *SensitiveDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package sensitive
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+)
+
+func TestExecute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.env":
+			w.Write([]byte("APP_KEY=base64:c2VjcmV0\nDB_PASSWORD=secret\n"))
+		case "/.env.local":
+			// 所有路径都返回同一个首页
+			w.Write([]byte("<!DOCTYPE html><html><head><title>Home</title></head><body>Welcome</body></html>"))
+		case "/www.zip":
+			w.Write([]byte("not a zip file"))
+		case "/db.sqlite":
+			w.Write([]byte("SQLite format 3\x00" + strings.Repeat("\x00", 200)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	rules := make(map[string]Rule)
+	for _, r := range DefaultRules() {
+		rules[r.Path] = r
+	}
+	d := NewSensitiveDumper()
+	d.Rules = []Rule{rules[".env"], rules[".env.local"], rules["www.zip"], rules["db.sqlite"], rules[".env"], {Path: "../evil.txt"}}
+	d.Filter = &filter.Filter{MaxSize: 100}
+	d.Paths = dumper.NewPathSet()
+
+	var mu sync.Mutex
+	var calls []string
+	cb := func(url string, status int, msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, fmt.Sprintf("%s %d %s", strings.TrimPrefix(url, srv.URL+"/"), status, msg))
+	}
+
+	outdir := t.TempDir()
+	if err := d.Execute(srv.URL, outdir, "", false, false, 4, cb); err != nil {
+		t.Fatal(err)
+	}
+
+	// 签名不匹配和错误页面不报告，超过 MaxSize 的文件被过滤
+	sort.Strings(calls)
+	want := []string{
+		"../evil.txt 0 非法路径",
+		".env 200 " + filepath.Join(outdir, ".env"),
+		"db.sqlite 0 已过滤",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("回调 = %q, want %q", calls, want)
+	}
+
+	entries, _ := os.ReadDir(outdir)
+	if len(entries) != 1 || entries[0].Name() != ".env" {
+		t.Errorf("保存了 %v", entries)
+	}
+	if paths := d.Paths.List(); len(paths) != 1 || paths[0] != ".env" {
+		t.Errorf("Paths = %q", paths)
+	}
+}
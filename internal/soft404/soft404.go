@@ -0,0 +1,214 @@
+// Package soft404 识别不存在的文件也返回200的错误页面 (soft 404) 和 WAF 拦截页面
+package soft404
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 校准和校验时读取的最大长度
+const maxBodySize = 1 << 20
+
+// 标题相同时允许的长度差，错误页面中可能包含请求路径、时间等变化的内容
+const (
+	minLengthTolerance = 64
+	lengthTolerance    = 0.05
+)
+
+var titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Fingerprint 记录一个错误页面的特征
+type Fingerprint struct {
+	Length int    // 去掉请求路径后的长度
+	Hash   string // 去掉请求路径后内容的 SHA-1
+	Title  string // HTML 标题
+}
+
+// Baseline 记录一个主机对不存在的路径返回的200页面
+// nil 的 Baseline 表示该主机正常返回404
+type Baseline struct {
+	Fingerprints []Fingerprint
+}
+
+// 校准时请求的随机路径模板，%s 替换为随机字符串
+// 包括普通文件、备份文件、目录，以及容易触发 WAF 规则的隐藏目录
+var probes = []string{
+	"%s",
+	"%s.bak",
+	"%s/",
+	".%s/%s",
+	".git/%s",
+}
+
+type entry struct {
+	once     sync.Once
+	baseline *Baseline
+}
+
+var (
+	mu    sync.Mutex
+	cache = make(map[string]*entry)
+)
+
+// Calibrate 请求目标URL下几个随机的不存在的路径，记录返回200的页面特征
+// 结果按主机缓存，同一主机只校准一次
+func Calibrate(client *http.Client, targetURL string) *Baseline {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil
+	}
+
+	mu.Lock()
+	e, ok := cache[u.Scheme+"://"+u.Host]
+	if !ok {
+		e = &entry{}
+		cache[u.Scheme+"://"+u.Host] = e
+	}
+	mu.Unlock()
+
+	e.once.Do(func() {
+		e.baseline = calibrate(client, targetURL)
+	})
+	return e.baseline
+}
+
+func calibrate(client *http.Client, targetURL string) *Baseline {
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	var b Baseline
+	for _, probe := range probes {
+		probeURL := targetURL + strings.ReplaceAll(probe, "%s", randomName())
+		data, ok := fetch(client, probeURL)
+		if !ok {
+			continue
+		}
+		fp := fingerprint(probeURL, data)
+		if !b.matchFingerprint(fp) {
+			b.Fingerprints = append(b.Fingerprints, fp)
+		}
+	}
+	if len(b.Fingerprints) == 0 {
+		return nil
+	}
+	return &b
+}
+
+// fetch 下载校准页面的内容，状态码不是200时返回 false
+// 文件下载使用 dumper.Fetch，这里只读取错误页面，不检查内容
+func fetch(client *http.Client, fileURL string) ([]byte, bool) {
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Match 判断 fileURL 返回的内容是否为错误页面
+func (b *Baseline) Match(fileURL string, data []byte) bool {
+	if b == nil {
+		return false
+	}
+	return b.matchFingerprint(fingerprint(fileURL, data))
+}
+
+func (b *Baseline) matchFingerprint(fp Fingerprint) bool {
+	for _, base := range b.Fingerprints {
+		if fp.Hash == base.Hash {
+			return true
+		}
+		if fp.Title == "" || fp.Title != base.Title {
+			continue
+		}
+		tolerance := int(float64(base.Length) * lengthTolerance)
+		if tolerance < minLengthTolerance {
+			tolerance = minLengthTolerance
+		}
+		if diff := fp.Length - base.Length; diff >= -tolerance && diff <= tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// Accept 判断 fileURL 返回的内容是否为真实文件：不是错误页面或 WAF 拦截页面，且与文件类型相符
+// nil 的 Baseline 只检查 WAF 拦截页面和文件类型
+func (b *Baseline) Accept(fileURL string, data []byte) bool {
+	if b.Match(fileURL, data) {
+		return false
+	}
+	name := fileURL
+	if u, err := url.Parse(fileURL); err == nil {
+		name = u.Path
+	}
+	if !isHTMLName(name) && IsBlockPage(data) {
+		return false
+	}
+	return Valid(name, data)
+}
+
+// fingerprint 计算页面特征，错误页面中经常包含请求路径，计算前先将其去掉
+func fingerprint(fileURL string, data []byte) Fingerprint {
+	normalized := data
+	for _, s := range pathForms(fileURL) {
+		normalized = bytes.ReplaceAll(normalized, []byte(s), nil)
+	}
+	sum := sha1.Sum(normalized)
+	return Fingerprint{
+		Length: len(normalized),
+		Hash:   hex.EncodeToString(sum[:]),
+		Title:  Title(data),
+	}
+}
+
+// pathForms 返回请求路径在页面中可能出现的几种形式
+func pathForms(fileURL string) []string {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil
+	}
+	var forms []string
+	for _, p := range []string{u.EscapedPath(), u.Path} {
+		p = strings.TrimSuffix(p, "/")
+		if p != "" && (len(forms) == 0 || forms[0] != p) {
+			forms = append(forms, p)
+		}
+	}
+	return forms
+}
+
+// Title 返回 HTML 页面的标题
+func Title(data []byte) string {
+	if len(data) > 64<<10 {
+		data = data[:64<<10]
+	}
+	m := titleRe.FindSubmatch(data)
+	if m == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(string(m[1])), " ")
+}
+
+func randomName() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,84 @@
+package soft404
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCalibrate(t *testing.T) {
+	// 所有路径都返回200，错误页面中包含请求路径
+	wildcard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>页面不存在</title></head><body>" + r.URL.Path + " not found</body></html>"))
+	}))
+	defer wildcard.Close()
+
+	normal := httptest.NewServer(http.NotFoundHandler())
+	defer normal.Close()
+
+	if Calibrate(normal.Client(), normal.URL+"/") != nil {
+		t.Error("正常返回404的主机不应有 baseline")
+	}
+
+	b := Calibrate(wildcard.Client(), wildcard.URL+"/app/")
+	if b == nil {
+		t.Fatal("校准失败")
+	}
+	if Calibrate(wildcard.Client(), wildcard.URL+"/other/") != b {
+		t.Error("同一主机应使用缓存的 baseline")
+	}
+
+	tests := []struct {
+		path  string
+		data  string
+		match bool
+	}{
+		{"/config.php", "<html><head><title>页面不存在</title></head><body>/config.php not found</body></html>", true},
+		{"/很长的路径/backup.zip", "<html><head><title>页面不存在</title></head><body>/很长的路径/backup.zip not found</body></html>", true},
+		{"/config.php", "<?php $db = 'secret';", false},
+		{"/index.html", "<html><head><title>首页</title></head><body>welcome</body></html>", false},
+	}
+	for _, tt := range tests {
+		if got := b.Match(wildcard.URL+tt.path, []byte(tt.data)); got != tt.match {
+			t.Errorf("Match(%s, %q) = %v, want %v", tt.path, tt.data, got, tt.match)
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	blockPage := "<!DOCTYPE html><html><head><title>403</title></head><body>Access Denied by Web Application Firewall</body></html>"
+	b := &Baseline{Fingerprints: []Fingerprint{fingerprint("http://x/missing", []byte("<html><title>Not Found</title></html>"))}}
+
+	tests := []struct {
+		name   string
+		base   *Baseline
+		url    string
+		data   string
+		accept bool
+	}{
+		{"普通文件", nil, "http://x/a.txt", "hello", true},
+		{"错误页面", b, "http://x/a.txt", "<html><title>Not Found</title></html>", false},
+		{"WAF 拦截页面", nil, "http://x/config.php", blockPage, false},
+		{"带 BOM 的拦截页面", nil, "http://x/config.php", "\ufeff\n  " + blockPage, false},
+		{"HTML 文件本身", nil, "http://x/denied.html", blockPage, true},
+		{"包含拦截提示的 PHP 源码", nil, "http://x/login.php", "<?php\n// access denied\n?>\n<html><body>Access Denied</body></html>", true},
+		{"包含拦截提示的 JSP 源码", nil, "http://x/login.jsp", "<%@ page contentType=\"text/html\" %>\n<html><body>access denied</body></html>", true},
+		{"包含拦截提示的配置文件", nil, "http://x/nginx.conf", "# mod_security\nSecRuleEngine On\n", true},
+		{"文件类型不符", nil, "http://x/.git/HEAD", "<html><body>hi</body></html>", false},
+	}
+	for _, tt := range tests {
+		if got := tt.base.Accept(tt.url, []byte(tt.data)); got != tt.accept {
+			t.Errorf("%s: Accept = %v, want %v", tt.name, got, tt.accept)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if got := Title([]byte("<HTML><TITLE>\n  404 \n Not Found</TITLE>")); got != "404 Not Found" {
+		t.Errorf("Title = %q", got)
+	}
+	if got := Title([]byte(strings.Repeat("x", 64<<10) + "<title>late</title>")); got != "" {
+		t.Errorf("只检查前 64KB，Title = %q", got)
+	}
+}
@@ -0,0 +1,144 @@
+package soft404
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// WAF 拦截页面中常见的内容，均为小写
+var blockMarkers = []string{
+	"access denied",
+	"request blocked",
+	"this request was blocked",
+	"web application firewall",
+	"attention required! | cloudflare",
+	"cloudflare ray id",
+	"incapsula incident id",
+	"sucuri website firewall",
+	"modsecurity",
+	"mod_security",
+	"safedog",
+	"网站防火墙",
+	"安全狗",
+	"云锁",
+	"您的访问被拦截",
+	"请求已被拦截",
+	"已被网站管理员设置拦截",
+}
+
+// IsBlockPage 判断内容是否为 WAF 拦截页面
+// 只检查以 HTML 标签开头的完整页面，PHP、JSP 等源码中即使包含 HTML 和拦截提示也不算
+func IsBlockPage(data []byte) bool {
+	if !isHTMLDocument(data) {
+		return false
+	}
+	if len(data) > 64<<10 {
+		data = data[:64<<10]
+	}
+	lower := bytes.ToLower(data)
+	for _, marker := range blockMarkers {
+		if bytes.Contains(lower, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTMLDocument 判断内容是否以 HTML 标签开头
+func isHTMLDocument(data []byte) bool {
+	if len(data) > 1024 {
+		data = data[:1024]
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	lower := bytes.ToLower(bytes.TrimSpace(data))
+	for _, prefix := range []string{"<!doctype html", "<html", "<head", "<body", "<title"} {
+		if bytes.HasPrefix(lower, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHTML 判断内容是否为 HTML 页面
+func IsHTML(data []byte) bool {
+	if len(data) > 1024 {
+		data = data[:1024]
+	}
+	lower := bytes.ToLower(bytes.TrimSpace(data))
+	return bytes.HasPrefix(lower, []byte("<!doctype html")) ||
+		bytes.HasPrefix(lower, []byte("<html")) ||
+		bytes.Contains(lower, []byte("<head>")) ||
+		bytes.Contains(lower, []byte("<body"))
+}
+
+// isHTMLName 判断文件名是否本身就是 HTML 页面
+func isHTMLName(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm", ".xhtml", ".shtml":
+		return true
+	}
+	return false
+}
+
+var (
+	shaRe     = regexp.MustCompile(`^[0-9a-f]{40}(?:[0-9a-f]{24})?\s*$`)
+	numberRe  = regexp.MustCompile(`^\d+\s*$`)
+	entriesRe = regexp.MustCompile(`^(?:\d+\s*\n|<\?xml)`)
+)
+
+// Valid 根据文件类型检查内容，排除返回错误页面的情况
+// 没有对应规则的版本控制元数据文件不能是 HTML 页面，其他文件不做检查
+func Valid(name string, data []byte) bool {
+	base := path.Base(name)
+	dir := path.Dir(name)
+
+	switch {
+	case base == "index" && path.Base(dir) == ".git":
+		return bytes.HasPrefix(data, []byte("DIRC"))
+	case (base == "HEAD" && path.Base(dir) == ".git") || strings.Contains("/"+name, "/.git/refs/"):
+		return bytes.HasPrefix(data, []byte("ref: ")) || shaRe.Match(data)
+	case strings.Contains("/"+dir+"/", "/.git/objects/") && !strings.Contains(name, "/info/") && !strings.Contains(name, "/pack/"):
+		// 松散对象使用 zlib 压缩
+		return len(data) >= 2 && data[0]&0x0f == 8 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+	case base == "wc.db" && path.Base(dir) == ".svn":
+		return bytes.HasPrefix(data, []byte("SQLite format 3\x00"))
+	case base == "entries" && path.Base(dir) == ".svn":
+		return entriesRe.Match(data)
+	case base == "format" && path.Base(dir) == ".svn":
+		return numberRe.Match(data)
+	case base == "branch-format" && path.Base(dir) == ".bzr":
+		return bytes.HasPrefix(data, []byte("Bazaar"))
+	case base == ".DS_Store":
+		return len(data) >= 8 && bytes.Equal(data[:8], []byte("\x00\x00\x00\x01Bud1"))
+	case strings.EqualFold(base, "Thumbs.db"):
+		return bytes.HasPrefix(data, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"))
+	case strings.HasPrefix(base, "._"):
+		return bytes.HasPrefix(data, []byte("\x00\x05\x16\x07"))
+	case strings.HasSuffix(base, ".zip") || strings.HasSuffix(base, ".jar") || strings.HasSuffix(base, ".war"):
+		// 普通、空的和分卷的 zip 文件头各不相同
+		return bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06")) || bytes.HasPrefix(data, []byte("PK\x07\x08"))
+	case strings.HasSuffix(base, ".tar.gz") || strings.HasSuffix(base, ".tgz"):
+		return bytes.HasPrefix(data, []byte("\x1f\x8b"))
+	case strings.HasSuffix(base, ".swp"):
+		return bytes.HasPrefix(data, []byte("b0VIM"))
+	case strings.HasSuffix(base, ".class"):
+		return bytes.HasPrefix(data, []byte("\xca\xfe\xba\xbe"))
+	case isMetadata(name) && !strings.HasSuffix(base, ".svn-base"):
+		// .svn-base 是工作区文件的原始副本，内容不限
+		return !IsHTML(data)
+	}
+	return true
+}
+
+// isMetadata 判断路径是否位于版本控制的元数据目录中
+func isMetadata(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		switch part {
+		case ".git", ".svn", ".hg", ".bzr", "CVS":
+			return true
+		}
+	}
+	return false
+}
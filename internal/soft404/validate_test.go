@@ -0,0 +1,78 @@
+package soft404
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	html := "<html><body>not found</body></html>"
+
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{".git/index", "DIRC\x00\x00\x00\x02", true},
+		{".git/index", html, false},
+		{".git/HEAD", "ref: refs/heads/master\n", true},
+		{".git/HEAD", "0123456789abcdef0123456789abcdef01234567\n", true},
+		{".git/HEAD", html, false},
+		{".git/refs/heads/main", "0123456789abcdef0123456789abcdef01234567", true},
+		{".git/refs/heads/main", "main", false},
+		{".git/objects/ab/cdef", "\x78\x9c\x01\x02", true},
+		{".git/objects/ab/cdef", html, false},
+		{".git/objects/info/packs", "P pack-1.pack\n", true},
+		{".svn/wc.db", "SQLite format 3\x00", true},
+		{".svn/wc.db", html, false},
+		{".svn/entries", "12\n", true},
+		{".svn/entries", "<?xml version=\"1.0\"?>", true},
+		{".svn/entries", html, false},
+		{".svn/format", "4\n", true},
+		{".svn/format", html, false},
+		{".svn/pristine/ab/abcd.svn-base", html, true},
+		{".bzr/branch-format", "Bazaar-NG meta directory, format 1\n", true},
+		{".bzr/branch-format", html, false},
+		{".hg/requires", html, false},
+		{".hg/requires", "revlogv1\nstore\n", true},
+		{"CVS/Root", html, false},
+		{"a/.DS_Store", "\x00\x00\x00\x01Bud1", true},
+		{"a/.DS_Store", html, false},
+		{"Thumbs.db", "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", true},
+		{"THUMBS.DB", html, false},
+		{"._photo.jpg", "\x00\x05\x16\x07\x00\x02", true},
+		{"._photo.jpg", html, false},
+		{"backup.zip", "PK\x03\x04", true},
+		{"empty.zip", "PK\x05\x06", true},
+		{"split.zip", "PK\x07\x08", true},
+		{"WEB-INF/lib/a.jar", "PK\x03\x04", true},
+		{"ROOT.war", html, false},
+		{"site.tar.gz", "\x1f\x8b\x08", true},
+		{"site.tgz", html, false},
+		{".index.php.swp", "b0VIM 9.0", true},
+		{".index.php.swp", html, false},
+		{"WEB-INF/classes/A.class", "\xca\xfe\xba\xbe", true},
+		{"WEB-INF/classes/A.class", html, false},
+		{"index.php", html, true},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.name, []byte(tt.data)); got != tt.valid {
+			t.Errorf("Valid(%s, %q) = %v, want %v", tt.name, tt.data, got, tt.valid)
+		}
+	}
+}
+
+func TestIsHTML(t *testing.T) {
+	tests := []struct {
+		data string
+		html bool
+	}{
+		{"<!DOCTYPE html><p>x</p>", true},
+		{"  <HTML>", true},
+		{"<?php ?>\n<head><title>x</title></head>", true},
+		{"ref: refs/heads/master", false},
+		{"{\"body\": 1}", false},
+	}
+	for _, tt := range tests {
+		if got := IsHTML([]byte(tt.data)); got != tt.html {
+			t.Errorf("IsHTML(%q) = %v, want %v", tt.data, got, tt.html)
+		}
+	}
+}
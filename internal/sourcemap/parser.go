@@ -0,0 +1,139 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// index map 的最大嵌套层数
+const maxSectionDepth = 8
+
+// SourceFile 表示 source map 中带有原始内容的一个源文件
+type SourceFile struct {
+	Source  string // source map 中记录的原始路径，例如 webpack:///src/App.tsx
+	Path    string // 规范化后的本地相对路径
+	Content string // 原始源码
+}
+
+// sourceMap 对应 source map v3 的 JSON 结构，sections 用于 index map
+type sourceMap struct {
+	Version        int       `json:"version"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+	Sections       []section `json:"sections"`
+}
+
+type section struct {
+	URL string     `json:"url"`
+	Map *sourceMap `json:"map"`
+}
+
+// Parse 解析 source map，返回所有包含 sourcesContent 的源文件
+// index map 中以 url 引用的子 map 不会被下载，只处理内嵌的 map
+func Parse(data []byte) ([]SourceFile, error) {
+	// 部分 source map 以 )]}' 开头防止 XSSI
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte(")]}'"))
+
+	var sm sourceMap
+	if err := json.Unmarshal(data, &sm); err != nil {
+		return nil, fmt.Errorf("解析 source map 失败: %v", err)
+	}
+	if sm.Version == 0 && len(sm.Sources) == 0 && len(sm.Sections) == 0 {
+		return nil, fmt.Errorf("不是有效的 source map")
+	}
+
+	var files []SourceFile
+	collect(&sm, 0, &files)
+	return files, nil
+}
+
+// collect 收集 map 及其内嵌 sections 中的源文件
+func collect(sm *sourceMap, depth int, files *[]SourceFile) {
+	if sm == nil || depth > maxSectionDepth {
+		return
+	}
+	for i, source := range sm.Sources {
+		if i >= len(sm.SourcesContent) || sm.SourcesContent[i] == nil {
+			continue
+		}
+		p := SourcePath(source, sm.SourceRoot)
+		if p == "" {
+			continue
+		}
+		*files = append(*files, SourceFile{Source: source, Path: p, Content: *sm.SourcesContent[i]})
+	}
+	for _, s := range sm.Sections {
+		collect(s.Map, depth+1, files)
+	}
+}
+
+// SourcePath 将 source map 中的路径转换为本地相对路径
+// 去掉 webpack:// 等协议前缀和查询参数，丢弃 . 和 .. 路径段，避免越出输出目录
+func SourcePath(source string, sourceRoot string) string {
+	s := source
+	if sourceRoot != "" && !strings.Contains(s, "://") {
+		s = strings.TrimSuffix(sourceRoot, "/") + "/" + s
+	}
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(s, "\\", "/"), "/") {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		// 去掉 Windows 盘符
+		if len(parts) == 0 && len(part) == 2 && part[1] == ':' {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// sourceMappingURL 注释，//# 为标准写法，//@ 为旧写法，CSS 使用 /*# */
+var mappingURLRe = regexp.MustCompile(`(?m)(?://|/\*)[#@]\s*sourceMappingURL\s*=\s*(\S+?)\s*(?:\*/)?\s*$`)
+
+// MappingURL 返回脚本中最后一个 sourceMappingURL 注释的值
+func MappingURL(script []byte) string {
+	matches := mappingURLRe.FindAllSubmatch(script, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
+
+// DecodeDataURL 解码内联在 data: URL 中的 source map
+func DecodeDataURL(ref string) ([]byte, bool) {
+	if !strings.HasPrefix(ref, "data:") {
+		return nil, false
+	}
+	meta, payload, ok := strings.Cut(ref[len("data:"):], ",")
+	if !ok {
+		return nil, false
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			if data, err = base64.RawStdEncoding.DecodeString(payload); err != nil {
+				return nil, false
+			}
+		}
+		return data, true
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, false
+	}
+	return []byte(data), true
+}
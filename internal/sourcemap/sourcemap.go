@@ -0,0 +1,350 @@
+// Package sourcemap 实现 JavaScript source map 泄露的下载和源码还原
+package sourcemap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/publicsuffix"
+)
+
+// 还原的源码保存在输出目录下的该子目录中
+const outputDir = "sourcemaps"
+
+// 下载页面、脚本和 source map 的最大长度
+const maxFetchSize = 64 << 20
+
+// SourceMapDumper 从页面引用的脚本中收集 source map 并还原原始源码
+type SourceMapDumper struct {
+	dumper.BaseDumper
+	// Filter 写出源码文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录目标下的脚本和 source map 路径
+	Paths *dumper.PathSet
+}
+
+// NewSourceMapDumper 创建 SourceMapDumper 实例
+func NewSourceMapDumper() *SourceMapDumper {
+	return &SourceMapDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "sourcemap",
+			Description: "通过 source map 还原前端源码",
+		},
+	}
+}
+
+// harvestState 记录一次收集的状态
+type harvestState struct {
+	client     *http.Client
+	root       *url.URL
+	outdir     string
+	progressCb dumper.ProgressCallback
+
+	// mu 保护 maps 和 sources，并发下载的脚本依次提取
+	mu      sync.Mutex
+	maps    map[string]bool
+	sources map[string]bool
+}
+
+// Check 检查目标页面引用的脚本是否存在 source map
+func (d *SourceMapDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	root, err := url.Parse(targetURL)
+	if err != nil {
+		return false, nil
+	}
+	state := &harvestState{client: client, root: root}
+	for _, script := range state.scripts(targetURL) {
+		if _, data := state.findMapData(script); data != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Execute 执行下载操作
+func (d *SourceMapDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	root, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("URL解析失败: %v", err)
+	}
+
+	state := &harvestState{
+		client:     client,
+		root:       root,
+		outdir:     outdir,
+		maps:       make(map[string]bool),
+		sources:    make(map[string]bool),
+		progressCb: progressCb,
+	}
+
+	// 目标本身是 .map 或 .js 时直接处理，否则从页面中收集脚本
+	var scripts []string
+	switch strings.ToLower(path.Ext(root.Path)) {
+	case ".map":
+		if data, ok := state.fetch(targetURL); ok {
+			d.extract(state, targetURL, data)
+		}
+		return nil
+	case ".js", ".mjs", ".css":
+		scripts = []string{targetURL}
+	default:
+		scripts = state.scripts(targetURL)
+	}
+
+	pool := dumper.NewPool(workers)
+	for _, script := range scripts {
+		if rel, ok := state.relPath(script); ok {
+			d.Paths.Add(rel)
+		}
+		pool.Go(func() {
+			mapURL, data := state.findMapData(script)
+			if data != nil {
+				state.mu.Lock()
+				d.extract(state, mapURL, data)
+				state.mu.Unlock()
+			}
+		})
+	}
+	pool.Wait()
+
+	return nil
+}
+
+// scripts 获取页面并返回其中引用的同站脚本和样式表URL
+func (s *harvestState) scripts(pageURL string) []string {
+	resp, err := s.client.Get(pageURL)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return nil
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		u, err := resp.Request.URL.Parse(strings.TrimSpace(ref))
+		if err != nil || !s.sameSite(u) {
+			return
+		}
+		u.Fragment = ""
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			urls = append(urls, u.String())
+		}
+	}
+
+	doc.Find("script[src]").Each(func(_ int, sel *goquery.Selection) {
+		add(sel.AttrOr("src", ""))
+	})
+	doc.Find(`link[rel="modulepreload"][href], link[rel="preload"][as="script"][href], link[rel="stylesheet"][href]`).Each(func(_ int, sel *goquery.Selection) {
+		add(sel.AttrOr("href", ""))
+	})
+
+	return urls
+}
+
+// sameSite 判断URL是否为与目标属于同一站点的 HTTP 地址 (相同主机或同一注册域名下的子域名，例如静态资源域名)
+func (s *harvestState) sameSite(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host, root := strings.ToLower(u.Hostname()), strings.ToLower(s.root.Hostname())
+	if host == root {
+		return true
+	}
+	return siteOf(host) != "" && siteOf(host) == siteOf(root)
+}
+
+// siteOf 根据公共后缀列表返回主机名的注册域名，例如 a.example.co.uk 返回 example.co.uk
+// IP 地址和无法确定注册域名的主机返回空字符串
+func siteOf(host string) string {
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	site, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return ""
+	}
+	return site
+}
+
+// relPath 返回目标目录下的地址相对于目标URL的路径
+func (s *harvestState) relPath(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host != s.root.Host {
+		return "", false
+	}
+	dir := s.root.Path[:strings.LastIndex(s.root.Path, "/")+1]
+	if dir == "" {
+		dir = "/"
+	}
+	if !strings.HasPrefix(u.Path, dir) || len(u.Path) == len(dir) {
+		return "", false
+	}
+	return u.Path[len(dir):], true
+}
+
+// findMapData 查找并下载脚本对应的 source map，返回 map 地址和内容
+// 依次检查 SourceMap 响应头、sourceMappingURL 注释，最后尝试 <脚本>.map
+func (s *harvestState) findMapData(scriptURL string) (string, []byte) {
+	resp, err := s.client.Get(scriptURL)
+	if err != nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+
+	ref := resp.Header.Get("SourceMap")
+	if ref == "" {
+		ref = resp.Header.Get("X-SourceMap")
+	}
+	if ref == "" {
+		ref = MappingURL(body)
+	}
+
+	// 内联的 source map
+	if data, ok := DecodeDataURL(ref); ok {
+		return scriptURL, data
+	}
+
+	// 响应头和注释中的地址来自远程内容，只请求同一站点的地址
+	candidates := []string{}
+	if ref != "" {
+		if u, err := resp.Request.URL.Parse(ref); err == nil && s.sameSite(u) {
+			candidates = append(candidates, u.String())
+		}
+	}
+	if u := *resp.Request.URL; s.sameSite(&u) {
+		u.RawQuery = ""
+		u.Fragment = ""
+		candidates = append(candidates, u.String()+".map")
+	}
+
+	for _, candidate := range candidates {
+		if data, ok := s.fetch(candidate); ok && looksLikeMap(data) {
+			return candidate, data
+		}
+	}
+	return "", nil
+}
+
+// looksLikeMap 粗略判断内容是否为 source map，排除返回 HTML 的自定义404页面
+func looksLikeMap(data []byte) bool {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte(")]}'"))
+	data = bytes.TrimSpace(data)
+	return bytes.HasPrefix(data, []byte("{")) && (bytes.Contains(data, []byte(`"sources"`)) || bytes.Contains(data, []byte(`"sections"`)))
+}
+
+// fetch 下载文件内容，状态码不是200时返回 false
+func (s *harvestState) fetch(fileURL string) ([]byte, bool) {
+	resp, err := s.client.Get(fileURL)
+	if err != nil {
+		if s.progressCb != nil {
+			s.progressCb(fileURL, 0, "下载失败")
+		}
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if s.progressCb != nil {
+		s.progressCb(fileURL, resp.StatusCode, "")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// extract 保存 source map 并写出其中的原始源码
+func (d *SourceMapDumper) extract(state *harvestState, mapURL string, data []byte) {
+	if state.maps[mapURL] {
+		return
+	}
+	state.maps[mapURL] = true
+	if rel, ok := state.relPath(mapURL); ok {
+		d.Paths.Add(rel)
+	}
+
+	files, err := Parse(data)
+	if err != nil {
+		if state.progressCb != nil {
+			state.progressCb(mapURL, 0, "解析 source map 失败")
+		}
+		return
+	}
+
+	if err := os.MkdirAll(state.outdir, 0755); err != nil {
+		return
+	}
+
+	// 按URL路径保存 source map 本身，内联的 map 以脚本路径加 .map 保存
+	if u, err := url.Parse(mapURL); err == nil {
+		name := u.Path
+		if !strings.HasSuffix(name, ".map") {
+			name += ".map"
+		}
+		dumper.SaveFile(state.outdir, name, bytes.NewReader(data))
+	}
+
+	for _, file := range files {
+		if state.sources[file.Path] {
+			continue
+		}
+		state.sources[file.Path] = true
+
+		if !d.Filter.Match(filter.Entry{Path: file.Path, Size: int64(len(file.Content))}) {
+			continue
+		}
+
+		localPath, err := dumper.SaveFile(state.outdir, outputDir+"/"+file.Path, strings.NewReader(file.Content))
+		if state.progressCb == nil {
+			continue
+		}
+		if err != nil {
+			state.progressCb(mapURL, 0, "写入失败")
+		} else {
+			state.progressCb(mapURL+"#"+file.Source, http.StatusOK, localPath)
+		}
+	}
+}
+
+// Validate 验证URL是否有效
+func (d *SourceMapDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package sourcemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSameSite(t *testing.T) {
+	tests := []struct {
+		root string
+		ref  string
+		want bool
+	}{
+		{"https://www.example.com/", "https://static.example.com/app.js", true},
+		{"https://www.example.com/", "http://example.com/app.js", true},
+		{"https://www.example.com/", "https://example.org/app.js", false},
+		{"https://www.example.co.uk/", "https://cdn.example.co.uk/app.js", true},
+		{"https://www.example.co.uk/", "https://attacker.co.uk/app.js", false},
+		{"https://a.example.com.cn/", "https://b.other.com.cn/app.js", false},
+		{"https://user.github.io/", "https://other.github.io/app.js", false},
+		{"http://127.0.0.1:8080/", "http://127.0.0.1:9090/app.js", true},
+		{"http://10.0.0.1/", "http://10.0.0.2/app.js", false},
+		{"http://localhost/", "http://evil.localhost/app.js", false},
+		{"https://www.example.com/", "file:///etc/passwd", false},
+		{"https://www.example.com/", "ftp://www.example.com/app.js", false},
+	}
+
+	for _, tt := range tests {
+		root, _ := url.Parse(tt.root)
+		ref, _ := url.Parse(tt.ref)
+		s := &harvestState{root: root}
+		if got := s.sameSite(ref); got != tt.want {
+			t.Errorf("sameSite(%s, %s) = %v, want %v", tt.root, tt.ref, got, tt.want)
+		}
+	}
+}
+
+// roundTripFunc 把函数包装为 http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRelPath(t *testing.T) {
+	tests := []struct {
+		root string
+		ref  string
+		want string
+		ok   bool
+	}{
+		{"https://example.com/", "https://example.com/static/app.js.map", "static/app.js.map", true},
+		{"https://example.com/app/index.html", "https://example.com/app/js/main.js", "js/main.js", true},
+		{"https://example.com/app/", "https://example.com/other/main.js", "", false},
+		{"https://example.com/", "https://cdn.example.com/main.js", "", false},
+		{"https://example.com/app/", "https://example.com/app/", "", false},
+		{"https://example.com", "https://example.com/main.js", "main.js", true},
+	}
+
+	for _, tt := range tests {
+		root, _ := url.Parse(tt.root)
+		s := &harvestState{root: root}
+		got, ok := s.relPath(tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("relPath(%s, %s) = %q, %v, want %q, %v", tt.root, tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFindMapDataSkipsForeignHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/header.js":
+			w.Header().Set("SourceMap", "http://attacker.example/header.js.map")
+			w.Write([]byte("var a;"))
+		case "/comment.js":
+			w.Write([]byte("var a;\n//# sourceMappingURL=https://internal.example.net/comment.js.map\n"))
+		case "/local.js":
+			w.Write([]byte("var a;\n//# sourceMappingURL=local.js.map\n"))
+		case "/local.js.map":
+			w.Write([]byte(`{"version":3,"sources":["b.js"],"sourcesContent":["y"],"mappings":""}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// 其他主机的请求不发送，只记录下来
+	var foreign []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host != srv.Listener.Addr().String() {
+			foreign = append(foreign, r.URL.String())
+			return nil, fmt.Errorf("foreign host")
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	root, _ := url.Parse(srv.URL + "/")
+	s := &harvestState{client: client, root: root}
+
+	for _, script := range []string{"/header.js", "/comment.js"} {
+		if mapURL, data := s.findMapData(srv.URL + script); data != nil {
+			t.Errorf("%s: fetched foreign source map %s", script, mapURL)
+		}
+	}
+	if len(foreign) != 0 {
+		t.Errorf("requested foreign URLs: %v", foreign)
+	}
+
+	if mapURL, data := s.findMapData(srv.URL + "/local.js"); data == nil || mapURL != srv.URL+"/local.js.map" {
+		t.Errorf("local source map not found: %q", mapURL)
+	}
+}
@@ -0,0 +1,199 @@
+package svn
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dumpall-go/internal/filter"
+	"dumpall-go/pkg/sqlite"
+)
+
+// Node 表示工作副本中的一个文件或目录
+type Node struct {
+	Path     string    // 相对路径
+	Kind     string    // file 或 dir
+	Revision int64     // 版本号
+	Checksum string    // wc.db 中为 $sha1$...，entries 中为 md5
+	Size     int64     // 文件大小
+	ModTime  time.Time // 修改时间
+	URL      string    // 仓库中的路径
+}
+
+// filterEntry 生成用于过滤的文件信息，大小为 0 时视为未知
+func (n Node) filterEntry(name string) filter.Entry {
+	size := n.Size
+	if size == 0 {
+		size = -1
+	}
+	return filter.Entry{Path: strings.TrimPrefix(name, "/"), Size: size, ModTime: n.ModTime}
+}
+
+// ParseWcDB 解析 SVN 1.7+ 的 wc.db 文件，返回 NODES 表中的文件和目录
+func ParseWcDB(data []byte) ([]Node, error) {
+	db, err := sqlite.Open(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := db.Records("NODES")
+	if err != nil {
+		return nil, fmt.Errorf("读取 NODES 表失败: %v", err)
+	}
+
+	// 同一路径可能存在多层记录，保留 op_depth 最大的一条
+	byPath := make(map[string]Node)
+	depths := make(map[string]int64)
+	for _, rec := range records {
+		if presence := toString(rec["presence"]); presence != "" && presence != "normal" {
+			continue
+		}
+
+		path := toString(rec["local_relpath"])
+		depth := toInt(rec["op_depth"])
+		if old, ok := depths[path]; ok && old > depth {
+			continue
+		}
+
+		node := Node{
+			Path:     path,
+			Kind:     toString(rec["kind"]),
+			Revision: toInt(rec["revision"]),
+			Checksum: toString(rec["checksum"]),
+			Size:     toInt(rec["translated_size"]),
+			URL:      toString(rec["repos_path"]),
+		}
+		if usec := toInt(rec["last_mod_time"]); usec > 0 {
+			node.ModTime = time.UnixMicro(usec)
+		}
+
+		byPath[path] = node
+		depths[path] = depth
+	}
+
+	nodes := make([]Node, 0, len(byPath))
+	for _, node := range byPath {
+		if node.Path == "" {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Path < nodes[j].Path })
+
+	return nodes, nil
+}
+
+// PristinePath 返回 wc.db 校验和对应的 pristine 文件相对路径
+func PristinePath(checksum string) string {
+	sha1 := strings.TrimPrefix(checksum, "$sha1$")
+	if len(sha1) < 3 || sha1 == checksum {
+		return ""
+	}
+	return "pristine/" + sha1[:2] + "/" + sha1 + ".svn-base"
+}
+
+// ParseEntries 解析 SVN 1.6 及以下的 .svn/entries 文件，返回当前目录下的条目
+func ParseEntries(data []byte) ([]Node, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<wc-entries")) {
+		return parseXMLEntries(data)
+	}
+
+	lines := strings.SplitN(string(data), "\n", 2)
+	format, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil, fmt.Errorf("无效的 entries 文件")
+	}
+	// SVN 1.7+ 的 entries 只包含格式号，文件信息存放在 wc.db 中
+	if format >= 12 || len(lines) < 2 {
+		return nil, fmt.Errorf("entries 格式 %d 不包含文件列表", format)
+	}
+
+	var nodes []Node
+	for i, block := range strings.Split(lines[1], "\f\n") {
+		fields := strings.Split(block, "\n")
+		// 第一个条目描述目录本身
+		if i == 0 || len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+
+		node := Node{
+			Path: fields[0],
+			Kind: fields[1],
+		}
+		if len(fields) > 2 {
+			node.Revision, _ = strconv.ParseInt(fields[2], 10, 64)
+		}
+		if len(fields) > 3 {
+			node.URL = fields[3]
+		}
+		if len(fields) > 6 && fields[6] != "" {
+			node.ModTime, _ = time.Parse(time.RFC3339Nano, fields[6])
+		}
+		if len(fields) > 7 {
+			node.Checksum = fields[7]
+		}
+		if len(fields) > 32 {
+			node.Size, _ = strconv.ParseInt(fields[32], 10, 64)
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// xmlEntries 对应 SVN 1.3 及以下的 XML 格式 entries 文件
+type xmlEntries struct {
+	Entries []struct {
+		Name      string `xml:"name,attr"`
+		Kind      string `xml:"kind,attr"`
+		Revision  string `xml:"revision,attr"`
+		URL       string `xml:"url,attr"`
+		Checksum  string `xml:"checksum,attr"`
+		TextTime  string `xml:"text-time,attr"`
+		Committed string `xml:"committed-date,attr"`
+	} `xml:"entry"`
+}
+
+func parseXMLEntries(data []byte) ([]Node, error) {
+	var doc xmlEntries
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 XML entries 失败: %v", err)
+	}
+
+	var nodes []Node
+	for _, e := range doc.Entries {
+		if e.Name == "" {
+			continue
+		}
+		node := Node{
+			Path:     e.Name,
+			Kind:     e.Kind,
+			URL:      e.URL,
+			Checksum: e.Checksum,
+		}
+		node.Revision, _ = strconv.ParseInt(e.Revision, 10, 64)
+		node.ModTime, _ = time.Parse(time.RFC3339Nano, e.TextTime)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
+
+func toInt(v interface{}) int64 {
+	i, _ := v.(int64)
+	return i
+}
@@ -0,0 +1,118 @@
+package svn
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// checkNodes 比较解析结果中的路径、类型、校验和、大小和修改时间
+func checkNodes(t *testing.T, name string, got, want []Node) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d nodes, want %d: %+v", name, len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Path != w.Path || g.Kind != w.Kind || g.Checksum != w.Checksum || g.Size != w.Size || !g.ModTime.Equal(w.ModTime) {
+			t.Errorf("%s: node %d = %+v, want %+v", name, i, g, w)
+		}
+	}
+}
+
+// testdata/entries.v10 为 SVN 1.6 的纯文本格式，末尾的空字段被省略
+func TestParseEntries(t *testing.T) {
+	nodes, err := ParseEntries(readFixture(t, "entries.v10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNodes(t, "entries.v10", nodes, []Node{
+		{Path: "config.php", Kind: "file", Checksum: "5d41402abc4b2a76b9719d911017c592", Size: 120, ModTime: time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)},
+		{Path: "中文.txt", Kind: "file", Checksum: "7215ee9c7d9dc229d2921a40e899ec5f", ModTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{Path: "includes", Kind: "dir"},
+		{Path: "../evil", Kind: "file"},
+	})
+}
+
+// testdata/entries.xml 为 SVN 1.3 及以下的 XML 格式
+func TestParseEntriesXML(t *testing.T) {
+	nodes, err := ParseEntries(readFixture(t, "entries.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNodes(t, "entries.xml", nodes, []Node{
+		{Path: "index.php", Kind: "file", Checksum: "d41d8cd98f00b204e9800998ecf8427e", ModTime: time.Date(2005, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{Path: "lib", Kind: "dir"},
+	})
+}
+
+func TestParseEntriesMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"空文件", ""},
+		{"HTML 页面", "<html><body>not found</body></html>"},
+		{"SVN 1.7 的 entries", "12\n"},
+		{"只有格式号", "10"},
+		{"损坏的 XML", "<?xml version=\"1.0\"?><wc-entries><entry"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseEntries([]byte(tt.data)); err == nil {
+			t.Errorf("%s: 应返回错误", tt.name)
+		}
+	}
+}
+
+// testdata/wc.db 使用 SVN 1.7+ 的 NODES 表结构，gen 目录下的 100 个文件使 B 树包含多个页
+func TestParseWcDB(t *testing.T) {
+	nodes, err := ParseWcDB(readFixture(t, "wc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 103 {
+		t.Fatalf("got %d nodes, want 103", len(nodes))
+	}
+
+	mtime := time.UnixMicro(1704164645123456)
+	// 已删除的文件不返回，同一路径保留 op_depth 最大的记录
+	checkNodes(t, "wc.db", []Node{nodes[0], nodes[1], nodes[101], nodes[102]}, []Node{
+		{Path: "config.php", Kind: "file", Checksum: "$sha1$aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Size: 120, ModTime: mtime},
+		{Path: "gen/file000.txt", Kind: "file", Checksum: "$sha1$0000000000000000000000000000000000000000", ModTime: mtime},
+		{Path: "src", Kind: "dir"},
+		{Path: "src/a.php", Kind: "file", Checksum: "$sha1$2222222222222222222222222222222222222222", Size: 20, ModTime: mtime},
+	})
+	if nodes[101].URL != "trunk/src" || nodes[0].Revision != 5 {
+		t.Errorf("nodes = %+v, %+v", nodes[0], nodes[101])
+	}
+
+	if _, err := ParseWcDB([]byte("SQLite format 3\x00")); err == nil {
+		t.Error("截断的 wc.db 应返回错误")
+	}
+}
+
+func TestPristinePath(t *testing.T) {
+	tests := []struct {
+		checksum string
+		want     string
+	}{
+		{"$sha1$aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", "pristine/aa/aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d.svn-base"},
+		{"$md5 $5d41402abc4b2a76b9719d911017c592", ""},
+		{"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", ""},
+		{"$sha1$a", ""},
+	}
+	for _, tt := range tests {
+		if got := PristinePath(tt.checksum); got != tt.want {
+			t.Errorf("PristinePath(%q) = %q, want %q", tt.checksum, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package svn
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/soft404"
+)
+
+// restoreWcDB 根据 wc.db 中的记录下载 pristine 文件，工作区文件不限制大小
+func (d *SvnDumper) restoreWcDB(client *http.Client, baseline *soft404.Baseline, pool *dumper.Pool, targetURL string, outdir string, nodes []Node, progressCb dumper.ProgressCallback) {
+	for _, node := range nodes {
+		if node.Kind != "file" {
+			continue
+		}
+		d.Paths.Add(node.Path)
+		if !d.Filter.Match(node.filterEntry(node.Path)) {
+			continue
+		}
+		pristine := PristinePath(node.Checksum)
+		if pristine == "" {
+			continue
+		}
+		pool.Go(func() {
+			dumper.DownloadStream(client, baseline, d.Filter, targetURL+".svn/"+pristine, outdir, node.Path, progressCb)
+		})
+	}
+}
+
+// restoreEntries 根据 entries 文件下载 text-base 文件并递归子目录
+func (d *SvnDumper) restoreEntries(client *http.Client, baseline *soft404.Baseline, pool *dumper.Pool, dirURL string, outdir string, relDir string, depth int, progressCb dumper.ProgressCallback) {
+	entriesPath, err := dumper.SafePath(outdir, relDir+"/.svn/entries")
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(entriesPath)
+	if err != nil {
+		return
+	}
+	nodes, err := ParseEntries(data)
+	if err != nil {
+		return
+	}
+
+	for _, node := range nodes {
+		// entries 中的名称不应包含路径分隔符
+		if strings.ContainsAny(node.Path, "/\\") {
+			continue
+		}
+		name := relDir + "/" + node.Path
+
+		switch node.Kind {
+		case "file":
+			d.Paths.Add(name)
+			if !d.Filter.Match(node.filterEntry(name)) {
+				continue
+			}
+			fileURL := dirURL + ".svn/text-base/" + url.PathEscape(node.Path) + ".svn-base"
+			pool.Go(func() {
+				dumper.DownloadStream(client, baseline, d.Filter, fileURL, outdir, name, progressCb)
+			})
+		case "dir":
+			if depth >= maxEntriesDepth {
+				continue
+			}
+			subURL := dirURL + url.PathEscape(node.Path) + "/"
+			if _, ok := dumper.Download(client, baseline, nil, subURL+".svn/entries", outdir, name+"/.svn/entries", 0, progressCb); ok {
+				d.restoreEntries(client, baseline, pool, subURL, outdir, name, depth+1, progressCb)
+			}
+		}
+	}
+}
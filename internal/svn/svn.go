@@ -2,19 +2,27 @@ package svn
 
 import (
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
 )
 
+// 递归解析子目录 entries 的最大深度
+const maxEntriesDepth = 32
+
 // SvnDumper 实现 .svn 源代码下载
 type SvnDumper struct {
 	dumper.BaseDumper
+	// Filter 还原工作副本文件时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 wc.db 和 entries 中的文件路径
+	Paths *dumper.PathSet
 }
 
 // NewSvnDumper 创建 SvnDumper 实例
@@ -34,28 +42,13 @@ func (d *SvnDumper) Check(targetURL string, client *http.Client) (bool, error) {
 		targetURL += "/"
 	}
 
-	// 检查 .svn/entries 文件
-	entriesURL := targetURL + ".svn/entries"
-	resp, err := client.Head(entriesURL)
-	if err != nil {
-		return false, nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
-	}
-
-	// 检查 .svn/wc.db 文件 (SVN 1.7+)
-	wcdbURL := targetURL + ".svn/wc.db"
-	resp, err = client.Head(wcdbURL)
-	if err != nil {
-		return false, nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
+	// 检查 .svn/entries 和 .svn/wc.db (SVN 1.7+) 文件，排除不存在的文件也返回200的情况
+	baseline := soft404.Calibrate(client, targetURL)
+	for _, file := range []string{".svn/entries", ".svn/wc.db"} {
+		fileURL := targetURL + file
+		if _, ok := dumper.Fetch(client, baseline, fileURL, 0, nil); ok {
+			return true, nil
+		}
 	}
 
 	return false, nil
@@ -64,16 +57,9 @@ func (d *SvnDumper) Check(targetURL string, client *http.Client) (bool, error) {
 // Execute 执行下载操作
 func (d *SvnDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
 	// 创建HTTP客户端
-	client := &http.Client{}
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return fmt.Errorf("代理设置错误: %v", err)
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		client.Transport = transport
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
 	}
 
 	// 确保URL以/结尾
@@ -97,58 +83,27 @@ func (d *SvnDumper) Execute(targetURL string, outdir string, proxy string, force
 		".svn/tmp",
 	}
 
+	baseline := soft404.Calibrate(client, targetURL)
+
 	// 下载文件
 	for _, file := range svnFiles {
-		fileURL := targetURL + file
-		localPath := filepath.Join(outdir, file)
-
-		// 创建目录
-		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
-			if progressCb != nil {
-				progressCb(fileURL, 0, "创建目录失败")
-			}
-			continue
-		}
-
-		// 下载文件
-		resp, err := client.Get(fileURL)
-		if err != nil {
-			if progressCb != nil {
-				progressCb(fileURL, 0, "下载失败")
-			}
-			continue
-		}
-
-		// 调用进度回调
-		if progressCb != nil {
-			progressCb(fileURL, resp.StatusCode, localPath)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			continue
-		}
-
-		// 创建本地文件
-		f, err := os.Create(localPath)
-		if err != nil {
-			resp.Body.Close()
-			continue
-		}
+		dumper.Download(client, baseline, nil, targetURL+file, outdir, file, 0, progressCb)
+	}
 
-		// 写入文件内容
-		_, err = io.Copy(f, resp.Body)
-		resp.Body.Close()
-		f.Close()
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
 
-		if err != nil {
-			if progressCb != nil {
-				progressCb(fileURL, 0, "写入失败")
-			}
-			continue
+	// SVN 1.7+ 根据 wc.db 还原源代码
+	if data, err := os.ReadFile(filepath.Join(outdir, ".svn", "wc.db")); err == nil {
+		if nodes, err := ParseWcDB(data); err == nil {
+			d.restoreWcDB(client, baseline, pool, targetURL, outdir, nodes, progressCb)
+			return nil
 		}
 	}
 
+	// SVN 1.6 及以下根据 entries 逐级还原源代码
+	d.restoreEntries(client, baseline, pool, targetURL, outdir, "", 0, progressCb)
+
 	return nil
 }
 
@@ -0,0 +1,145 @@
+package thumbs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"dumpall-go/pkg/cfb"
+)
+
+// CatalogEntry 表示 Thumbs.db 的 Catalog 流中的一条缩略图记录
+type CatalogEntry struct {
+	ID      uint32    // 缩略图流的编号，流名为编号的倒序十进制字符串
+	Name    string    // 文件名
+	ModTime time.Time // 文件修改时间
+}
+
+// ParseThumbsDB 解析 Thumbs.db 中 Catalog 流记录的文件名
+func ParseThumbsDB(data []byte) ([]CatalogEntry, error) {
+	f, err := cfb.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := f.ReadStream("Catalog")
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(catalog)
+}
+
+// ParseCatalog 解析 Catalog 流
+// 流以 16 字节的头开始，其后每条记录为: 长度(4) 编号(4) [FILETIME(8)] UTF-16 文件名
+func ParseCatalog(data []byte) ([]CatalogEntry, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("Catalog 流长度不足")
+	}
+	headerLen := int(binary.LittleEndian.Uint16(data[0:]))
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	if headerLen < 16 || headerLen > len(data) {
+		headerLen = 16
+	}
+
+	var entries []CatalogEntry
+	for off := headerLen; off+8 <= len(data) && len(entries) < count; {
+		size := int(binary.LittleEndian.Uint32(data[off:]))
+		if size < 8 || off+size > len(data) {
+			break
+		}
+		rec := data[off : off+size]
+		off += size
+
+		entry := CatalogEntry{ID: binary.LittleEndian.Uint32(rec[4:])}
+		name := rec[8:]
+		// Windows XP 及以后的记录在文件名前有修改时间，FILETIME 的高位字节不会是 UTF-16 的 ASCII 字符
+		if len(name) >= 8 && !looksLikeUTF16(name[:8]) {
+			entry.ModTime = cfb.Filetime(binary.LittleEndian.Uint64(name))
+			name = name[8:]
+		}
+		entry.Name = decodeUTF16(name)
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// looksLikeUTF16 判断数据是否为可打印 ASCII 字符组成的 UTF-16LE 字符串
+func looksLikeUTF16(b []byte) bool {
+	for i := 0; i+1 < len(b); i += 2 {
+		if b[i+1] != 0 || b[i] < 0x20 {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeUTF16 解码以 NUL 结尾的 UTF-16LE 字符串
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return string(utf16.Decode(u))
+}
+
+// ParseDesktopIni 解析 desktop.ini 中引用的同目录文件
+// [LocalizedFileNames] 段的键为目录中的文件名，IconFile、IconResource 等值可能是相对路径
+func ParseDesktopIni(data []byte) []string {
+	// desktop.ini 通常以 UTF-16LE 编码保存
+	if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+		data = []byte(decodeUTF16(append(data[2:], 0, 0)))
+	} else if !utf8.Valid(data) {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		if name == "" || seen[name] || strings.ContainsAny(name, `:%@`) || strings.HasPrefix(name, `\`) {
+			return
+		}
+		seen[name] = true
+		names = append(names, strings.ReplaceAll(name, `\`, "/"))
+	}
+
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line[1 : len(line)-1])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+
+		switch {
+		case section == "localizedfilenames":
+			add(key)
+		case strings.EqualFold(key, "IconFile"), strings.EqualFold(key, "IconResource"), strings.EqualFold(key, "Logo"):
+			// IconResource 的格式为 文件名,图标序号
+			if i := strings.LastIndex(value, ","); i >= 0 {
+				value = value[:i]
+			}
+			add(value)
+		}
+	}
+	return names
+}
@@ -0,0 +1,161 @@
+package thumbs
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/Thumbs.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseThumbsDB(t *testing.T) {
+	entries, err := ParseThumbsDB(readFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := []CatalogEntry{
+		{ID: 1, Name: "photo.jpg", ModTime: mod},
+		{ID: 2, Name: "中文.png", ModTime: mod},
+		{ID: 3, Name: "old.gif"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		if entries[i].ID != want[i].ID || entries[i].Name != want[i].Name || !entries[i].ModTime.Equal(want[i].ModTime) {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+// 测试文件中 FAT 和短扇区分配表所在的偏移，以及 Catalog 流的结束位置
+const (
+	fatOffset     = 512
+	miniFATOffset = 512 * 3
+	catalogEnd    = 512*4 + 112
+)
+
+func TestParseThumbsDBMalformed(t *testing.T) {
+	put := func(off int, v uint32) func([]byte) []byte {
+		return func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[off:], v)
+			return b
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+	}{
+		{"html page", func([]byte) []byte { return []byte("<html><body>404</body></html>") }},
+		{"truncated header", func(b []byte) []byte { return b[:100] }},
+		{"truncated FAT", func(b []byte) []byte { return b[:700] }},
+		{"truncated directory", func(b []byte) []byte { return b[:1100] }},
+		{"FAT self cycle", put(fatOffset+1*4, 1)},
+		{"FAT two-sector cycle", func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[fatOffset+1*4:], 3)
+			binary.LittleEndian.PutUint32(b[fatOffset+3*4:], 1)
+			return b
+		}},
+		{"FAT out of range", put(fatOffset+1*4, 5000)},
+		{"mini FAT cycle", put(miniFATOffset, 0)},
+		{"DIFAT cycle", func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[0x2c:], 1000)
+			binary.LittleEndian.PutUint32(b[0x44:], 0)
+			binary.LittleEndian.PutUint32(b[fatOffset+508:], 0)
+			return b
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.mutate(readFixture(t))
+			if err := parseWithTimeout(t, data); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestParseThumbsDBTruncated(t *testing.T) {
+	// 截断到任意长度都不能 panic 或卡住，截断了 Catalog 流时必须返回错误
+	data := readFixture(t)
+	for n := 0; n < len(data); n += 7 {
+		err := parseWithTimeout(t, data[:n])
+		if n < catalogEnd && err == nil {
+			t.Errorf("truncated to %d bytes: expected error", n)
+		}
+	}
+}
+
+func parseWithTimeout(t *testing.T, data []byte) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		_, err := ParseThumbsDB(data)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("ParseThumbsDB did not terminate")
+		return nil
+	}
+}
+
+func TestParseCatalogMalformed(t *testing.T) {
+	header := func(count uint32) []byte {
+		b := make([]byte, 16)
+		binary.LittleEndian.PutUint16(b[0:], 16)
+		binary.LittleEndian.PutUint32(b[4:], count)
+		return b
+	}
+	record := func(size uint32) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint32(b[0:], size)
+		return append(b, 'a', 0, 0, 0)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{"empty", nil, false},
+		{"short", make([]byte, 15), false},
+		{"zero size record", append(header(5), record(0)...), true},
+		{"huge size record", append(header(5), record(0xffffffff)...), true},
+		{"huge count", append(header(0xffffffff), record(12)...), true},
+		{"huge header length", append([]byte{0xff, 0xff}, header(1)[2:]...), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCatalog(tt.data)
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseDesktopIni(t *testing.T) {
+	ini := "[.ShellClassInfo]\r\nIconResource=icons\\folder.ico,0\r\nIconFile=C:\\Windows\\x.ico\r\n" +
+		"[LocalizedFileNames]\r\nreport.docx=@shell32.dll,-1\r\n\\evil=1\r\n"
+	got := strings.Join(ParseDesktopIni([]byte(ini)), ",")
+	if got != "icons/folder.ico,report.docx" {
+		t.Fatalf("ParseDesktopIni = %s", got)
+	}
+}
@@ -0,0 +1,128 @@
+// Package thumbs 实现 Windows Thumbs.db 和 desktop.ini 信息泄露的下载
+package thumbs
+
+import (
+	"bytes"
+	"net/http"
+	"path"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+	"dumpall-go/pkg/cfb"
+)
+
+// 递归解析子目录的最大深度
+const maxCrawlDepth = 16
+
+// ThumbsDumper 解析 Thumbs.db 和 desktop.ini 并下载其中记录的文件
+type ThumbsDumper struct {
+	dumper.BaseDumper
+	// Filter 下载文件的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 Thumbs.db 和 desktop.ini 中的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewThumbsDumper 创建 ThumbsDumper 实例
+func NewThumbsDumper() *ThumbsDumper {
+	return &ThumbsDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "thumbs",
+			Description: "下载 Thumbs.db 和 desktop.ini 中记录的文件",
+		},
+	}
+}
+
+// Check 检查目标是否存在 Thumbs.db 信息泄露
+func (d *ThumbsDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	data, ok := dumper.Fetch(client, soft404.Calibrate(client, targetURL), targetURL+"Thumbs.db", 0, nil)
+	return ok && bytes.HasPrefix(data, []byte(cfb.Magic)), nil
+}
+
+// Execute 执行下载操作
+func (d *ThumbsDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	pool := dumper.NewPool(workers)
+	defer pool.Wait()
+
+	baseline := soft404.Calibrate(client, targetURL)
+	d.crawl(client, baseline, pool, targetURL, outdir, "", 0, make(map[string]bool), progressCb)
+	return nil
+}
+
+// crawl 下载并解析当前目录的 Thumbs.db 和 desktop.ini，然后下载其中的文件并尝试递归子目录
+func (d *ThumbsDumper) crawl(client *http.Client, baseline *soft404.Baseline, pool *dumper.Pool, baseURL string, outdir string, relDir string, depth int, seen map[string]bool, progressCb dumper.ProgressCallback) {
+	var names []string
+
+	if data, ok := dumper.Fetch(client, baseline, baseURL+"Thumbs.db", 0, progressCb); ok && bytes.HasPrefix(data, []byte(cfb.Magic)) {
+		dumper.SaveData(outdir, relDir+"/Thumbs.db", data, baseURL+"Thumbs.db", progressCb)
+		entries, err := ParseThumbsDB(data)
+		if err != nil && progressCb != nil {
+			progressCb(baseURL+"Thumbs.db", 0, "解析 Thumbs.db 失败")
+		}
+		for _, e := range entries {
+			names = append(names, e.Name)
+		}
+	}
+
+	if data, ok := dumper.Fetch(client, baseline, baseURL+"desktop.ini", 0, progressCb); ok && looksLikeIni(data) {
+		dumper.SaveData(outdir, relDir+"/desktop.ini", data, baseURL+"desktop.ini", progressCb)
+		names = append(names, ParseDesktopIni(data)...)
+	}
+
+	for _, name := range names {
+		// Catalog 和 desktop.ini 中的名称应为当前目录下的文件
+		if name == "." || name == ".." || strings.HasPrefix(name, "/") || strings.Contains(name, `\`) {
+			continue
+		}
+		entryPath := relDir + "/" + name
+		if seen[entryPath] {
+			continue
+		}
+		seen[entryPath] = true
+
+		entryURL := baseURL + dumper.EscapePath(name)
+		d.Paths.Add(entryPath)
+		if d.Filter.Match(filter.Entry{Path: strings.TrimPrefix(entryPath, "/"), Size: -1}) {
+			pool.Go(func() {
+				dumper.Download(client, baseline, d.Filter, entryURL, outdir, entryPath, 0, progressCb)
+			})
+		}
+
+		// 没有扩展名的记录尝试作为子目录继续解析
+		if depth < maxCrawlDepth && path.Ext(name) == "" {
+			d.crawl(client, baseline, pool, entryURL+"/", outdir, entryPath, depth+1, seen, progressCb)
+		}
+	}
+}
+
+// looksLikeIni 粗略判断内容是否为 desktop.ini，排除返回 HTML 的自定义404页面
+func looksLikeIni(data []byte) bool {
+	if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+		return bytes.Contains(data, []byte{'[', 0})
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) || bytes.Contains(data, []byte("\n["))
+}
+
+// Validate 验证URL是否有效
+func (d *ThumbsDumper) Validate(url string) error {
+	return nil
+}
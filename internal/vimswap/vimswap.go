@@ -0,0 +1,317 @@
+// Package vimswap 解析 Vim 交换文件 (.swp) 并还原其中的文本
+package vimswap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// block 0 中各字段的偏移
+const (
+	b0VersionOff  = 2
+	b0PageSizeOff = 12
+	b0MtimeOff    = 16
+	b0PidOff      = 24
+	b0UnameOff    = 28
+	b0HnameOff    = 68
+	b0FnameOff    = 108
+	b0FnameSize   = 900
+	b0MagicOff    = b0FnameOff + b0FnameSize
+)
+
+// block 0 中的魔数，用于判断字节序和 long 的长度
+const (
+	b0MagicLong  = 0x30313233
+	b0MagicInt   = 0x20212223
+	b0MagicShort = 0x1213
+	b0MagicChar  = 0x55
+)
+
+// 指针块和数据块的标识
+const (
+	ptrID  = 'p'<<8 | 't'
+	dataID = 'd'<<8 | 'a'
+)
+
+const (
+	dbIndexMask  = 0x7fffffff
+	maxTreeDepth = 32
+	minPageSize  = 1024
+	maxPageSize  = 1 << 20
+)
+
+// 支持的交换文件扩展名，同一文件被多次打开时依次为 .swp、.swo、.swn ...
+var swapExts = []string{".swp", ".swo", ".swn"}
+
+// Swap 表示解析后的交换文件
+type Swap struct {
+	Version  string   // 创建交换文件的 Vim 版本
+	User     string   // 编辑文件的用户
+	Host     string   // 编辑文件的主机名
+	FileName string   // 被编辑文件的路径
+	PID      uint32   // Vim 进程号
+	MTime    uint32   // 被编辑文件的修改时间
+	Dirty    bool     // 缓冲区是否有未保存的修改
+	DOS      bool     // 文件是否使用 CRLF 换行
+	Lines    []string // 还原的文本行
+}
+
+// layout 记录交换文件的字节序和 long 的长度
+type layout struct {
+	order    binary.ByteOrder
+	longSize int
+}
+
+func (l layout) long(b []byte) int64 {
+	if l.longSize == 8 {
+		return int64(l.order.Uint64(b))
+	}
+	return int64(int32(l.order.Uint32(b)))
+}
+
+// detectLayout 根据 block 0 中的魔数判断交换文件的格式
+func detectLayout(b0 []byte) (layout, bool) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, size := range []int{8, 4} {
+			l := layout{order: order, longSize: size}
+			off := b0MagicOff
+			if len(b0) < off+size+7 {
+				continue
+			}
+			if l.long(b0[off:]) != b0MagicLong {
+				continue
+			}
+			off += size
+			if order.Uint32(b0[off:]) != b0MagicInt || order.Uint16(b0[off+4:]) != b0MagicShort || b0[off+6] != b0MagicChar {
+				continue
+			}
+			return l, true
+		}
+	}
+	return layout{}, false
+}
+
+// Parse 解析 Vim 交换文件并按指针块顺序还原所有文本行
+func Parse(data []byte) (*Swap, error) {
+	if len(data) < b0MagicOff+16 || data[0] != 'b' {
+		return nil, fmt.Errorf("不是有效的交换文件")
+	}
+	if data[1] != '0' {
+		return nil, fmt.Errorf("不支持加密的交换文件")
+	}
+
+	l, ok := detectLayout(data)
+	if !ok {
+		return nil, fmt.Errorf("无法识别交换文件格式")
+	}
+
+	// page size 等字段以固定的小端序写入
+	pageSize := int(binary.LittleEndian.Uint32(data[b0PageSizeOff:]))
+	if pageSize < minPageSize || pageSize > maxPageSize {
+		return nil, fmt.Errorf("页大小异常: %d", pageSize)
+	}
+
+	fname := data[b0FnameOff : b0FnameOff+b0FnameSize]
+	swap := &Swap{
+		Version:  cstring(data[b0VersionOff:b0PageSizeOff]),
+		User:     cstring(data[b0UnameOff:b0HnameOff]),
+		Host:     cstring(data[b0HnameOff:b0FnameOff]),
+		FileName: cstring(fname),
+		PID:      binary.LittleEndian.Uint32(data[b0PidOff:]),
+		MTime:    binary.LittleEndian.Uint32(data[b0MtimeOff:]),
+		Dirty:    fname[b0FnameSize-1] == 0x55,
+		DOS:      fname[b0FnameSize-2]&3 == 2,
+	}
+
+	r := &reader{data: data, pageSize: pageSize, layout: l, visited: make(map[int64]bool)}
+	r.walk(1, 1, 0, swap)
+	if len(swap.Lines) == 0 {
+		return swap, fmt.Errorf("交换文件中没有可还原的文本")
+	}
+	return swap, nil
+}
+
+// Text 返回还原的文本内容
+func (s *Swap) Text() []byte {
+	eol := "\n"
+	if s.DOS {
+		eol = "\r\n"
+	}
+	var buf bytes.Buffer
+	for _, line := range s.Lines {
+		buf.WriteString(line)
+		buf.WriteString(eol)
+	}
+	return buf.Bytes()
+}
+
+// reader 遍历交换文件中的指针块树
+type reader struct {
+	data     []byte
+	pageSize int
+	layout   layout
+	visited  map[int64]bool
+}
+
+// block 返回从块号开始的若干页，超出文件范围时返回 nil
+func (r *reader) block(bnum int64, pages int) []byte {
+	// 块号来自文件内容，先和总页数比较再相乘，避免溢出
+	total := int64(len(r.data)) / int64(r.pageSize)
+	if bnum <= 0 || pages <= 0 || bnum >= total || int64(pages) > total-bnum {
+		return nil
+	}
+	start := bnum * int64(r.pageSize)
+	return r.data[start : start+int64(pages)*int64(r.pageSize)]
+}
+
+// walk 按顺序遍历指针块和数据块，尚未写入交换文件的块 (负块号) 会被跳过
+func (r *reader) walk(bnum int64, pages int, depth int, swap *Swap) {
+	if depth > maxTreeDepth || r.visited[bnum] {
+		return
+	}
+	b := r.block(bnum, pages)
+	if b == nil {
+		return
+	}
+	r.visited[bnum] = true
+
+	order := r.layout.order
+	switch order.Uint16(b) {
+	case ptrID:
+		r.walkPointers(b, depth, swap)
+	case dataID:
+		swap.Lines = append(swap.Lines, r.dataLines(b)...)
+	}
+}
+
+// walkPointers 遍历指针块中的所有条目
+func (r *reader) walkPointers(b []byte, depth int, swap *Swap) {
+	order := r.layout.order
+	ls := r.layout.longSize
+	count := int(order.Uint16(b[2:]))
+
+	// pointer_entry 由三个 long 和一个 int 组成，按 long 对齐
+	entrySize := 3*ls + 4
+	if entrySize%ls != 0 {
+		entrySize += ls - entrySize%ls
+	}
+	off := 8
+	for i := 0; i < count; i++ {
+		e := off + i*entrySize
+		if e+entrySize > len(b) {
+			return
+		}
+		bnum := r.layout.long(b[e:])
+		pageCount := int(int32(order.Uint32(b[e+3*ls:])))
+		if pageCount <= 0 {
+			pageCount = 1
+		}
+
+		// 数据块可能跨越多页，需要先读取块头判断类型
+		if head := r.block(bnum, 1); head != nil && order.Uint16(head) == dataID {
+			r.walk(bnum, pageCount, depth+1, swap)
+		} else {
+			r.walk(bnum, 1, depth+1, swap)
+		}
+	}
+}
+
+// dataLines 读取数据块中的文本行
+func (r *reader) dataLines(b []byte) []string {
+	order := r.layout.order
+	ls := r.layout.longSize
+	if len(b) < 16+ls {
+		return nil
+	}
+	txtEnd := int(order.Uint32(b[12:]))
+	if txtEnd > len(b) {
+		txtEnd = len(b)
+	}
+	count := r.layout.long(b[16:])
+
+	var lines []string
+	idx := 16 + ls
+	for i := int64(0); i < count; i++ {
+		p := idx + int(i)*4
+		if p+4 > len(b) {
+			break
+		}
+		start := int(order.Uint32(b[p:]) & dbIndexMask)
+		if start >= txtEnd {
+			break
+		}
+		lines = append(lines, cstring(b[start:txtEnd]))
+	}
+	return lines
+}
+
+// cstring 返回以 NUL 结尾的字符串
+func cstring(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// IsSwapName 判断文件名是否为 Vim 交换文件
+func IsSwapName(name string) bool {
+	for _, ext := range swapExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecoveredName 返回交换文件对应的还原文件名
+// 例如 .config.php.swp 和 config.php.swp 都还原为 config.php.recovered
+func RecoveredName(swapPath string) string {
+	dir, base := filepath.Split(swapPath)
+	for _, ext := range swapExts {
+		if strings.HasSuffix(base, ext) {
+			base = strings.TrimSuffix(base, ext)
+			break
+		}
+	}
+	if trimmed := strings.TrimPrefix(base, "."); trimmed != "" {
+		base = trimmed
+	}
+	return filepath.Join(dir, base+".recovered")
+}
+
+// RecoverFile 解析本地的交换文件，并把还原的文本写入同目录下的 .recovered 文件
+func RecoverFile(swapPath string) (string, error) {
+	data, err := os.ReadFile(swapPath)
+	if err != nil {
+		return "", fmt.Errorf("读取交换文件失败: %v", err)
+	}
+	swap, err := Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	name := RecoveredName(swapPath)
+	if err := os.WriteFile(name, swap.Text(), 0644); err != nil {
+		return "", fmt.Errorf("写入还原文件失败: %v", err)
+	}
+	return name, nil
+}
+
+// RecoverDir 还原目录中所有的交换文件，返回生成的 .recovered 文件
+func RecoverDir(dir string) []string {
+	var recovered []string
+	filepath.WalkDir(dir, func(p string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !IsSwapName(entry.Name()) {
+			return nil
+		}
+		if name, err := RecoverFile(p); err == nil {
+			recovered = append(recovered, name)
+		}
+		return nil
+	})
+	return recovered
+}
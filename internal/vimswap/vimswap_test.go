@@ -0,0 +1,189 @@
+package vimswap
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testdata/.config.php.swp 由 Vim 9.0 在 x86_64 上编辑 /var/www/html/config.php 时生成
+func TestParseFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/.config.php.swp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	swap, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if swap.Version != "VIM 9.0" || swap.User != "root" || swap.FileName != "/var/www/html/config.php" || !swap.Dirty {
+		t.Errorf("swap = %+v", swap)
+	}
+	want := []string{"<?php", `$db_pass = "secret";`, "// 中文注释", "return $db_pass;"}
+	if !reflect.DeepEqual(swap.Lines, want) {
+		t.Errorf("Lines = %q, want %q", swap.Lines, want)
+	}
+}
+
+// swapBuilder 按指定的字节序和 long 长度构造交换文件
+type swapBuilder struct {
+	order    binary.ByteOrder
+	longSize int
+	pages    [][]byte
+}
+
+const testPageSize = minPageSize
+
+func newSwapBuilder(order binary.ByteOrder, longSize int) *swapBuilder {
+	b := &swapBuilder{order: order, longSize: longSize}
+
+	b0 := make([]byte, testPageSize)
+	copy(b0, "b0VIM 9.0")
+	binary.LittleEndian.PutUint32(b0[b0PageSizeOff:], testPageSize)
+	copy(b0[b0UnameOff:], "www")
+	copy(b0[b0FnameOff:], "config.php")
+	b0[b0FnameOff+b0FnameSize-1] = 0x55
+	off := b0MagicOff
+	b.putLong(b0[off:], b0MagicLong)
+	off += longSize
+	order.PutUint32(b0[off:], b0MagicInt)
+	order.PutUint16(b0[off+4:], b0MagicShort)
+	b0[off+6] = b0MagicChar
+	b.pages = append(b.pages, b0)
+	return b
+}
+
+func (b *swapBuilder) putLong(p []byte, v int64) {
+	if b.longSize == 8 {
+		b.order.PutUint64(p, uint64(v))
+	} else {
+		b.order.PutUint32(p, uint32(v))
+	}
+}
+
+// pointer 追加一个指针块，条目为块号和页数
+func (b *swapBuilder) pointer(entries ...[2]int64) {
+	p := make([]byte, testPageSize)
+	b.order.PutUint16(p, ptrID)
+	b.order.PutUint16(p[2:], uint16(len(entries)))
+	entrySize := 3*b.longSize + 4
+	if entrySize%b.longSize != 0 {
+		entrySize += b.longSize - entrySize%b.longSize
+	}
+	for i, e := range entries {
+		off := 8 + i*entrySize
+		b.putLong(p[off:], e[0])
+		b.order.PutUint32(p[off+3*b.longSize:], uint32(e[1]))
+	}
+	b.pages = append(b.pages, p)
+}
+
+// data 追加一个数据块，文本从页尾向前存放
+func (b *swapBuilder) data(lines ...string) {
+	p := make([]byte, testPageSize)
+	b.order.PutUint16(p, dataID)
+	b.order.PutUint32(p[12:], testPageSize)
+	b.putLong(p[16:], int64(len(lines)))
+	end := testPageSize
+	for i, line := range lines {
+		end -= len(line) + 1
+		copy(p[end:], line)
+		b.order.PutUint32(p[16+b.longSize+i*4:], uint32(end))
+	}
+	b.order.PutUint32(p[8:], uint32(end))
+	b.pages = append(b.pages, p)
+}
+
+func (b *swapBuilder) bytes() []byte {
+	var out []byte
+	for _, p := range b.pages {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestParseLayouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    binary.ByteOrder
+		longSize int
+	}{
+		{"小端 64 位", binary.LittleEndian, 8},
+		{"小端 32 位", binary.LittleEndian, 4},
+		{"大端 64 位", binary.BigEndian, 8},
+		{"大端 32 位", binary.BigEndian, 4},
+	}
+	for _, tt := range tests {
+		b := newSwapBuilder(tt.order, tt.longSize)
+		b.pointer([2]int64{2, 1}, [2]int64{3, 1})
+		b.data("a", "b")
+		b.data("c")
+
+		swap, err := Parse(b.bytes())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := []string{"a", "b", "c"}; !reflect.DeepEqual(swap.Lines, want) {
+			t.Errorf("%s: Lines = %q, want %q", tt.name, swap.Lines, want)
+		}
+		if swap.User != "www" || swap.FileName != "config.php" || !swap.Dirty {
+			t.Errorf("%s: swap = %+v", tt.name, swap)
+		}
+	}
+}
+
+func TestParseHostileBlocks(t *testing.T) {
+	// 块号超出文件、溢出、为负或指向自身时跳过，只还原合法的数据块
+	b := newSwapBuilder(binary.LittleEndian, 8)
+	b.pointer(
+		[2]int64{1 << 51, 1},
+		[2]int64{1<<62 + 1, 1},
+		[2]int64{-3, 1},
+		[2]int64{1, 1},
+		[2]int64{2, 1 << 30},
+		[2]int64{2, 1},
+	)
+	b.data("ok")
+
+	swap, err := Parse(b.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ok"}; !reflect.DeepEqual(swap.Lines, want) {
+		t.Errorf("Lines = %q, want %q", swap.Lines, want)
+	}
+
+	r := &reader{data: b.bytes(), pageSize: testPageSize}
+	for _, bnum := range []int64{1 << 51, 1 << 62, -1, 3} {
+		if r.block(bnum, 1) != nil {
+			t.Errorf("block(%d) 应返回 nil", bnum)
+		}
+	}
+	if r.block(2, 1<<30) != nil {
+		t.Error("超出文件的页数应返回 nil")
+	}
+}
+
+func TestRecoverDir(t *testing.T) {
+	data, err := os.ReadFile("testdata/.config.php.swp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".config.php.swp"), data, 0644)
+	os.WriteFile(filepath.Join(dir, "broken.swp"), []byte("b0VIM"), 0644)
+
+	got := RecoverDir(dir)
+	want := filepath.Join(dir, "config.php.recovered")
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("RecoverDir = %q, want [%q]", got, want)
+	}
+	text, _ := os.ReadFile(want)
+	if string(text) != "<?php\n$db_pass = \"secret\";\n// 中文注释\nreturn $db_pass;\n" {
+		t.Errorf("还原内容 = %q", text)
+	}
+}
@@ -0,0 +1,100 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PROPFIND 请求体，只请求下载需要的属性
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop>
+    <D:resourcetype/>
+    <D:getcontentlength/>
+    <D:getlastmodified/>
+    <D:displayname/>
+  </D:prop>
+</D:propfind>`
+
+// Resource 表示 PROPFIND 响应中的一个资源
+type Resource struct {
+	URL     string    // 资源的完整URL
+	Path    string    // 解码后的URL路径
+	Size    int64     // 文件大小，未知时为 -1
+	ModTime time.Time // 修改时间，未知时为零值
+	IsDir   bool      // 是否为集合（目录）
+}
+
+// multistatus 对应 207 Multi-Status 响应体
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   prop   `xml:"DAV: prop"`
+}
+
+type prop struct {
+	ResourceType  resourceType `xml:"DAV: resourcetype"`
+	ContentLength string       `xml:"DAV: getcontentlength"`
+	LastModified  string       `xml:"DAV: getlastmodified"`
+	DisplayName   string       `xml:"DAV: displayname"`
+}
+
+type resourceType struct {
+	Collection *struct{} `xml:"DAV: collection"`
+}
+
+// parseMultistatus 解析 multistatus XML，href 相对于 base 解析为完整URL
+func parseMultistatus(base *url.URL, data []byte) ([]Resource, error) {
+	var ms multistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("解析 multistatus 失败: %v", err)
+	}
+
+	var resources []Resource
+	for _, r := range ms.Responses {
+		href := strings.TrimSpace(r.Href)
+		if href == "" {
+			continue
+		}
+		u, err := base.Parse(href)
+		if err != nil {
+			continue
+		}
+
+		res := Resource{URL: u.String(), Path: u.Path, Size: -1}
+		for _, ps := range r.Propstats {
+			// 只使用状态为 200 的属性
+			if ps.Status != "" && !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			if ps.Prop.ResourceType.Collection != nil {
+				res.IsDir = true
+			}
+			if size, err := strconv.ParseInt(strings.TrimSpace(ps.Prop.ContentLength), 10, 64); err == nil {
+				res.Size = size
+			}
+			if t, err := time.Parse(time.RFC1123, strings.TrimSpace(ps.Prop.LastModified)); err == nil {
+				res.ModTime = t
+			}
+		}
+		// 部分服务器不返回 resourcetype，以 / 结尾的 href 视为目录
+		if strings.HasSuffix(u.Path, "/") {
+			res.IsDir = true
+		}
+		resources = append(resources, res)
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,248 @@
+package webdav
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// 默认的递归深度和文件数量上限，与目录列表保持一致
+const (
+	DefaultMaxDepth = 10
+	DefaultMaxFiles = 10000
+)
+
+// PROPFIND 响应体的最大长度
+const maxMultistatusSize = 32 << 20
+
+// WebDAVDumper 通过 PROPFIND 枚举并下载 WebDAV 目录中的文件
+type WebDAVDumper struct {
+	dumper.BaseDumper
+	MaxDepth int // 最大递归深度，0 表示不限制
+	MaxFiles int // 最多下载的文件数量，0 表示不限制
+	// Truncated 执行后为 true 表示达到了 MaxFiles 上限，下载的文件不完整
+	Truncated bool
+	// Filter 下载文件的过滤条件
+	Filter *filter.Filter
+	// Paths 记录 PROPFIND 返回的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewWebDAVDumper 创建 WebDAVDumper 实例
+func NewWebDAVDumper() *WebDAVDumper {
+	return &WebDAVDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "webdav",
+			Description: "通过 WebDAV PROPFIND 下载目录中的文件",
+		},
+		MaxDepth: DefaultMaxDepth,
+		MaxFiles: DefaultMaxFiles,
+	}
+}
+
+// crawlState 记录一次 WebDAV 遍历的状态
+type crawlState struct {
+	client     *http.Client
+	baseline   *soft404.Baseline
+	root       *url.URL
+	rootPath   string
+	outdir     string
+	visited    map[string]bool
+	files      int
+	pool       *dumper.Pool
+	progressCb dumper.ProgressCallback
+}
+
+// relPath 返回资源相对于起始目录的路径，不在起始目录下时返回 false
+func (s *crawlState) relPath(res Resource) (string, bool) {
+	p := path.Clean(res.Path)
+	if res.IsDir {
+		p += "/"
+	}
+	if !strings.HasPrefix(p, s.rootPath) {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(p, s.rootPath), "/"), true
+}
+
+// inScope 判断资源URL是否与起始URL同源
+func (s *crawlState) inScope(res Resource) bool {
+	u, err := url.Parse(res.URL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == s.root.Scheme && strings.EqualFold(u.Host, s.root.Host)
+}
+
+// Check 检查目标是否开启了 WebDAV
+func (d *WebDAVDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	_, status, err := propfind(client, targetURL, "0")
+	if err != nil {
+		return false, nil
+	}
+	return status == http.StatusMultiStatus, nil
+}
+
+// propfind 发送 PROPFIND 请求并解析响应，返回资源列表和状态码
+func propfind(client *http.Client, targetURL string, depth string) ([]Resource, int, error) {
+	req, err := http.NewRequest("PROPFIND", targetURL, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, resp.StatusCode, fmt.Errorf("响应状态码异常: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMultistatusSize))
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	resources, err := parseMultistatus(resp.Request.URL, body)
+	return resources, resp.StatusCode, err
+}
+
+// Execute 执行下载操作
+func (d *WebDAVDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	root, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("URL解析失败: %v", err)
+	}
+
+	// 没有开启 WebDAV 时直接返回
+	if ok, _ := d.Check(targetURL, client); !ok {
+		return nil
+	}
+
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	state := &crawlState{
+		client:     client,
+		baseline:   soft404.Calibrate(client, targetURL),
+		root:       root,
+		rootPath:   path.Clean(root.Path) + "/",
+		outdir:     outdir,
+		visited:    make(map[string]bool),
+		pool:       dumper.NewPool(workers),
+		progressCb: progressCb,
+	}
+	if state.rootPath == "//" {
+		state.rootPath = "/"
+	}
+	defer state.pool.Wait()
+
+	// 优先尝试 Depth: infinity 一次获取整个目录树，服务器拒绝时逐层请求
+	if resources, _, err := propfind(client, targetURL, "infinity"); err == nil {
+		d.process(state, resources, 0, false)
+		return nil
+	}
+
+	d.crawl(state, targetURL, 0)
+	return nil
+}
+
+// crawl 使用 Depth: 1 逐层遍历目录
+func (d *WebDAVDumper) crawl(state *crawlState, dirURL string, depth int) {
+	resources, _, err := propfind(state.client, dirURL, "1")
+	if err != nil {
+		return
+	}
+	d.process(state, resources, depth, true)
+}
+
+// process 处理一次 PROPFIND 返回的资源，下载文件，recurse 为 true 时递归子目录
+// 文件交给 pool 并发下载，子目录在当前 goroutine 中依次请求
+func (d *WebDAVDumper) process(state *crawlState, resources []Resource, depth int, recurse bool) {
+	progressCb := state.progressCb
+
+	for _, res := range resources {
+		// 子目录中已达到上限时不再继续
+		if d.Truncated {
+			return
+		}
+		if !state.inScope(res) {
+			continue
+		}
+
+		rel, ok := state.relPath(res)
+		if !ok || rel == "" || state.visited[rel] {
+			continue
+		}
+		state.visited[rel] = true
+
+		// 资源路径来自远程响应，越出输出目录的路径直接跳过
+		if _, err := dumper.SafePath(state.outdir, rel); err != nil {
+			if progressCb != nil {
+				progressCb(res.URL, 0, "非法路径")
+			}
+			continue
+		}
+
+		if res.IsDir {
+			if recurse && (d.MaxDepth == 0 || depth+1 <= d.MaxDepth) {
+				d.crawl(state, res.URL, depth+1)
+			}
+			continue
+		}
+		d.Paths.Add(rel)
+
+		// Depth: infinity 一次返回所有层级，根据路径计算文件所在目录的深度
+		if !recurse && d.MaxDepth > 0 && strings.Count(rel, "/") > d.MaxDepth {
+			continue
+		}
+
+		if !d.Filter.Match(filter.Entry{Path: rel, Size: res.Size, ModTime: res.ModTime}) {
+			continue
+		}
+
+		// 只有还有需要下载的文件时才算作被截断
+		if d.MaxFiles > 0 && state.files >= d.MaxFiles {
+			d.Truncated = true
+			return
+		}
+		state.files++
+		state.pool.Go(func() {
+			dumper.DownloadStream(state.client, state.baseline, d.Filter, res.URL, state.outdir, rel, state.progressCb)
+		})
+	}
+}
+
+// Validate 验证URL是否有效
+func (d *WebDAVDumper) Validate(url string) error {
+	return nil
+}
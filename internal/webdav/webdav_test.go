@@ -0,0 +1,206 @@
+package webdav
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseMultistatus(t *testing.T) {
+	data, err := os.ReadFile("testdata/multistatus.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("http://example.com/dav/")
+	resources, err := parseMultistatus(base, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jan2 := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []Resource{
+		{URL: "http://example.com/dav/", Path: "/dav/", Size: -1, ModTime: jan2, IsDir: true},
+		{URL: "http://example.com/dav/backup.sql", Path: "/dav/backup.sql", Size: 1048576, ModTime: jan2},
+		{URL: "http://example.com/dav/%e4%b8%ad%e6%96%87.txt", Path: "/dav/中文.txt", Size: 12,
+			ModTime: time.Date(2023, 2, 1, 12, 30, 0, 0, time.UTC)},
+		// 没有 resourcetype 但以 / 结尾
+		{URL: "http://example.com/dav/images/", Path: "/dav/images/", Size: -1, ModTime: jan2, IsDir: true},
+		// 状态不是 200 的属性被忽略
+		{URL: "http://example.com/dav/old.log", Path: "/dav/old.log", Size: -1},
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("得到 %d 个资源, want %d: %+v", len(resources), len(want), resources)
+	}
+	for i := range want {
+		got := resources[i]
+		if got.URL != want[i].URL || got.Path != want[i].Path || got.Size != want[i].Size ||
+			got.IsDir != want[i].IsDir || !got.ModTime.Equal(want[i].ModTime) {
+			t.Errorf("resources[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if _, err := parseMultistatus(base, []byte("<D:multistatus")); err == nil {
+		t.Error("无效的 XML 应返回错误")
+	}
+}
+
+func TestRelPathInScope(t *testing.T) {
+	root, _ := url.Parse("http://example.com/dav/")
+	s := &crawlState{root: root, rootPath: "/dav/"}
+	tests := []struct {
+		res   Resource
+		rel   string
+		ok    bool
+		scope bool
+	}{
+		{Resource{URL: "http://example.com/dav/", Path: "/dav/", IsDir: true}, "", true, true},
+		{Resource{URL: "http://example.com/dav/a/b.txt", Path: "/dav/a/b.txt"}, "a/b.txt", true, true},
+		{Resource{URL: "http://EXAMPLE.com/dav/sub/", Path: "/dav/sub/", IsDir: true}, "sub", true, true},
+		{Resource{URL: "http://example.com/dav", Path: "/dav"}, "", false, true},
+		{Resource{URL: "http://example.com/davx/a.txt", Path: "/davx/a.txt"}, "", false, true},
+		{Resource{URL: "http://example.com/dav/../etc/passwd", Path: "/dav/../etc/passwd"}, "", false, true},
+		{Resource{URL: "https://example.com/dav/a.txt", Path: "/dav/a.txt"}, "a.txt", true, false},
+		{Resource{URL: "http://evil.example/dav/a.txt", Path: "/dav/a.txt"}, "a.txt", true, false},
+	}
+	for _, tt := range tests {
+		rel, ok := s.relPath(tt.res)
+		if rel != tt.rel || ok != tt.ok {
+			t.Errorf("relPath(%s) = %q, %v, want %q, %v", tt.res.URL, rel, ok, tt.rel, tt.ok)
+		}
+		if scope := s.inScope(tt.res); scope != tt.scope {
+			t.Errorf("inScope(%s) = %v, want %v", tt.res.URL, scope, tt.scope)
+		}
+	}
+}
+
+// davServer 模拟 /dav/ 下的 WebDAV 目录，infinity 为 false 时拒绝 Depth: infinity
+// 根目录的列表中还包含其他站点和起始目录之外的资源
+type davServer struct {
+	*httptest.Server
+	infinity bool
+
+	mu     sync.Mutex
+	depths []string
+}
+
+var davTree = []string{"/dav/", "/dav/a.txt", "/dav/sub/", "/dav/sub/b.txt", "/dav/sub/deep/", "/dav/sub/deep/c.txt"}
+
+func newDavServer(t *testing.T, infinity bool) *davServer {
+	s := &davServer{infinity: infinity}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *davServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PROPFIND" {
+		for _, p := range davTree {
+			if p == r.URL.Path && !strings.HasSuffix(p, "/") {
+				w.Write([]byte(p))
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	depth := r.Header.Get("Depth")
+	s.mu.Lock()
+	s.depths = append(s.depths, depth)
+	s.mu.Unlock()
+	if depth == "infinity" && !s.infinity {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var buf strings.Builder
+	buf.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`)
+	for _, p := range davTree {
+		rest, ok := strings.CutPrefix(p, r.URL.Path)
+		if !ok {
+			continue
+		}
+		level := strings.Count(strings.TrimSuffix(rest, "/"), "/")
+		if rest != "" && (depth == "0" || depth == "1" && level > 0) {
+			continue
+		}
+		prop := fmt.Sprintf("<D:getcontentlength>%d</D:getcontentlength>", len(p))
+		if strings.HasSuffix(p, "/") {
+			prop = "<D:resourcetype><D:collection/></D:resourcetype>"
+		}
+		fmt.Fprintf(&buf, `<D:response><D:href>%s</D:href><D:propstat><D:prop>%s</D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`, p, prop)
+	}
+	if r.URL.Path == "/dav/" && depth != "0" {
+		buf.WriteString(`<D:response><D:href>http://evil.example/dav/evil.txt</D:href></D:response>`)
+		buf.WriteString(`<D:response><D:href>/other/secret.txt</D:href></D:response>`)
+	}
+	buf.WriteString(`</D:multistatus>`)
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(buf.String()))
+}
+
+func savedFiles(outdir string) []string {
+	var files []string
+	filepath.WalkDir(outdir, func(p string, e os.DirEntry, err error) error {
+		if err == nil && !e.IsDir() {
+			rel, _ := filepath.Rel(outdir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		infinity  bool
+		maxDepth  int
+		maxFiles  int
+		files     []string
+		depths    string
+		truncated bool
+	}{
+		{"Depth infinity", true, 0, 0, []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"}, "0,infinity", false},
+		{"不支持 infinity 时逐层请求", false, 0, 0, []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"}, "0,infinity,1,1,1", false},
+		{"infinity 限制深度", true, 1, 0, []string{"a.txt", "sub/b.txt"}, "0,infinity", false},
+		{"逐层请求限制深度", false, 1, 0, []string{"a.txt", "sub/b.txt"}, "0,infinity,1,1", false},
+		{"达到文件数量上限", true, 0, 2, []string{"a.txt", "sub/b.txt"}, "0,infinity", true},
+		{"逐层请求达到文件数量上限", false, 0, 1, []string{"a.txt"}, "0,infinity,1,1", true},
+		{"文件数量等于上限", true, 0, 3, []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"}, "0,infinity", false},
+	}
+	for _, tt := range tests {
+		srv := newDavServer(t, tt.infinity)
+		d := NewWebDAVDumper()
+		d.MaxDepth = tt.maxDepth
+		d.MaxFiles = tt.maxFiles
+		outdir := t.TempDir()
+		if err := d.Execute(srv.URL+"/dav", outdir, "", false, false, 4, nil); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if files := savedFiles(outdir); !reflect.DeepEqual(files, tt.files) {
+			t.Errorf("%s: 保存了 %q, want %q", tt.name, files, tt.files)
+		}
+		if depths := strings.Join(srv.depths, ","); depths != tt.depths {
+			t.Errorf("%s: Depth = %s, want %s", tt.name, depths, tt.depths)
+		}
+		if d.Truncated != tt.truncated {
+			t.Errorf("%s: Truncated = %v, want %v", tt.name, d.Truncated, tt.truncated)
+		}
+		if data, _ := os.ReadFile(filepath.Join(outdir, "a.txt")); string(data) != "/dav/a.txt" {
+			t.Errorf("%s: a.txt = %q", tt.name, data)
+		}
+	}
+}
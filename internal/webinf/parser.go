@@ -0,0 +1,243 @@
+package webinf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Descriptor 表示从 web.xml 或 Spring/Struts 配置中解析出的引用
+type Descriptor struct {
+	Classes []string // 引用的 Java 类名
+	Configs []string // 引用的其他配置文件，相对于 Web 应用根目录
+}
+
+// 框架自身的类位于 WEB-INF/lib 的 jar 中，不需要单独下载
+var frameworkPrefixes = []string{
+	"java.", "javax.", "jakarta.", "sun.", "com.sun.",
+	"org.springframework.", "org.apache.", "org.hibernate.", "org.mybatis.",
+	"com.opensymphony.", "org.eclipse.", "com.alibaba.druid.", "ch.qos.",
+}
+
+var (
+	// web.xml 中 servlet/filter/listener 的类名
+	webXMLClassRe = regexp.MustCompile(`<(?:servlet|filter|listener)-class>\s*([^<\s]+)\s*</`)
+	// Spring、Struts 配置中 class="..." 或 type="..." 形式的类名
+	classAttrRe = regexp.MustCompile(`\b(?:class|type)\s*=\s*"([A-Za-z_][\w$]*(?:\.[A-Za-z_][\w$]*)+)"`)
+	// 配置之间的引用: Spring 的 <import resource>、Struts 的 <include file>、MyBatis 的 <mapper resource>
+	configRefRe = regexp.MustCompile(`<(?:import|include|mapper)\b[^>]*\b(?:resource|file)\s*=\s*"([^"]+\.xml)"`)
+	// java 类名
+	classNameRe = regexp.MustCompile(`^[A-Za-z_][\w$]*(\.[A-Za-z_][\w$]*)+$`)
+)
+
+// webApp 对应 web.xml 中需要解析的部分
+type webApp struct {
+	ContextParams []param   `xml:"context-param"`
+	Servlets      []servlet `xml:"servlet"`
+	Filters       []servlet `xml:"filter"`
+}
+
+type param struct {
+	Name  string `xml:"param-name"`
+	Value string `xml:"param-value"`
+}
+
+type servlet struct {
+	Name       string  `xml:"servlet-name"`
+	Class      string  `xml:"servlet-class"`
+	FilterCls  string  `xml:"filter-class"`
+	InitParams []param `xml:"init-param"`
+}
+
+// ParseWebXML 解析 web.xml 中引用的类和配置文件
+func ParseWebXML(data []byte) Descriptor {
+	var desc Descriptor
+	for _, m := range webXMLClassRe.FindAllSubmatch(data, -1) {
+		desc.Classes = append(desc.Classes, string(m[1]))
+	}
+
+	var app webApp
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	if err := dec.Decode(&app); err != nil {
+		return desc.dedupe()
+	}
+
+	// contextConfigLocation 和 Struts 1 的 config 参数中列出的配置文件
+	addParams := func(params []param) {
+		for _, p := range params {
+			switch p.Name {
+			case "contextConfigLocation", "config", "configLocation":
+				for _, ref := range splitLocations(p.Value) {
+					if cfg := ResolveConfig("WEB-INF/web.xml", ref); cfg != "" {
+						desc.Configs = append(desc.Configs, cfg)
+					}
+				}
+			}
+		}
+	}
+	addParams(app.ContextParams)
+
+	hasContextConfig := false
+	for _, p := range app.ContextParams {
+		if p.Name == "contextConfigLocation" {
+			hasContextConfig = true
+		}
+	}
+	for _, s := range app.Servlets {
+		addParams(s.InitParams)
+
+		// DispatcherServlet 未指定配置时默认读取 WEB-INF/<servlet-name>-servlet.xml
+		if strings.HasSuffix(s.Class, "DispatcherServlet") && !hasParam(s.InitParams, "contextConfigLocation") && s.Name != "" {
+			desc.Configs = append(desc.Configs, "WEB-INF/"+s.Name+"-servlet.xml")
+		}
+	}
+	for _, f := range app.Filters {
+		addParams(f.InitParams)
+
+		// Struts 2 的过滤器默认读取 classpath 下的 struts.xml
+		if strings.Contains(f.FilterCls, "struts2") {
+			desc.Configs = append(desc.Configs, "WEB-INF/classes/struts.xml")
+		}
+	}
+	if !hasContextConfig && bytes.Contains(data, []byte("ContextLoaderListener")) {
+		desc.Configs = append(desc.Configs, "WEB-INF/applicationContext.xml")
+	}
+
+	return desc.dedupe()
+}
+
+func hasParam(params []param, name string) bool {
+	for _, p := range params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseConfig 解析 Spring、Struts、MyBatis 等 XML 配置中引用的类和其他配置文件
+// name 为配置文件相对于 Web 应用根目录的路径，用于解析相对引用
+func ParseConfig(name string, data []byte) Descriptor {
+	var desc Descriptor
+	for _, m := range classAttrRe.FindAllSubmatch(data, -1) {
+		desc.Classes = append(desc.Classes, string(m[1]))
+	}
+	for _, m := range configRefRe.FindAllSubmatch(data, -1) {
+		if cfg := ResolveConfig(name, string(m[1])); cfg != "" {
+			desc.Configs = append(desc.Configs, cfg)
+		}
+	}
+	return desc.dedupe()
+}
+
+func (d Descriptor) dedupe() Descriptor {
+	var out Descriptor
+	seen := make(map[string]bool)
+	for _, c := range d.Classes {
+		if !seen["c:"+c] && classNameRe.MatchString(c) && !isFramework(c) {
+			seen["c:"+c] = true
+			out.Classes = append(out.Classes, c)
+		}
+	}
+	for _, c := range d.Configs {
+		if !seen["f:"+c] {
+			seen["f:"+c] = true
+			out.Configs = append(out.Configs, c)
+		}
+	}
+	return out
+}
+
+func isFramework(class string) bool {
+	for _, prefix := range frameworkPrefixes {
+		if strings.HasPrefix(class, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLocations 拆分以逗号、分号或空白分隔的配置路径
+func splitLocations(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
+
+// ResolveConfig 将配置中的引用转换为相对于 Web 应用根目录的路径
+// classpath: 前缀对应 WEB-INF/classes，带通配符的路径无法直接下载，返回空字符串
+func ResolveConfig(from string, ref string) string {
+	ref = strings.Replace(strings.TrimSpace(ref), "classpath*:", "classpath:", 1)
+	if ref == "" || strings.ContainsAny(ref, "*?${}") {
+		return ""
+	}
+
+	var p string
+	switch {
+	case strings.HasPrefix(ref, "classpath:"):
+		p = "WEB-INF/classes/" + strings.TrimPrefix(ref, "classpath:")
+	case strings.HasPrefix(ref, "/"):
+		p = ref
+	case strings.Contains(ref, ":"):
+		return ""
+	case strings.HasPrefix(from, "WEB-INF/classes/") && !strings.HasPrefix(ref, "WEB-INF/"):
+		// classpath 中的配置引用一般相对于 classpath 根目录
+		p = "WEB-INF/classes/" + ref
+	default:
+		p = path.Join(path.Dir(from), ref)
+	}
+
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" || p == "." {
+		return ""
+	}
+	return p
+}
+
+// ClassPath 返回类文件在 WEB-INF/classes 下的路径，内部类使用 $ 分隔
+func ClassPath(class string) string {
+	return "WEB-INF/classes/" + strings.ReplaceAll(class, ".", "/") + ".class"
+}
+
+// ParseManifest 解析 MANIFEST.MF 中的属性，续行以单个空格开头
+func ParseManifest(data []byte) map[string]string {
+	attrs := make(map[string]string)
+	var key string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, " ") && key != "" {
+			attrs[key] += line[1:]
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			key = ""
+			continue
+		}
+		key = strings.TrimSpace(k)
+		attrs[key] = strings.TrimSpace(v)
+	}
+	return attrs
+}
+
+// ManifestJars 返回 MANIFEST.MF 的 Class-Path 中引用的 jar 包在 WEB-INF/lib 下的路径
+func ManifestJars(data []byte) []string {
+	var jars []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Fields(ParseManifest(data)["Class-Path"]) {
+		if !strings.HasSuffix(strings.ToLower(entry), ".jar") || strings.Contains(entry, ":") {
+			continue
+		}
+		jar := "WEB-INF/lib/" + path.Base(entry)
+		if !seen[jar] {
+			seen[jar] = true
+			jars = append(jars, jar)
+		}
+	}
+	return jars
+}
@@ -0,0 +1,131 @@
+package webinf
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseWebXML(t *testing.T) {
+	desc := ParseWebXML(readFixture(t, "web.xml"))
+
+	// 框架自身的类被排除
+	wantClasses := []string{"com.example.web.StartupListener", "com.example.web.LoginServlet", "com.example.web.AuthFilter"}
+	if !reflect.DeepEqual(desc.Classes, wantClasses) {
+		t.Errorf("Classes = %q, want %q", desc.Classes, wantClasses)
+	}
+	// contextConfigLocation 中带通配符的路径被忽略，DispatcherServlet 和 Struts 2 使用默认配置
+	wantConfigs := []string{
+		"WEB-INF/classes/spring/applicationContext.xml",
+		"WEB-INF/spring-security.xml",
+		"WEB-INF/springmvc-servlet.xml",
+		"WEB-INF/struts-config.xml",
+		"WEB-INF/classes/struts.xml",
+	}
+	if !reflect.DeepEqual(desc.Configs, wantConfigs) {
+		t.Errorf("Configs = %q, want %q", desc.Configs, wantConfigs)
+	}
+
+	// 没有 contextConfigLocation 时 ContextLoaderListener 读取默认的 applicationContext.xml
+	desc = ParseWebXML([]byte(`<web-app><listener><listener-class>org.springframework.web.context.ContextLoaderListener</listener-class></listener></web-app>`))
+	if !reflect.DeepEqual(desc.Configs, []string{"WEB-INF/applicationContext.xml"}) || len(desc.Classes) != 0 {
+		t.Errorf("ParseWebXML = %+v", desc)
+	}
+
+	// 无法解析的 XML 仍然提取类名
+	desc = ParseWebXML([]byte(`<web-app><servlet><servlet-class>com.example.A</servlet-class>`))
+	if !reflect.DeepEqual(desc.Classes, []string{"com.example.A"}) {
+		t.Errorf("Classes = %q", desc.Classes)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		file    string
+		name    string
+		classes []string
+		configs []string
+	}{
+		{"applicationContext.xml", "WEB-INF/classes/spring/applicationContext.xml",
+			[]string{"com.example.service.UserServiceImpl", "com.example.util.Crypto$Inner"},
+			[]string{"WEB-INF/classes/dao.xml", "WEB-INF/classes/spring/service.xml", "WEB-INF/other.xml", "WEB-INF/classes/mappers/UserMapper.xml"}},
+		{"struts.xml", "WEB-INF/classes/struts.xml",
+			[]string{"com.example.interceptor.AuthInterceptor", "com.example.action.LoginAction"},
+			[]string{"WEB-INF/classes/struts-admin.xml"}},
+	}
+	for _, tt := range tests {
+		desc := ParseConfig(tt.name, readFixture(t, tt.file))
+		if !reflect.DeepEqual(desc.Classes, tt.classes) {
+			t.Errorf("%s: Classes = %q, want %q", tt.file, desc.Classes, tt.classes)
+		}
+		if !reflect.DeepEqual(desc.Configs, tt.configs) {
+			t.Errorf("%s: Configs = %q, want %q", tt.file, desc.Configs, tt.configs)
+		}
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	tests := []struct {
+		from, ref, want string
+	}{
+		{"WEB-INF/web.xml", "classpath:spring/a.xml", "WEB-INF/classes/spring/a.xml"},
+		{"WEB-INF/web.xml", "classpath*:a.xml", "WEB-INF/classes/a.xml"},
+		{"WEB-INF/web.xml", "/WEB-INF/a.xml", "WEB-INF/a.xml"},
+		{"WEB-INF/struts-config.xml", "tiles.xml", "WEB-INF/tiles.xml"},
+		{"WEB-INF/classes/spring/a.xml", "b.xml", "WEB-INF/classes/b.xml"},
+		{"WEB-INF/web.xml", "../../../etc/a.xml", "etc/a.xml"},
+		{"WEB-INF/web.xml", "classpath*:mappers/*.xml", ""},
+		{"WEB-INF/web.xml", "${config}.xml", ""},
+		{"WEB-INF/web.xml", "http://example.com/a.xml", ""},
+		{"WEB-INF/web.xml", "  ", ""},
+	}
+	for _, tt := range tests {
+		if got := ResolveConfig(tt.from, tt.ref); got != tt.want {
+			t.Errorf("ResolveConfig(%q, %q) = %q, want %q", tt.from, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestManifestJars(t *testing.T) {
+	data := readFixture(t, "MANIFEST.MF")
+	attrs := ParseManifest(data)
+	if attrs["Manifest-Version"] != "1.0" || attrs["Built-By"] != "jenkins" {
+		t.Errorf("ParseManifest = %q", attrs)
+	}
+
+	// 续行拼接后去重，跳过 URL 和非 jar 文件
+	want := []string{"WEB-INF/lib/commons-lang3-3.12.0.jar", "WEB-INF/lib/app-core-1.0.jar", "WEB-INF/lib/app-util.jar"}
+	if got := ManifestJars(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("ManifestJars = %q, want %q", got, want)
+	}
+	if got := ManifestJars([]byte("Manifest-Version: 1.0\n")); got != nil {
+		t.Errorf("ManifestJars = %q, want nil", got)
+	}
+}
+
+func TestClassAndMavenPath(t *testing.T) {
+	if got := ClassPath("com.example.util.Crypto$Inner"); got != "WEB-INF/classes/com/example/util/Crypto$Inner.class" {
+		t.Errorf("ClassPath = %q", got)
+	}
+
+	tests := map[string]string{
+		"WEB-INF/classes/com/example/A.class": "maven/target/classes/com/example/A.class",
+		"WEB-INF/classes/spring/a.xml":        "maven/src/main/resources/spring/a.xml",
+		"WEB-INF/lib/app-core-1.0.jar":        "maven/lib/app-core-1.0.jar",
+		"WEB-INF/web.xml":                     "maven/src/main/webapp/WEB-INF/web.xml",
+		"META-INF/MANIFEST.MF":                "maven/src/main/webapp/META-INF/MANIFEST.MF",
+	}
+	for name, want := range tests {
+		if got := MavenPath(name); got != want {
+			t.Errorf("MavenPath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package webinf 实现 Java Web 应用 WEB-INF 目录泄露的深度提取
+package webinf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"dumpall-go/internal/dumper"
+	"dumpall-go/internal/filter"
+	"dumpall-go/internal/httpclient"
+	"dumpall-go/internal/soft404"
+)
+
+// 整理后的 Maven 目录结构保存在输出目录下的该子目录中
+const mavenDir = "maven"
+
+// 最多解析的配置文件和下载的类文件数量
+const (
+	maxConfigs = 500
+	maxClasses = 10000
+)
+
+// 文件头
+var (
+	classMagic = []byte{0xca, 0xfe, 0xba, 0xbe}
+	zipMagic   = []byte("PK\x03\x04")
+)
+
+// WebInfDumper 根据 web.xml 下载类文件、配置文件和 jar 包，并整理为 Maven 目录结构
+type WebInfDumper struct {
+	dumper.BaseDumper
+	// Filter 下载类文件和 jar 包时的过滤条件
+	Filter *filter.Filter
+	// Paths 记录下载的文件路径
+	Paths *dumper.PathSet
+}
+
+// NewWebInfDumper 创建 WebInfDumper 实例
+func NewWebInfDumper() *WebInfDumper {
+	return &WebInfDumper{
+		BaseDumper: dumper.BaseDumper{
+			Name:        "webinf",
+			Description: "根据 WEB-INF/web.xml 下载 Java Web 应用",
+		},
+	}
+}
+
+// extractState 记录一次提取的状态，并发下载时 fetched 由 mu 保护
+type extractState struct {
+	client     *http.Client
+	baseline   *soft404.Baseline
+	baseURL    string
+	outdir     string
+	jars       []string
+	progressCb dumper.ProgressCallback
+
+	mu      sync.Mutex
+	fetched map[string]bool
+}
+
+// claim 标记文件已处理，文件已经处理过时返回 false
+func (s *extractState) claim(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fetched[name] {
+		return false
+	}
+	s.fetched[name] = true
+	return true
+}
+
+// Check 检查目标是否存在 WEB-INF/web.xml 泄露
+func (d *WebInfDumper) Check(targetURL string, client *http.Client) (bool, error) {
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+	data, ok := dumper.Fetch(client, baseline, targetURL+"WEB-INF/web.xml", 0, nil)
+	return ok && looksLikeXML(data, "<web-app"), nil
+}
+
+// Execute 执行下载操作
+func (d *WebInfDumper) Execute(targetURL string, outdir string, proxy string, force bool, debug bool, workers int, progressCb dumper.ProgressCallback) error {
+	// 创建HTTP客户端
+	client, err := httpclient.New(proxy)
+	if err != nil {
+		return err
+	}
+
+	// 确保URL以/结尾
+	if !strings.HasSuffix(targetURL, "/") {
+		targetURL += "/"
+	}
+
+	baseline := soft404.Calibrate(client, targetURL)
+
+	// web.xml 不存在时认为不存在 WEB-INF 泄露
+	webXML, ok := dumper.Fetch(client, baseline, targetURL+"WEB-INF/web.xml", 0, progressCb)
+	if !ok || !looksLikeXML(webXML, "<web-app") {
+		return nil
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outdir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	state := &extractState{
+		client:     client,
+		baseline:   baseline,
+		baseURL:    targetURL,
+		outdir:     outdir,
+		fetched:    map[string]bool{"WEB-INF/web.xml": true},
+		progressCb: progressCb,
+	}
+	d.save(state, "WEB-INF/web.xml", webXML)
+
+	desc := ParseWebXML(webXML)
+	classes := desc.Classes
+	configs := desc.Configs
+
+	// 依次解析 Spring、Struts 等配置文件，配置中引用的其他配置加入队列
+	configs = append(configs, "WEB-INF/classes/struts.xml", "WEB-INF/struts-config.xml", "WEB-INF/applicationContext.xml")
+	for i := 0; i < len(configs) && i < maxConfigs; i++ {
+		name := configs[i]
+		if !state.claim(name) {
+			continue
+		}
+
+		data, ok := dumper.Fetch(client, baseline, targetURL+dumper.EscapePath(name), 0, progressCb)
+		if !ok || !looksLikeXML(data, "<") {
+			continue
+		}
+		d.save(state, name, data)
+
+		sub := ParseConfig(name, data)
+		classes = append(classes, sub.Classes...)
+		configs = append(configs, sub.Configs...)
+	}
+
+	pool := dumper.NewPool(workers)
+
+	// 下载类文件
+	for i, class := range classes {
+		if i >= maxClasses {
+			break
+		}
+		pool.Go(func() {
+			d.download(state, ClassPath(class), classMagic)
+		})
+	}
+
+	// 下载 MANIFEST.MF 中引用的 jar 包，按 MANIFEST.MF 中的顺序记录下载成功的 jar 包
+	var jars []string
+	var jarOK []bool
+	if manifest, ok := dumper.Fetch(client, baseline, targetURL+"META-INF/MANIFEST.MF", 0, progressCb); ok && bytes.Contains(manifest, []byte("Manifest-Version")) {
+		d.save(state, "META-INF/MANIFEST.MF", manifest)
+		jars = ManifestJars(manifest)
+		jarOK = make([]bool, len(jars))
+		for i, jar := range jars {
+			pool.Go(func() {
+				jarOK[i] = d.download(state, jar, zipMagic)
+			})
+		}
+	}
+	pool.Wait()
+	for i, jar := range jars {
+		if jarOK[i] {
+			state.jars = append(state.jars, jar)
+		}
+	}
+
+	// 生成可以导入 IDE 或反编译工具的 pom.xml
+	if _, err := dumper.SaveFile(outdir, mavenDir+"/pom.xml", strings.NewReader(pom(state.jars))); err != nil && progressCb != nil {
+		progressCb(targetURL, 0, "写入失败")
+	}
+
+	return nil
+}
+
+// download 流式下载类文件或 jar 包，文件头不匹配时认为是自定义404页面
+func (d *WebInfDumper) download(state *extractState, name string, magic []byte) bool {
+	if !state.claim(name) {
+		return false
+	}
+
+	if !d.Filter.Match(filter.Entry{Path: name, Size: -1}) {
+		return false
+	}
+
+	fileURL := state.baseURL + dumper.EscapePath(name)
+	isMagic := func(head []byte) bool { return bytes.HasPrefix(head, magic) }
+	if !dumper.DownloadStreamCheck(state.client, state.baseline, d.Filter, fileURL, state.outdir, name, isMagic, state.progressCb) {
+		return false
+	}
+	d.Paths.Add(name)
+
+	// 从已保存的文件复制到 maven 目录，不把整个 jar 包读入内存
+	localPath, err := dumper.SafePath(state.outdir, name)
+	if err == nil {
+		var f *os.File
+		if f, err = os.Open(localPath); err == nil {
+			_, err = dumper.SaveFile(state.outdir, MavenPath(name), f)
+			f.Close()
+		}
+	}
+	if err != nil && state.progressCb != nil {
+		state.progressCb(fileURL, 0, "写入失败")
+	}
+	return true
+}
+
+// save 按原始路径保存文件，并在 maven 目录下保存一份
+func (d *WebInfDumper) save(state *extractState, name string, data []byte) {
+	d.Paths.Add(name)
+	for _, p := range []string{name, MavenPath(name)} {
+		localPath, err := dumper.SaveFile(state.outdir, p, bytes.NewReader(data))
+		if state.progressCb == nil {
+			continue
+		}
+		if err != nil {
+			state.progressCb(state.baseURL+dumper.EscapePath(name), 0, "写入失败")
+		} else if p == name {
+			state.progressCb(state.baseURL+dumper.EscapePath(name), http.StatusOK, localPath)
+		}
+	}
+}
+
+// MavenPath 返回文件在 Maven 目录结构中的路径
+// 类文件保存到 target/classes，classpath 资源保存到 src/main/resources，jar 包保存到 lib
+func MavenPath(name string) string {
+	switch {
+	case strings.HasPrefix(name, "WEB-INF/classes/") && strings.HasSuffix(name, ".class"):
+		return mavenDir + "/target/classes/" + strings.TrimPrefix(name, "WEB-INF/classes/")
+	case strings.HasPrefix(name, "WEB-INF/classes/"):
+		return mavenDir + "/src/main/resources/" + strings.TrimPrefix(name, "WEB-INF/classes/")
+	case strings.HasPrefix(name, "WEB-INF/lib/"):
+		return mavenDir + "/lib/" + path.Base(name)
+	default:
+		return mavenDir + "/src/main/webapp/" + name
+	}
+}
+
+// pom 生成 war 工程的 pom.xml，下载到的 jar 包作为 system 依赖
+func pom(jars []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>dumpall</groupId>
+  <artifactId>webapp</artifactId>
+  <version>1.0</version>
+  <packaging>war</packaging>
+  <dependencies>
+`)
+	for _, jar := range jars {
+		name := strings.TrimSuffix(path.Base(jar), ".jar")
+		fmt.Fprintf(&b, `    <dependency>
+      <groupId>local</groupId>
+      <artifactId>%s</artifactId>
+      <version>1.0</version>
+      <scope>system</scope>
+      <systemPath>${project.basedir}/lib/%s.jar</systemPath>
+    </dependency>
+`, xmlEscape(name), xmlEscape(name))
+	}
+	b.WriteString("  </dependencies>\n</project>\n")
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// looksLikeXML 粗略检查内容是否为 XML，排除返回 HTML 的自定义404页面
+func looksLikeXML(data []byte, marker string) bool {
+	head := bytes.ToLower(bytes.TrimSpace(data))
+	if bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html")) {
+		return false
+	}
+	return bytes.HasPrefix(head, []byte("<")) && bytes.Contains(data, []byte(marker))
+}
+
+// Validate 验证URL是否有效
+func (d *WebInfDumper) Validate(url string) error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package webinf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"dumpall-go/internal/dumper"
+)
+
+func TestExecute(t *testing.T) {
+	class := "\xca\xfe\xba\xbe\x00\x00\x00\x34"
+	// jar 包大于流式下载先检查的长度
+	jar := "PK\x03\x04" + strings.Repeat("j", 2<<20)
+	files := map[string]string{
+		"/app/WEB-INF/web.xml":                                           string(readFixture(t, "web.xml")),
+		"/app/WEB-INF/classes/spring/applicationContext.xml":             string(readFixture(t, "applicationContext.xml")),
+		"/app/META-INF/MANIFEST.MF":                                      string(readFixture(t, "MANIFEST.MF")),
+		"/app/WEB-INF/classes/com/example/web/LoginServlet.class":        class,
+		"/app/WEB-INF/classes/com/example/service/UserServiceImpl.class": class,
+		"/app/WEB-INF/classes/com/example/util/Crypto$Inner.class":       class,
+		"/app/WEB-INF/lib/app-core-1.0.jar":                              jar,
+		// 文件头不匹配的200响应是自定义错误页面
+		"/app/WEB-INF/classes/com/example/web/AuthFilter.class": "<html><body>Not Found</body></html>",
+		"/app/WEB-INF/lib/commons-lang3-3.12.0.jar":             "file not found",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, ok := files[r.URL.Path]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	d := NewWebInfDumper()
+	d.Paths = dumper.NewPathSet()
+	outdir := t.TempDir()
+	if err := d.Execute(srv.URL+"/app", outdir, "", false, false, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []string
+	filepath.WalkDir(outdir, func(p string, e os.DirEntry, err error) error {
+		if err == nil && !e.IsDir() {
+			rel, _ := filepath.Rel(outdir, p)
+			saved = append(saved, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(saved)
+	want := []string{
+		"META-INF/MANIFEST.MF",
+		"WEB-INF/classes/com/example/service/UserServiceImpl.class",
+		"WEB-INF/classes/com/example/util/Crypto$Inner.class",
+		"WEB-INF/classes/com/example/web/LoginServlet.class",
+		"WEB-INF/classes/spring/applicationContext.xml",
+		"WEB-INF/lib/app-core-1.0.jar",
+		"WEB-INF/web.xml",
+		"maven/lib/app-core-1.0.jar",
+		"maven/pom.xml",
+		"maven/src/main/resources/spring/applicationContext.xml",
+		"maven/src/main/webapp/META-INF/MANIFEST.MF",
+		"maven/src/main/webapp/WEB-INF/web.xml",
+		"maven/target/classes/com/example/service/UserServiceImpl.class",
+		"maven/target/classes/com/example/util/Crypto$Inner.class",
+		"maven/target/classes/com/example/web/LoginServlet.class",
+	}
+	if strings.Join(saved, "\n") != strings.Join(want, "\n") {
+		t.Errorf("saved = %q, want %q", saved, want)
+	}
+
+	for _, name := range []string{"WEB-INF/lib/app-core-1.0.jar", "maven/lib/app-core-1.0.jar"} {
+		if data, _ := os.ReadFile(filepath.Join(outdir, name)); string(data) != jar {
+			t.Errorf("%s 没有完整保存: %d 字节", name, len(data))
+		}
+	}
+	pom, _ := os.ReadFile(filepath.Join(outdir, "maven/pom.xml"))
+	if !strings.Contains(string(pom), "<artifactId>app-core-1.0</artifactId>") || strings.Contains(string(pom), "commons-lang3") {
+		t.Errorf("pom.xml 的依赖不正确:\n%s", pom)
+	}
+}
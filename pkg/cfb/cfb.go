@@ -0,0 +1,346 @@
+// Package cfb 实现一个只读的 OLE2 复合文档 (Compound File Binary) 解析器，用于读取 Thumbs.db 等泄露的文件
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+	"unicode/utf16"
+)
+
+// Magic 是复合文档的文件头
+const Magic = "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"
+
+// 特殊的扇区编号
+const (
+	maxRegSect = 0xfffffffa
+	endOfChain = 0xfffffffe
+	freeSect   = 0xffffffff
+	noStream   = 0xffffffff
+)
+
+// 目录项类型
+const (
+	TypeUnknown = 0
+	TypeStorage = 1
+	TypeStream  = 2
+	TypeRoot    = 5
+)
+
+const (
+	headerSize   = 512
+	dirEntrySize = 128
+	// 读取扇区链和遍历目录树时的上限，防止损坏的文件造成死循环
+	maxChainLength = 1 << 24
+	maxTreeDepth   = 64
+)
+
+// Entry 表示复合文档中的一个目录项
+type Entry struct {
+	Name    string    // 名称
+	Path    string    // 以 / 分隔的完整路径
+	Type    int       // 类型: TypeStorage, TypeStream 或 TypeRoot
+	Size    int64     // 流的长度
+	ModTime time.Time // 修改时间，流通常没有该字段
+
+	start uint32
+	left  uint32
+	right uint32
+	child uint32
+}
+
+// File 表示一个已加载到内存的复合文档
+type File struct {
+	data         []byte
+	sectorSize   int
+	miniSize     int
+	miniCutoff   int64
+	fat          []uint32
+	miniFAT      []uint32
+	miniStream   []byte
+	entries      []*Entry
+	streamByPath map[string]*Entry
+}
+
+// Open 从内存数据中打开复合文档
+func Open(data []byte) (*File, error) {
+	if len(data) < headerSize || string(data[:8]) != Magic {
+		return nil, fmt.Errorf("无效的复合文档")
+	}
+	if binary.LittleEndian.Uint16(data[0x1c:]) != 0xfffe {
+		return nil, fmt.Errorf("无效的字节序标记")
+	}
+
+	sectorShift := binary.LittleEndian.Uint16(data[0x1e:])
+	miniShift := binary.LittleEndian.Uint16(data[0x20:])
+	if sectorShift != 9 && sectorShift != 12 {
+		return nil, fmt.Errorf("无效的扇区大小: %d", sectorShift)
+	}
+	if miniShift >= sectorShift {
+		return nil, fmt.Errorf("无效的短扇区大小: %d", miniShift)
+	}
+
+	f := &File{
+		data:         data,
+		sectorSize:   1 << sectorShift,
+		miniSize:     1 << miniShift,
+		miniCutoff:   int64(binary.LittleEndian.Uint32(data[0x38:])),
+		streamByPath: make(map[string]*Entry),
+	}
+
+	if err := f.readFAT(); err != nil {
+		return nil, err
+	}
+
+	miniFAT, err := f.chain(binary.LittleEndian.Uint32(data[0x3c:]), -1)
+	if err != nil {
+		return nil, fmt.Errorf("读取短扇区分配表失败: %v", err)
+	}
+	f.miniFAT = toUint32s(miniFAT)
+
+	if err := f.readDirectory(binary.LittleEndian.Uint32(data[0x30:])); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// sector 返回扇区的数据，扇区超出文件范围时返回 nil
+func (f *File) sector(n uint32) []byte {
+	if n > maxRegSect {
+		return nil
+	}
+	start := (int64(n) + 1) * int64(f.sectorSize)
+	end := start + int64(f.sectorSize)
+	if end > int64(len(f.data)) {
+		// 文件末尾的扇区可能不完整
+		if start >= int64(len(f.data)) {
+			return nil
+		}
+		return f.data[start:]
+	}
+	return f.data[start:end]
+}
+
+// readFAT 根据文件头和 DIFAT 扇区读取扇区分配表
+func (f *File) readFAT() error {
+	numFAT := int(binary.LittleEndian.Uint32(f.data[0x2c:]))
+	var fatSectors []uint32
+	for i := 0; i < 109 && len(fatSectors) < numFAT; i++ {
+		fatSectors = append(fatSectors, binary.LittleEndian.Uint32(f.data[0x4c+i*4:]))
+	}
+
+	// 超过 109 个 FAT 扇区时，其余的记录在 DIFAT 扇区链中，每个扇区最后 4 字节指向下一个 DIFAT 扇区
+	next := binary.LittleEndian.Uint32(f.data[0x44:])
+	seen := make(map[uint32]bool)
+	for next <= maxRegSect && len(fatSectors) < numFAT {
+		if seen[next] {
+			return fmt.Errorf("DIFAT 扇区链存在循环")
+		}
+		seen[next] = true
+		sec := f.sector(next)
+		if len(sec) < f.sectorSize {
+			return fmt.Errorf("DIFAT 扇区超出文件范围")
+		}
+		per := f.sectorSize/4 - 1
+		for i := 0; i < per && len(fatSectors) < numFAT; i++ {
+			fatSectors = append(fatSectors, binary.LittleEndian.Uint32(sec[i*4:]))
+		}
+		next = binary.LittleEndian.Uint32(sec[per*4:])
+	}
+
+	for _, n := range fatSectors {
+		sec := f.sector(n)
+		if sec == nil {
+			return fmt.Errorf("FAT 扇区超出文件范围: %d", n)
+		}
+		f.fat = append(f.fat, toUint32s(sec)...)
+	}
+	return nil
+}
+
+// chain 读取从 start 开始的扇区链，size 小于 0 时读取整条链
+func (f *File) chain(start uint32, size int64) ([]byte, error) {
+	var buf bytes.Buffer
+	seen := make(map[uint32]bool)
+	for n := start; n != endOfChain && n != freeSect; {
+		if seen[n] || len(seen) > maxChainLength {
+			return nil, fmt.Errorf("扇区链存在循环")
+		}
+		seen[n] = true
+
+		sec := f.sector(n)
+		if sec == nil || int(n) >= len(f.fat) {
+			return nil, fmt.Errorf("扇区超出文件范围: %d", n)
+		}
+		buf.Write(sec)
+		if size >= 0 && int64(buf.Len()) >= size {
+			break
+		}
+		n = f.fat[n]
+	}
+
+	data := buf.Bytes()
+	if size >= 0 {
+		if int64(len(data)) < size {
+			return nil, fmt.Errorf("流数据不完整")
+		}
+		data = data[:size]
+	}
+	return data, nil
+}
+
+// miniChain 从短扇区流中读取从 start 开始的短扇区链
+func (f *File) miniChain(start uint32, size int64) ([]byte, error) {
+	var buf bytes.Buffer
+	seen := make(map[uint32]bool)
+	for n := start; n != endOfChain && n != freeSect && int64(buf.Len()) < size; {
+		if seen[n] || int(n) >= len(f.miniFAT) {
+			return nil, fmt.Errorf("短扇区链无效")
+		}
+		seen[n] = true
+
+		off := int64(n) * int64(f.miniSize)
+		end := off + int64(f.miniSize)
+		if end > int64(len(f.miniStream)) {
+			return nil, fmt.Errorf("短扇区超出范围: %d", n)
+		}
+		buf.Write(f.miniStream[off:end])
+		n = f.miniFAT[n]
+	}
+
+	if int64(buf.Len()) < size {
+		return nil, fmt.Errorf("流数据不完整")
+	}
+	return buf.Bytes()[:size], nil
+}
+
+// readDirectory 读取目录扇区链并构建目录树
+func (f *File) readDirectory(start uint32) error {
+	dir, err := f.chain(start, -1)
+	if err != nil {
+		return fmt.Errorf("读取目录失败: %v", err)
+	}
+
+	for off := 0; off+dirEntrySize <= len(dir); off += dirEntrySize {
+		e := parseEntry(dir[off : off+dirEntrySize])
+		// 版本 3 (512 字节扇区) 的文件只使用长度的低 32 位
+		if f.sectorSize == 512 {
+			e.Size &= 0xffffffff
+		}
+		f.entries = append(f.entries, e)
+	}
+	if len(f.entries) == 0 || f.entries[0].Type != TypeRoot {
+		return fmt.Errorf("缺少根目录项")
+	}
+
+	// 根目录项的起始扇区和长度描述短扇区流
+	root := f.entries[0]
+	if root.Size > 0 {
+		if f.miniStream, err = f.chain(root.start, root.Size); err != nil {
+			return fmt.Errorf("读取短扇区流失败: %v", err)
+		}
+	}
+
+	root.Path = ""
+	f.walk(root.child, "", 0, make(map[uint32]bool))
+	return nil
+}
+
+// walk 按中序遍历同级目录项组成的红黑树，并递归子存储
+func (f *File) walk(id uint32, parent string, depth int, seen map[uint32]bool) {
+	if id == noStream || int(id) >= len(f.entries) || seen[id] || depth > maxTreeDepth {
+		return
+	}
+	seen[id] = true
+
+	e := f.entries[id]
+	f.walk(e.left, parent, depth+1, seen)
+
+	e.Path = e.Name
+	if parent != "" {
+		e.Path = parent + "/" + e.Name
+	}
+	if e.Type == TypeStream {
+		f.streamByPath[e.Path] = e
+	}
+	if e.Type == TypeStorage {
+		f.walk(e.child, e.Path, depth+1, seen)
+	}
+
+	f.walk(e.right, parent, depth+1, seen)
+}
+
+// parseEntry 解析 128 字节的目录项
+func parseEntry(b []byte) *Entry {
+	nameLen := int(binary.LittleEndian.Uint16(b[64:]))
+	if nameLen > 64 {
+		nameLen = 64
+	}
+	u := make([]uint16, 0, nameLen/2)
+	for i := 0; i+1 < nameLen; i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+
+	return &Entry{
+		Name:    string(utf16.Decode(u)),
+		Type:    int(b[66]),
+		left:    binary.LittleEndian.Uint32(b[68:]),
+		right:   binary.LittleEndian.Uint32(b[72:]),
+		child:   binary.LittleEndian.Uint32(b[76:]),
+		ModTime: Filetime(binary.LittleEndian.Uint64(b[108:])),
+		start:   binary.LittleEndian.Uint32(b[116:]),
+		Size:    int64(binary.LittleEndian.Uint64(b[120:])),
+	}
+}
+
+// Entries 返回所有存储和流，按目录项的顺序排列
+func (f *File) Entries() []Entry {
+	var entries []Entry
+	for _, e := range f.entries[1:] {
+		if e.Path != "" && (e.Type == TypeStorage || e.Type == TypeStream) {
+			entries = append(entries, *e)
+		}
+	}
+	return entries
+}
+
+// ReadStream 读取指定路径的流
+func (f *File) ReadStream(path string) ([]byte, error) {
+	e, ok := f.streamByPath[path]
+	if !ok {
+		return nil, fmt.Errorf("流不存在: %s", path)
+	}
+	if e.Size < 0 || e.Size > int64(len(f.data)) {
+		return nil, fmt.Errorf("流长度异常: %d", e.Size)
+	}
+	if e.Size < f.miniCutoff {
+		return f.miniChain(e.start, e.Size)
+	}
+	return f.chain(e.start, e.Size)
+}
+
+// Filetime 将 Windows FILETIME (1601-01-01 起的 100 纳秒数) 转换为时间，0 返回零值
+func Filetime(ft uint64) time.Time {
+	if ft == 0 {
+		return time.Time{}
+	}
+	const epochDiff = 116444736000000000
+	if ft < epochDiff {
+		return time.Time{}
+	}
+	ft -= epochDiff
+	return time.Unix(int64(ft/1e7), int64(ft%1e7)*100)
+}
+
+func toUint32s(b []byte) []uint32 {
+	out := make([]uint32, len(b)/4)
+	for i := range out {
+		out[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return out
+}
@@ -0,0 +1,237 @@
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// 测试文件的布局: 扇区 0 为 FAT，扇区 1 为目录，扇区 2 为短扇区分配表，扇区 3 为短扇区流
+// 短扇区流中保存一个小于 4096 字节的 Catalog 流，扇区 4 之后保存一个普通扇区链上的流
+const (
+	fatOffset     = 512 * 1
+	dirOffset     = 512 * 2
+	miniFATOffset = 512 * 3
+)
+
+type testFile struct {
+	small   []byte // 保存在短扇区流中的 Catalog
+	big     []byte // 保存在普通扇区中的流
+	bigName string // 普通扇区中的流的名称，默认为 Big
+}
+
+func (tf testFile) build() []byte {
+	bigSectors := (len(tf.big) + 511) / 512
+	data := make([]byte, 512*(5+bigSectors))
+
+	// 文件头
+	copy(data, Magic)
+	binary.LittleEndian.PutUint16(data[0x18:], 0x3e)
+	binary.LittleEndian.PutUint16(data[0x1a:], 3)
+	binary.LittleEndian.PutUint16(data[0x1c:], 0xfffe)
+	binary.LittleEndian.PutUint16(data[0x1e:], 9)
+	binary.LittleEndian.PutUint16(data[0x20:], 6)
+	binary.LittleEndian.PutUint32(data[0x2c:], 1)
+	binary.LittleEndian.PutUint32(data[0x30:], 1)
+	binary.LittleEndian.PutUint32(data[0x38:], 4096)
+	binary.LittleEndian.PutUint32(data[0x3c:], 2)
+	binary.LittleEndian.PutUint32(data[0x40:], 1)
+	binary.LittleEndian.PutUint32(data[0x44:], endOfChain)
+	for i := 0; i < 109; i++ {
+		binary.LittleEndian.PutUint32(data[0x4c+i*4:], freeSect)
+	}
+	binary.LittleEndian.PutUint32(data[0x4c:], 0)
+
+	// FAT
+	fat := data[fatOffset : fatOffset+512]
+	for i := 0; i < 128; i++ {
+		binary.LittleEndian.PutUint32(fat[i*4:], freeSect)
+	}
+	binary.LittleEndian.PutUint32(fat[0:], 0xfffffffd)
+	binary.LittleEndian.PutUint32(fat[4:], endOfChain)
+	binary.LittleEndian.PutUint32(fat[8:], endOfChain)
+	binary.LittleEndian.PutUint32(fat[12:], endOfChain)
+	for i := 0; i < bigSectors; i++ {
+		next := uint32(5 + i)
+		if i == bigSectors-1 {
+			next = endOfChain
+		}
+		binary.LittleEndian.PutUint32(fat[(4+i)*4:], next)
+	}
+
+	// 短扇区分配表和短扇区流
+	miniSectors := (len(tf.small) + 63) / 64
+	miniFAT := data[miniFATOffset : miniFATOffset+512]
+	for i := 0; i < 128; i++ {
+		binary.LittleEndian.PutUint32(miniFAT[i*4:], freeSect)
+	}
+	for i := 0; i < miniSectors; i++ {
+		next := uint32(i + 1)
+		if i == miniSectors-1 {
+			next = endOfChain
+		}
+		binary.LittleEndian.PutUint32(miniFAT[i*4:], next)
+	}
+	copy(data[512*4:], tf.small)
+	copy(data[512*5:], tf.big)
+
+	// 目录: 根目录项的子节点为 Catalog，Catalog 的右兄弟为 Big
+	dir := data[dirOffset : dirOffset+512]
+	writeEntry(dir[0:], "Root Entry", TypeRoot, noStream, noStream, 1, 3, int64(miniSectors*64))
+	writeEntry(dir[128:], "Catalog", TypeStream, noStream, 2, noStream, 0, int64(len(tf.small)))
+	bigName := tf.bigName
+	if bigName == "" {
+		bigName = "Big"
+	}
+	writeEntry(dir[256:], bigName, TypeStream, noStream, noStream, noStream, 4, int64(len(tf.big)))
+	writeEntry(dir[384:], "", TypeUnknown, noStream, noStream, noStream, 0, 0)
+	binary.LittleEndian.PutUint64(dir[108:], 133485408000000000) // 2024-01-01 00:00:00 UTC
+
+	return data
+}
+
+func writeEntry(b []byte, name string, typ int, left, right, child, start uint32, size int64) {
+	u := utf16.Encode([]rune(name))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[i*2:], c)
+	}
+	if name != "" {
+		binary.LittleEndian.PutUint16(b[64:], uint16(len(u)*2+2))
+	}
+	b[66] = byte(typ)
+	binary.LittleEndian.PutUint32(b[68:], left)
+	binary.LittleEndian.PutUint32(b[72:], right)
+	binary.LittleEndian.PutUint32(b[76:], child)
+	binary.LittleEndian.PutUint32(b[116:], start)
+	binary.LittleEndian.PutUint64(b[120:], uint64(size))
+}
+
+func newTestFile() testFile {
+	return testFile{
+		small: bytes.Repeat([]byte("catalog!"), 20),
+		big:   bytes.Repeat([]byte("0123456789abcdef"), 300),
+	}
+}
+
+func TestOpenAndReadStreams(t *testing.T) {
+	tf := newTestFile()
+	f, err := Open(tf.build())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, e := range f.Entries() {
+		names = append(names, e.Path)
+	}
+	if len(names) != 2 || names[0] != "Catalog" || names[1] != "Big" {
+		t.Fatalf("entries = %v, want [Catalog Big]", names)
+	}
+
+	for path, want := range map[string][]byte{"Catalog": tf.small, "Big": tf.big} {
+		got, err := f.ReadStream(path)
+		if err != nil {
+			t.Fatalf("ReadStream(%s): %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("ReadStream(%s) returned %d bytes, want %d", path, len(got), len(want))
+		}
+	}
+	if _, err := f.ReadStream("Missing"); err == nil {
+		t.Fatal("ReadStream(Missing): expected error")
+	}
+
+	if got := f.entries[0].ModTime; !got.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("root ModTime = %v", got)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	put := func(off int, v uint32) func([]byte) []byte {
+		return func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[off:], v)
+			return b
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+		stream string // 非空时 Open 成功，读取该流时应返回错误
+	}{
+		{"empty", func(b []byte) []byte { return nil }, ""},
+		{"short header", func(b []byte) []byte { return b[:300] }, ""},
+		{"bad magic", func(b []byte) []byte { b[0] = 0; return b }, ""},
+		{"bad byte order", func(b []byte) []byte { b[0x1c] = 0; return b }, ""},
+		{"bad sector shift", func(b []byte) []byte { b[0x1e] = 30; return b }, ""},
+		{"bad mini shift", func(b []byte) []byte { b[0x20] = 9; return b }, ""},
+		{"truncated after FAT", func(b []byte) []byte { return b[:dirOffset] }, ""},
+		{"FAT sector out of range", put(0x4c, 1000), ""},
+		{"directory chain cycle", put(fatOffset+1*4, 1), ""},
+		{"two-sector chain cycle", func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[fatOffset+1*4:], 2)
+			binary.LittleEndian.PutUint32(b[fatOffset+2*4:], 1)
+			return b
+		}, ""},
+		{"DIFAT cycle", func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[0x2c:], 500)
+			binary.LittleEndian.PutUint32(b[0x44:], 4)
+			for i := 1; i < 109; i++ {
+				binary.LittleEndian.PutUint32(b[0x4c+i*4:], 0)
+			}
+			binary.LittleEndian.PutUint32(b[512*6-4:], 4)
+			return b
+		}, ""},
+		{"huge FAT count", put(0x2c, 0xffffffff), ""},
+		{"missing root entry", func(b []byte) []byte { b[dirOffset+66] = TypeStream; return b }, ""},
+		{"mini stream beyond file", put(dirOffset+120, 1<<30), ""},
+		{"big stream cycle", put(fatOffset+5*4, 4), "Big"},
+		{"big stream truncated", func(b []byte) []byte { return b[:512*12] }, "Big"},
+		{"big stream huge size", put(dirOffset+256+120, 0xfffffff0), "Big"},
+		{"mini chain cycle", put(miniFATOffset+1*4, 0), "Catalog"},
+		{"mini chain out of range", put(miniFATOffset+0*4, 100), "Catalog"},
+		{"mini stream too short", put(dirOffset+128+120, 1000), "Catalog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.mutate(newTestFile().build())
+			done := make(chan error, 1)
+			go func() {
+				f, err := Open(data)
+				if err == nil && tt.stream != "" {
+					_, err = f.ReadStream(tt.stream)
+				}
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("did not terminate")
+			}
+		})
+	}
+}
+
+func TestDirectoryTreeCycle(t *testing.T) {
+	// Catalog 的左兄弟指向自己，Big 的右兄弟指回 Catalog，子节点指向根目录项
+	data := newTestFile().build()
+	binary.LittleEndian.PutUint32(data[dirOffset+128+68:], 1)
+	binary.LittleEndian.PutUint32(data[dirOffset+256+72:], 1)
+	binary.LittleEndian.PutUint32(data[dirOffset+256+76:], 0)
+	data[dirOffset+256+66] = TypeStorage
+
+	f, err := Open(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.Entries()); n != 2 {
+		t.Fatalf("got %d entries, want 2", n)
+	}
+}
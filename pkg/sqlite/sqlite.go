@@ -0,0 +1,420 @@
+// Package sqlite 实现一个只读的 SQLite 数据库文件解析器，用于读取 wc.db 等泄露的数据库文件
+package sqlite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Magic 是 SQLite 数据库文件的文件头
+const Magic = "SQLite format 3\x00"
+
+// 遍历 B-Tree 时的最大深度，防止损坏的文件造成死循环
+// 子页指针形成环或重复指向同一页时由已访问页集合终止
+const maxDepth = 32
+
+// DB 表示一个已加载到内存的 SQLite 数据库文件
+type DB struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+// Table 表示 sqlite_master 中的一张表
+type Table struct {
+	Name     string
+	RootPage int
+	SQL      string
+	Columns  []string
+}
+
+// Row 表示表中的一行数据
+type Row struct {
+	RowID  int64
+	Values []interface{}
+}
+
+// Open 从内存数据中打开 SQLite 数据库
+func Open(data []byte) (*DB, error) {
+	if len(data) < 100 || string(data[:16]) != Magic {
+		return nil, fmt.Errorf("无效的 SQLite 文件")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("无效的页大小: %d", pageSize)
+	}
+
+	return &DB{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+	}, nil
+}
+
+// Tables 返回数据库中的所有表
+func (db *DB) Tables() ([]Table, error) {
+	rows, err := db.readTable(1)
+	if err != nil {
+		return nil, fmt.Errorf("读取 sqlite_master 失败: %v", err)
+	}
+
+	var tables []Table
+	for _, row := range rows {
+		if len(row.Values) < 5 {
+			continue
+		}
+		if typ, _ := row.Values[0].(string); typ != "table" {
+			continue
+		}
+		name, _ := row.Values[1].(string)
+		root, _ := row.Values[3].(int64)
+		sql, _ := row.Values[4].(string)
+		tables = append(tables, Table{
+			Name:     name,
+			RootPage: int(root),
+			SQL:      sql,
+			Columns:  parseColumns(sql),
+		})
+	}
+	return tables, nil
+}
+
+// Table 按名称查找表，名称不区分大小写
+func (db *DB) Table(name string) (*Table, error) {
+	tables, err := db.Tables()
+	if err != nil {
+		return nil, err
+	}
+	for i := range tables {
+		if strings.EqualFold(tables[i].Name, name) {
+			return &tables[i], nil
+		}
+	}
+	return nil, fmt.Errorf("表不存在: %s", name)
+}
+
+// Rows 读取表中的所有行
+func (db *DB) Rows(t *Table) ([]Row, error) {
+	return db.readTable(t.RootPage)
+}
+
+// Records 读取表中的所有行并按列名返回
+func (db *DB) Records(name string) ([]map[string]interface{}, error) {
+	t, err := db.Table(name)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Rows(t)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		record := make(map[string]interface{}, len(t.Columns))
+		for i, col := range t.Columns {
+			if i < len(row.Values) {
+				record[col] = row.Values[i]
+			}
+			// INTEGER PRIMARY KEY 列的值保存在 rowid 中
+			if record[col] == nil && i == t.rowIDAlias() {
+				record[col] = row.RowID
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// rowIDAlias 返回作为 rowid 别名的列序号，不存在时返回 -1
+func (t *Table) rowIDAlias() int {
+	for i, def := range splitDefinitions(t.SQL) {
+		upper := strings.ToUpper(def)
+		if strings.Contains(upper, "INTEGER PRIMARY KEY") {
+			return i
+		}
+	}
+	return -1
+}
+
+// page 返回指定页号的数据
+func (db *DB) page(n int) ([]byte, error) {
+	if n < 1 || n > len(db.data)/db.pageSize {
+		return nil, fmt.Errorf("页号越界: %d", n)
+	}
+	start := (n - 1) * db.pageSize
+	return db.data[start : start+db.pageSize], nil
+}
+
+// readTable 遍历表 B-Tree 并返回所有行
+func (db *DB) readTable(root int) ([]Row, error) {
+	var rows []Row
+	err := db.walk(root, 0, make(map[int]bool), func(row Row) {
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+func (db *DB) walk(n int, depth int, visited map[int]bool, fn func(Row)) error {
+	if depth > maxDepth {
+		return fmt.Errorf("B-Tree 层级过深")
+	}
+	if visited[n] {
+		return fmt.Errorf("B-Tree 页面重复引用: %d", n)
+	}
+	visited[n] = true
+
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+
+	// 第一页包含 100 字节的数据库文件头
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+
+	pageType := page[hdr]
+	cellCount := int(binary.BigEndian.Uint16(page[hdr+3:]))
+	if hdr+12+cellCount*2 > len(page) {
+		return fmt.Errorf("单元格数量异常: %d", cellCount)
+	}
+
+	switch pageType {
+	case 0x0d: // 表叶子页
+		pointers := hdr + 8
+		for i := 0; i < cellCount; i++ {
+			off := int(binary.BigEndian.Uint16(page[pointers+i*2:]))
+			row, err := db.readLeafCell(page, off)
+			if err != nil {
+				return err
+			}
+			fn(row)
+		}
+	case 0x05: // 表内部页
+		pointers := hdr + 12
+		for i := 0; i < cellCount; i++ {
+			off := int(binary.BigEndian.Uint16(page[pointers+i*2:]))
+			if off+4 > len(page) {
+				return fmt.Errorf("单元格越界")
+			}
+			child := int(binary.BigEndian.Uint32(page[off:]))
+			if err := db.walk(child, depth+1, visited, fn); err != nil {
+				return err
+			}
+		}
+		right := int(binary.BigEndian.Uint32(page[hdr+8:]))
+		return db.walk(right, depth+1, visited, fn)
+	default:
+		return fmt.Errorf("不支持的页类型: 0x%02x", pageType)
+	}
+
+	return nil
+}
+
+// readLeafCell 读取表叶子页中的单元格，必要时拼接溢出页
+func (db *DB) readLeafCell(page []byte, off int) (Row, error) {
+	if off >= len(page) {
+		return Row{}, fmt.Errorf("单元格越界")
+	}
+
+	payloadSize, n := readVarint(page[off:])
+	off += n
+	rowID, n := readVarint(page[off:])
+	off += n
+
+	if payloadSize > uint64(len(db.data)) {
+		return Row{}, fmt.Errorf("记录长度异常: %d", payloadSize)
+	}
+	total := int(payloadSize)
+
+	// 计算保存在本页内的数据长度
+	u := db.usable
+	x := u - 35
+	local := total
+	if total > x {
+		m := ((u-12)*32)/255 - 23
+		k := m + (total-m)%(u-4)
+		if k <= x {
+			local = k
+		} else {
+			local = m
+		}
+	}
+
+	if off+local > len(page) {
+		return Row{}, fmt.Errorf("单元格越界")
+	}
+	payload := make([]byte, 0, total)
+	payload = append(payload, page[off:off+local]...)
+
+	if local < total {
+		if off+local+4 > len(page) {
+			return Row{}, fmt.Errorf("单元格越界")
+		}
+		next := int(binary.BigEndian.Uint32(page[off+local:]))
+		for len(payload) < total && next != 0 {
+			overflow, err := db.page(next)
+			if err != nil {
+				return Row{}, err
+			}
+			next = int(binary.BigEndian.Uint32(overflow))
+			chunk := overflow[4:u]
+			if remain := total - len(payload); len(chunk) > remain {
+				chunk = chunk[:remain]
+			}
+			payload = append(payload, chunk...)
+		}
+	}
+
+	values, err := decodeRecord(payload)
+	if err != nil {
+		return Row{}, err
+	}
+	return Row{RowID: int64(rowID), Values: values}, nil
+}
+
+// decodeRecord 解码 SQLite 记录格式
+func decodeRecord(payload []byte) ([]interface{}, error) {
+	// 长度在转换为 int 之前检查，避免过大的值溢出为负数
+	headerSize, n := readVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, fmt.Errorf("记录头异常")
+	}
+
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		t, n := readVarint(payload[pos:])
+		if n == 0 {
+			return nil, fmt.Errorf("记录头异常")
+		}
+		types = append(types, t)
+		pos += n
+	}
+
+	values := make([]interface{}, 0, len(types))
+	body := payload[headerSize:]
+	for _, t := range types {
+		var size uint64
+		switch {
+		case t == 0, t == 8, t == 9:
+			size = 0
+		case t <= 4:
+			size = t
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t >= 12:
+			size = (t - 12) / 2
+		default:
+			return nil, fmt.Errorf("未知的数据类型: %d", t)
+		}
+		if size > uint64(len(body)) {
+			return nil, fmt.Errorf("记录数据越界")
+		}
+
+		v := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t == 8:
+			values = append(values, int64(0))
+		case t == 9:
+			values = append(values, int64(1))
+		case t <= 6:
+			// 大端有符号整数
+			var i int64
+			if size > 0 && v[0]&0x80 != 0 {
+				i = -1
+			}
+			for _, b := range v {
+				i = i<<8 | int64(b)
+			}
+			values = append(values, i)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case t%2 == 0:
+			values = append(values, append([]byte(nil), v...))
+		default:
+			values = append(values, string(v))
+		}
+	}
+
+	return values, nil
+}
+
+// readVarint 读取 SQLite 的大端变长整数
+func readVarint(data []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(data); i++ {
+		if i == 8 {
+			return v<<8 | uint64(data[i]), 9
+		}
+		v = v<<7 | uint64(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 0
+}
+
+// parseColumns 从 CREATE TABLE 语句中解析列名
+func parseColumns(sql string) []string {
+	var columns []string
+	for _, def := range splitDefinitions(sql) {
+		columns = append(columns, strings.Trim(strings.Fields(def)[0], "\"`[]"))
+	}
+	return columns
+}
+
+// splitDefinitions 按顶层逗号切分 CREATE TABLE 括号中的列定义
+func splitDefinitions(sql string) []string {
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+
+	var defs []string
+	depth := 0
+	last := start + 1
+	for i := start + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, strings.TrimSpace(sql[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	defs = append(defs, strings.TrimSpace(sql[last:end]))
+
+	// 过滤掉表级约束，使序号与列序号一致
+	var columns []string
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CONSTRAINT", "FOREIGN", "CHECK":
+			continue
+		}
+		columns = append(columns, def)
+	}
+	return columns
+}
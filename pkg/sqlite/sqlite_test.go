@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// newDB 构造只有文件头的数据库，页面内容由调用方填写
+func newDB(pages int) []byte {
+	data := make([]byte, 512*pages)
+	copy(data, Magic)
+	binary.BigEndian.PutUint16(data[16:], 512)
+	return data
+}
+
+// interior 把第 n 页写成表内部页，所有子页指针都指向 children
+func interior(data []byte, n int, right uint32, children ...uint32) {
+	page := data[(n-1)*512 : n*512]
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	page[hdr] = 0x05
+	binary.BigEndian.PutUint16(page[hdr+3:], uint16(len(children)))
+	binary.BigEndian.PutUint32(page[hdr+8:], right)
+	off := 400
+	for i, child := range children {
+		binary.BigEndian.PutUint16(page[hdr+12+i*2:], uint16(off))
+		binary.BigEndian.PutUint32(page[off:], child)
+		off += 4
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() []byte
+	}{
+		{"self", func() []byte {
+			data := newDB(1)
+			interior(data, 1, 1)
+			return data
+		}},
+		{"loop", func() []byte {
+			data := newDB(2)
+			interior(data, 1, 2)
+			interior(data, 2, 1)
+			return data
+		}},
+		{"fan", func() []byte {
+			// 每一层都有多个指针指向下一层的同一页，不去重时遍历次数按层数指数增长
+			data := newDB(30)
+			for n := 1; n < 30; n++ {
+				interior(data, n, uint32(n+1), uint32(n+1), uint32(n+1), uint32(n+1))
+			}
+			interior(data, 30, 1)
+			return data
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := Open(tt.build())
+			if err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan error, 1)
+			go func() {
+				_, err := db.Tables()
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("walk did not terminate")
+			}
+		})
+	}
+}
+
+func TestDecodeRecordMalformed(t *testing.T) {
+	huge := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"empty", nil},
+		{"huge header size", huge},
+		{"header beyond payload", []byte{0x10, 0x01}},
+		{"huge serial type", append([]byte{0x0a}, huge...)},
+		{"blob beyond body", []byte{0x02, 0x20, 'a'}},
+		{"reserved type", []byte{0x02, 0x0a}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeRecord(tt.payload); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestDecodeRecord(t *testing.T) {
+	// 记录头: 长度 4, 类型 NULL、1 字节整数、长度 3 的字符串
+	payload := []byte{0x04, 0x00, 0x01, 0x13, 0xfe, 'a', 'b', 'c'}
+	values, err := decodeRecord(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || values[0] != nil || values[1] != int64(-2) || values[2] != "abc" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+}
+
+func TestPageOutOfRange(t *testing.T) {
+	db, err := Open(newDB(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, -1, 2, 1 << 50} {
+		if _, err := db.page(n); err == nil {
+			t.Fatalf("page %d: expected error", n)
+		}
+	}
+}